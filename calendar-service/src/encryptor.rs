@@ -54,14 +54,14 @@ pub fn encrypt(
 ///
 /// A String
 pub fn decrypt(data: &[u8], key: &[u8; 32], iv: &[u8; 12]) -> Result<String, Box<dyn Error>> {
-    // TODO: Return Result like in encrypt()
     let cipher = Aes256Gcm::new(GenericArray::from_slice(key));
     let nonce = Nonce::from_slice(iv);
 
     let mut buffer = data.to_vec();
+    // Fails if the file was encrypted with another key (e.g. before per-user keys)
     cipher
         .decrypt_in_place(nonce, b"", &mut buffer)
-        .expect("decryption failure!");
+        .map_err(|_| "decryption failure!")?;
 
     let buffer = pkcs5_unpadding(&buffer);
     let decoded = std::str::from_utf8(&buffer)
@@ -179,4 +179,12 @@ mod tests {
         let expected_data = "Hello".to_string();
         assert_eq!(decrypted_data.unwrap(), expected_data, "Decryption failed");
     }
+
+    #[test]
+    fn test_decrypt_with_wrong_key() {
+        let encrypted_data = super::encrypt("Hello".to_string(), KEY, IV).unwrap();
+        let decrypted_data =
+            super::decrypt(&encrypted_data, b"10987654321098765432109876543210", IV);
+        assert!(decrypted_data.is_err(), "Decryption should fail");
+    }
 }
@@ -6,7 +6,9 @@ use std::env;
 use tonic::{transport::Server, Request, Response, Status};
 
 use calendar::calendar_service_server::{CalendarService as Calendar, CalendarServiceServer};
-use calendar::{CreateCalendarRequest, GetCalendarRequest, GetCalendarResponse};
+use calendar::{
+    CreateCalendarRequest, DeleteCalendarRequest, GetCalendarRequest, GetCalendarResponse,
+};
 
 pub mod calendar {
     tonic::include_proto!("calendar");
@@ -27,8 +29,9 @@ impl Calendar for CalendarService {
         }
         let mut iv: [u8; 12] = Default::default();
         iv.copy_from_slice(&request_iv[0..12]);
+        let key = service::calendar::encryption_key(&request.get_ref().calendar_key)?;
 
-        let file = service::calendar::read(request.get_ref().calendar_id.as_str(), &iv);
+        let file = service::calendar::read(request.get_ref().calendar_id.as_str(), &iv, &key);
         match file.is_err() {
             true => Err(Status::internal(file.err().unwrap().to_string())),
             false => {
@@ -50,6 +53,7 @@ impl Calendar for CalendarService {
         }
         let mut iv: [u8; 12] = Default::default();
         iv.copy_from_slice(&request_iv[0..12]);
+        let key = service::calendar::encryption_key(&request.get_ref().calendar_key)?;
 
         let timezone_input = &request.get_ref().timezone.parse::<Tz>();
         let timezone: Tz = match timezone_input.is_err() {
@@ -61,12 +65,28 @@ impl Calendar for CalendarService {
             service::calendar::create(&request.get_ref().calendar_entities.clone(), timezone),
             request.get_ref().calendar_id.as_str(),
             &iv,
+            &key,
         );
         match write_check {
             Ok(_) => Ok(Response::new(())),
             Err(msg) => Err(Status::internal(msg.to_string())),
         }
     }
+
+    async fn delete_calendar(
+        &self,
+        request: Request<DeleteCalendarRequest>,
+    ) -> Result<Response<()>, Status> {
+        let calendar_id = request.get_ref().calendar_id.as_str();
+        if calendar_id.is_empty() || !calendar_id.chars().all(char::is_alphanumeric) {
+            return Err(Status::invalid_argument("Invalid calendar_id value"));
+        }
+
+        match service::calendar::delete(calendar_id) {
+            Ok(_) => Ok(Response::new(())),
+            Err(msg) => Err(Status::internal(msg.to_string())),
+        }
+    }
 }
 
 #[tokio::main]
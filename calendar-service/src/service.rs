@@ -12,17 +12,47 @@ pub mod calendar {
     use std::path::Path;
     use tonic::Status;
 
+    /// It returns the user's key to encrypt the calendar file.
+    /// If the user's key is not provided, `ENCRYPTION_KEY` is used.
+    ///
+    /// Arguments:
+    ///
+    /// * `user_key`: 32 bytes key of the user or an empty slice.
+    ///
+    /// Returns:
+    ///
+    /// AES-256 key or an ([`Err`]) if the key has an invalid length.
+    pub fn encryption_key(user_key: &[u8]) -> Result<[u8; 32], Status> {
+        let mut encryption_key: [u8; 32] = Default::default();
+
+        if user_key.is_empty() {
+            // TODO: Find a better way to get ENV variables in Rust
+            let env_key =
+                env::var("ENCRYPTION_KEY").expect("Expected ENCRYPTION_KEY ENV to be set");
+            encryption_key.copy_from_slice(env_key.as_bytes());
+            return Ok(encryption_key);
+        }
+
+        if user_key.len() != 32 {
+            return Err(Status::invalid_argument("Invalid calendar_key length"));
+        }
+        encryption_key.copy_from_slice(user_key);
+
+        Ok(encryption_key)
+    }
+
     /// Read a file and return the contents as a string
     ///
     /// Arguments:
     ///
     /// * `file_name`: The name of the file to read.
     /// * `iv`: Initialization vector. This is a random value that is used to ensure that the same plaintext
+    /// * `key`: AES-256 key the file was encrypted with.
     ///
     /// Returns:
     ///
     /// A String containing the contents of the file or an ([`Err`]).
-    pub fn read(file_name: &str, iv: &[u8; 12]) -> Result<String, Status> {
+    pub fn read(file_name: &str, iv: &[u8; 12], key: &[u8; 32]) -> Result<String, Status> {
         const FILE_PATH: &str = "data/";
         let binding = FILE_PATH.to_owned() + file_name;
         let path = Path::new(binding.as_str());
@@ -39,12 +69,7 @@ pub mod calendar {
             .read_to_end(&mut file_data)
             .expect("Failed to read file");
 
-        // TODO: Find a better way to get ENV variables in Rust
-        let env_key = env::var("ENCRYPTION_KEY").expect("Expected ENCRYPTION_KEY ENV to be set");
-        let mut encryption_key: [u8; 32] = Default::default();
-        encryption_key.copy_from_slice(env_key.as_bytes());
-
-        let decrypted = decrypt(file_data.as_slice(), &encryption_key, iv);
+        let decrypted = decrypt(file_data.as_slice(), key, iv);
         match decrypted {
             Ok(data) => Ok(data),
             Err(e) => Err(Status::internal(e.to_string())),
@@ -86,11 +111,17 @@ pub mod calendar {
     /// * `data`: String - This is the data that we want to write to the file.
     /// * `file_name`: The name of the file to write to.
     /// * `iv`: Initialization vector. This is a random value that is used to ensure that the same plaintext
+    /// * `key`: AES-256 key to encrypt the file with.
     ///
     /// Returns:
     ///
     /// A Result that either success ([`Ok`]) or failure ([`Err`])
-    pub fn write(data: String, file_name: &str, iv: &[u8; 12]) -> Result<(), Box<dyn Error>> {
+    pub fn write(
+        data: String,
+        file_name: &str,
+        iv: &[u8; 12],
+        key: &[u8; 32],
+    ) -> Result<(), Box<dyn Error>> {
         const FILE_PATH: &str = "data/";
         let path = FILE_PATH.to_owned() + file_name;
         let path = Path::new(&path);
@@ -100,12 +131,7 @@ pub mod calendar {
             std::fs::create_dir_all(parent_folder).unwrap();
         }
 
-        // TODO: Find a better way to get ENV variables in Rust
-        let env_key = env::var("ENCRYPTION_KEY").expect("Expected ENCRYPTION_KEY ENV to be set");
-        let mut encryption_key: [u8; 32] = Default::default();
-        encryption_key.copy_from_slice(env_key.as_bytes());
-
-        let encrypted = encrypt(data, &encryption_key, iv).expect("Encryption failed");
+        let encrypted = encrypt(data, key, iv).expect("Encryption failed");
 
         let mut file = File::create(path).expect("Unable to create file");
         file.write_all(encrypted.as_slice())
@@ -114,6 +140,27 @@ pub mod calendar {
         Ok(())
     }
 
+    /// It deletes the calendar file with the given name, if it exists
+    ///
+    /// Arguments:
+    ///
+    /// * `file_name`: The name of the file to delete.
+    ///
+    /// Returns:
+    ///
+    /// A Result that either success ([`Ok`]) or failure ([`Err`])
+    pub fn delete(file_name: &str) -> Result<(), Box<dyn Error>> {
+        const FILE_PATH: &str = "data/";
+        let path = FILE_PATH.to_owned() + file_name;
+        let path = Path::new(&path);
+
+        if path.exists() {
+            std::fs::remove_file(path)?;
+        }
+
+        Ok(())
+    }
+
     /// It takes a `CalendarEntity` and returns an iCal `Event` with the same data
     ///
     /// Arguments:
@@ -241,7 +288,9 @@ pub mod calendar {
             let iv = b"123456789012";
             let file_name = "tmp/test.ics";
 
-            write("Test data string".to_string(), file_name, &iv).unwrap();
+            let key = encryption_key(&[]).unwrap();
+
+            write("Test data string".to_string(), file_name, &iv, &key).unwrap();
 
             let path = Path::new("data/tmp/test.ics");
             assert!(path.exists(), "File does not exist");
@@ -258,11 +307,63 @@ pub mod calendar {
             let iv = b"123456789012";
             let file_name = "tmp/test.ics";
 
-            write("Test data string".to_string(), file_name, &iv).unwrap();
+            let key = encryption_key(&[]).unwrap();
 
-            let data = read(file_name, &iv).unwrap();
+            write("Test data string".to_string(), file_name, &iv, &key).unwrap();
+
+            let data = read(file_name, &iv, &key).unwrap();
             assert_eq!(data, "Test data string", "Unexpected data");
 
+            // File can't be read with another user's key
+            let user_key = encryption_key(b"abcdefghijklmnopqrstuvwxyz123456").unwrap();
+            assert!(
+                read(file_name, &iv, &user_key).is_err(),
+                "Read with wrong key"
+            );
+
+            // Clear test files
+            std::fs::remove_dir_all("data/tmp").unwrap();
+        }
+
+        #[test]
+        #[serial]
+        fn test_encryption_key() {
+            env::set_var("ENCRYPTION_KEY", "12345678901234567890123456789012");
+
+            let key = encryption_key(&[]).unwrap();
+            assert_eq!(
+                &key, b"12345678901234567890123456789012",
+                "ENV key expected"
+            );
+
+            let key = encryption_key(b"abcdefghijklmnopqrstuvwxyz123456").unwrap();
+            assert_eq!(
+                &key, b"abcdefghijklmnopqrstuvwxyz123456",
+                "User key expected"
+            );
+
+            assert!(encryption_key(b"short").is_err(), "Invalid length");
+        }
+
+        #[test]
+        #[serial]
+        fn test_delete() {
+            env::set_var("ENCRYPTION_KEY", "12345678901234567890123456789012");
+
+            let iv = b"123456789012";
+            let file_name = "tmp/test.ics";
+
+            let key = encryption_key(&[]).unwrap();
+
+            write("Test data string".to_string(), file_name, &iv, &key).unwrap();
+            delete(file_name).unwrap();
+
+            let path = Path::new("data/tmp/test.ics");
+            assert!(!path.exists(), "File was not deleted");
+
+            // Deleting missing file is not an error
+            delete(file_name).unwrap();
+
             // Clear test files
             std::fs::remove_dir_all("data/tmp").unwrap();
         }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/internal/utils"
+	"github.com/samgozman/validity.red/document/pkg/storage"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MIME types of the files which can be attached to the documents (scans and photos).
+// The type is detected by the file content, not by the file name.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type AttachmentServer struct {
+	App *Config
+	// Necessary parameter to insure backwards compatibility
+	proto.UnimplementedAttachmentServiceServer
+}
+
+func (as *AttachmentServer) Upload(ctx context.Context, req *proto.AttachmentUploadRequest) (*proto.ResponseAttachment, error) {
+	userID, documentID, err := as.checkInputsAndDocumentExistence(ctx, req.GetUserID(), req.GetDocumentID())
+	if err != nil {
+		return nil, err
+	}
+
+	content := req.GetContent()
+	if len(content) == 0 || int64(len(content)) > as.App.limits.MaxAttachmentSize {
+		return nil, ErrInvalidAttachmentSize
+	}
+
+	mimeType := http.DetectContentType(content)
+	if !allowedAttachmentTypes[mimeType] {
+		return nil, ErrInvalidAttachmentType
+	}
+
+	// Check if user has reached the limit of attachments
+	count, err := as.App.Attachments.Count(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= as.App.limits.MaxAttachmentsPerDocument {
+		return nil, ErrMaxAttachmentsLimit
+	}
+
+	// ID is generated before the insert, because the content is bound to it
+	a := attachment.Attachment{
+		ID:         uuid.New(),
+		UserID:     userID,
+		DocumentID: documentID,
+		FileName:   sanitizeFileName(req.GetFileName()),
+		MimeType:   mimeType,
+		Size:       int64(len(content)),
+	}
+
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := a.EncryptContent(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.App.Storage.Put(ctx, a.StorageKey(), encrypted)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Copy is inserted, because the file name is encrypted by the hook
+	inserted := a
+	err = as.App.Attachments.InsertOne(ctx, &inserted)
+
+	if err != nil {
+		// Don't leave the content without metadata in the storage
+		if err := as.App.Storage.Delete(ctx, a.StorageKey()); err != nil {
+			sentry.CaptureException(err)
+		}
+
+		return nil, err
+	}
+
+	a.CreatedAt = inserted.CreatedAt
+
+	return &proto.ResponseAttachment{
+		Attachment: utils.ConvertAttachmentToProtoFormat(&a),
+	}, nil
+}
+
+func (as *AttachmentServer) GetAll(ctx context.Context, req *proto.AttachmentsRequest) (*proto.ResponseAttachmentsList, error) {
+	_, documentID, err := as.checkInputsAndDocumentExistence(ctx, req.GetUserID(), req.GetDocumentID())
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := as.App.Attachments.FindAll(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseAttachmentsList{
+		Attachments: utils.ConvertAttachmentsToProtoFormat(&attachments),
+	}, nil
+}
+
+// GetAllForUser returns attachments of all user's documents, used for the data export.
+func (as *AttachmentServer) GetAllForUser(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseAttachmentsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	attachments, err := as.App.Attachments.FindAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseAttachmentsList{
+		Attachments: utils.ConvertAttachmentsToProtoFormat(&attachments),
+	}, nil
+}
+
+func (as *AttachmentServer) Download(ctx context.Context, req *proto.AttachmentRequest) (*proto.ResponseAttachmentContent, error) {
+	a, err := as.findAttachment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := as.App.Storage.Get(ctx, a.StorageKey())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			sentry.CaptureException(fmt.Errorf("content of attachment '%s' is missing: %w", a.ID, err))
+			return nil, status.Error(codes.NotFound, "attachment not found")
+		}
+
+		sentry.CaptureException(err)
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	content, err := a.DecryptContent(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseAttachmentContent{
+		Attachment: utils.ConvertAttachmentToProtoFormat(a),
+		Content:    content,
+	}, nil
+}
+
+func (as *AttachmentServer) Delete(ctx context.Context, req *proto.AttachmentRequest) (*emptypb.Empty, error) {
+	a, err := as.findAttachment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.App.Attachments.DeleteOne(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	// Metadata is already deleted, so the orphan content is only reported
+	err = as.App.Storage.Delete(ctx, a.StorageKey())
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Helper to parse request IDs and find the attachment of the user's document.
+func (as *AttachmentServer) findAttachment(ctx context.Context, req *proto.AttachmentRequest) (*attachment.Attachment, error) {
+	userID, documentID, err := as.checkInputsAndDocumentExistence(ctx, req.GetUserID(), req.GetDocumentID())
+	if err != nil {
+		return nil, err
+	}
+
+	attachmentID, err := uuid.Parse(req.GetAttachmentID())
+	if err != nil {
+		return nil, ErrInvalidAttachmentID
+	}
+
+	a := attachment.Attachment{
+		ID:         attachmentID,
+		UserID:     userID,
+		DocumentID: documentID,
+	}
+
+	err = as.App.Attachments.FindOne(ctx, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// Helper to parse userId and documentId and validate document existence.
+func (as *AttachmentServer) checkInputsAndDocumentExistence(
+	ctx context.Context,
+	uID string,
+	dID string,
+) (
+	userID uuid.UUID,
+	documentID uuid.UUID,
+	e error,
+) {
+	userID, err := uuid.Parse(uID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidUserID
+	}
+
+	documentID, err = uuid.Parse(dID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidDocumentID
+	}
+
+	isDocumentExist, err := as.App.Documents.Exists(ctx, &document.Document{
+		ID:     documentID,
+		UserID: userID,
+	})
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	if !isDocumentExist {
+		return uuid.Nil, uuid.Nil, ErrDocumentNotFound
+	}
+
+	return userID, documentID, nil
+}
+
+// Keep only the base name of the uploaded file without control characters.
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, name)
+
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+
+	if runes := []rune(name); len(runes) > attachment.MaxFileNameLength {
+		name = string(runes[len(runes)-attachment.MaxFileNameLength:])
+	}
+
+	return name
+}
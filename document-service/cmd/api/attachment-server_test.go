@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+// Minimal PNG signature is enough for the content type detection
+var testPNG = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{1}, 64)...)
+
+func TestAttachmentServer_Upload(t *testing.T) {
+	okReq := &proto.AttachmentUploadRequest{
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		FileName:   "C:\\scans\\passport.png",
+		Content:    testPNG,
+	}
+
+	tests := []struct {
+		name     string
+		req      *proto.AttachmentUploadRequest
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:    "should upload attachment",
+			req:     okReq,
+			wantErr: false,
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.AttachmentUploadRequest{
+				UserID:     "justWrongId",
+				DocumentID: okReq.DocumentID,
+				FileName:   okReq.FileName,
+				Content:    okReq.Content,
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if documentId is not exists",
+			req: &proto.AttachmentUploadRequest{
+				UserID:     okReq.UserID,
+				DocumentID: "00000000-0000-0000-0000-000000000000",
+				FileName:   okReq.FileName,
+				Content:    okReq.Content,
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+		{
+			name: "should fail if content is empty",
+			req: &proto.AttachmentUploadRequest{
+				UserID:     okReq.UserID,
+				DocumentID: okReq.DocumentID,
+				FileName:   okReq.FileName,
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidAttachmentSize,
+		},
+		{
+			name: "should fail if content is too large",
+			req: &proto.AttachmentUploadRequest{
+				UserID:     okReq.UserID,
+				DocumentID: okReq.DocumentID,
+				FileName:   okReq.FileName,
+				Content:    append(testPNG, make([]byte, testApp.limits.MaxAttachmentSize)...),
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidAttachmentSize,
+		},
+		{
+			name: "should fail if file type is not allowed",
+			req: &proto.AttachmentUploadRequest{
+				UserID:     okReq.UserID,
+				DocumentID: okReq.DocumentID,
+				FileName:   "passport.png",
+				Content:    []byte("<html><script>alert(1)</script></html>"),
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidAttachmentType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := &AttachmentServer{App: &testApp}
+			got, err := as.Upload(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AttachmentServer.Upload() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.errorMsg) {
+					t.Errorf("AttachmentServer.Upload() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			a := got.GetAttachment()
+			if a.FileName != "passport.png" || a.MimeType != "image/png" || a.Size != int64(len(testPNG)) {
+				t.Errorf("AttachmentServer.Upload() = %v, want passport.png image/png", a)
+			}
+		})
+	}
+}
+
+func TestAttachmentServer_Download(t *testing.T) {
+	as := &AttachmentServer{App: &testApp}
+	ctx := context.Background()
+
+	uploaded, err := as.Upload(ctx, &proto.AttachmentUploadRequest{
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		FileName:   "scan.png",
+		Content:    testPNG,
+	})
+	if err != nil {
+		t.Fatalf("AttachmentServer.Upload() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		req      *proto.AttachmentRequest
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name: "should download decrypted attachment",
+			req: &proto.AttachmentRequest{
+				UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+				DocumentID:   "434377cf-7509-4cc0-9895-0afa683f0e56",
+				AttachmentID: uploaded.GetAttachment().GetID(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "should fail if attachmentId is incorrect",
+			req: &proto.AttachmentRequest{
+				UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+				DocumentID:   "434377cf-7509-4cc0-9895-0afa683f0e56",
+				AttachmentID: "justWrongId",
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidAttachmentID,
+		},
+		{
+			name: "should fail if documentId is not exists",
+			req: &proto.AttachmentRequest{
+				UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+				DocumentID:   "00000000-0000-0000-0000-000000000000",
+				AttachmentID: uploaded.GetAttachment().GetID(),
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := as.Download(ctx, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AttachmentServer.Download() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.errorMsg) {
+					t.Errorf("AttachmentServer.Download() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if !bytes.Equal(got.GetContent(), testPNG) {
+				t.Errorf("AttachmentServer.Download() content is not equal to the uploaded one")
+			}
+		})
+	}
+}
+
+func TestAttachmentServer_Delete(t *testing.T) {
+	as := &AttachmentServer{App: &testApp}
+	ctx := context.Background()
+
+	uploaded, err := as.Upload(ctx, &proto.AttachmentUploadRequest{
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		FileName:   "scan.png",
+		Content:    testPNG,
+	})
+	if err != nil {
+		t.Fatalf("AttachmentServer.Upload() error = %v", err)
+	}
+
+	req := &proto.AttachmentRequest{
+		UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+		DocumentID:   "434377cf-7509-4cc0-9895-0afa683f0e56",
+		AttachmentID: uploaded.GetAttachment().GetID(),
+	}
+
+	if _, err := as.Delete(ctx, req); err != nil {
+		t.Fatalf("AttachmentServer.Delete() error = %v", err)
+	}
+
+	// Mock still finds the metadata, but the content should be deleted
+	if _, err := as.Download(ctx, req); err == nil {
+		t.Errorf("AttachmentServer.Delete() attachment content is not deleted")
+	}
+}
+
+func Test_sanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "passport.png", want: "passport.png"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: "C:\\scans\\policy.pdf", want: "policy.pdf"},
+		{name: " scan\r\n.jpg ", want: "scan.jpg"},
+		{name: "..", want: ""},
+		{name: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFileName(tt.name); got != tt.want {
+				t.Errorf("sanitizeFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
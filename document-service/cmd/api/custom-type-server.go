@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/customtype"
+	"github.com/samgozman/validity.red/document/internal/utils"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// CreateCustomType adds the document type defined by the user.
+func (ds *DocumentServer) CreateCustomType(ctx context.Context, req *proto.CustomTypeRequest) (*proto.ResponseCustomType, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	count, err := ds.App.CustomTypes.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxCustomTypesPerUser {
+		return nil, ErrMaxCustomTypesLimit
+	}
+
+	c := customtype.CustomType{
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.CustomTypes.InsertOne(ctx, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name is encrypted on insert, so the requested one is returned
+	c.Name = req.GetName()
+
+	return &proto.ResponseCustomType{
+		CustomType: utils.ConvertCustomTypeToProtoFormat(&c),
+	}, nil
+}
+
+// EditCustomType renames the user's custom type.
+func (ds *DocumentServer) EditCustomType(ctx context.Context, req *proto.CustomTypeRequest) (*proto.ResponseCustomType, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	customTypeID, err := uuid.Parse(req.GetCustomTypeID())
+	if err != nil {
+		return nil, ErrInvalidCustomTypeID
+	}
+
+	c := customtype.CustomType{
+		ID:     customTypeID,
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.CustomTypes.UpdateOne(ctx, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseCustomType{
+		CustomType: &proto.CustomType{
+			ID:   customTypeID.String(),
+			Name: req.GetName(),
+		},
+	}, nil
+}
+
+// DeleteCustomType deletes the user's custom type, its documents keep the OTHER type.
+func (ds *DocumentServer) DeleteCustomType(ctx context.Context, req *proto.CustomTypeRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	customTypeID, err := uuid.Parse(req.GetCustomTypeID())
+	if err != nil {
+		return nil, ErrInvalidCustomTypeID
+	}
+
+	err = ds.App.CustomTypes.DeleteOne(ctx, &customtype.CustomType{
+		ID:     customTypeID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetCustomTypes returns all custom types of the user.
+func (ds *DocumentServer) GetCustomTypes(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseCustomTypesList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	customTypes, err := ds.App.CustomTypes.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseCustomTypesList{
+		CustomTypes: utils.ConvertCustomTypesToProtoFormat(&customTypes),
+	}, nil
+}
+
+// Parse ID of the user's custom type assigned to the document, nil if it is not set.
+func (ds *DocumentServer) findCustomTypeID(ctx context.Context, userID uuid.UUID, id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	customTypeID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCustomTypeID
+	}
+
+	exists, err := ds.App.CustomTypes.Exists(ctx, &customtype.CustomType{ID: customTypeID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrCustomTypeNotFound
+	}
+
+	return &customTypeID, nil
+}
+
+// Get type of the document, documents of the custom types have the OTHER type
+// so the clients which know only the built-in types can display them.
+func documentType(t proto.Type, customTypeID *uuid.UUID) *proto.Type {
+	if customTypeID != nil {
+		t = proto.Type_OTHER
+	}
+
+	return &t
+}
+
+// Count documents of the custom types with their names.
+func (ds *DocumentServer) countCustomTypes(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+) ([]*proto.DocumentCustomTypesCount, error) {
+	counts, err := ds.App.Documents.CountCustomTypes(ctx, userID, personID)
+	if err != nil || len(counts) == 0 {
+		return counts, err
+	}
+
+	customTypes, err := ds.App.CustomTypes.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range counts {
+		for _, customType := range customTypes {
+			if customType.ID.String() == c.CustomTypeID {
+				c.Name = customType.Name
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// Parse IDs of the custom types to filter documents by.
+func parseCustomTypeFilter(ids []string) ([]uuid.UUID, error) {
+	customTypeIDs := make([]uuid.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		customTypeID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidCustomTypeID
+		}
+
+		customTypeIDs = append(customTypeIDs, customTypeID)
+	}
+
+	return customTypeIDs, nil
+}
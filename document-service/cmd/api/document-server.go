@@ -1,18 +1,40 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/exporter"
+	"github.com/samgozman/validity.red/document/internal/importer"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
 	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
 	"github.com/samgozman/validity.red/document/internal/utils"
 	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const NumberOfLatestDocuments = 5
 
+// Max number of documents on one page of the documents list.
+const MaxDocumentsPageSize = 100
+
+// Max length of the search query in characters.
+const MaxSearchQueryLength = 100
+
+// Purpose of the key derived from the user's data key for the calendar file encryption.
+const calendarKeyPurpose = "calendar"
+
 type DocumentServer struct {
 	App *Config
 	// Necessary parameter to insure backwards compatibility
@@ -37,13 +59,36 @@ func (ds *DocumentServer) Create(ctx context.Context, req *proto.DocumentCreateR
 		return nil, ErrMaxDocumentsLimit
 	}
 
+	if !isValidRenewalPeriod(input.RenewalMonths) {
+		return nil, ErrInvalidRenewalPeriod
+	}
+
+	personID, err := ds.findPersonID(ctx, userID, input.PersonID)
+	if err != nil {
+		return nil, err
+	}
+
+	customTypeID, err := ds.findCustomTypeID(ctx, userID, input.CustomTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := ds.findTags(ctx, userID, input.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// register document
 	d := document.Document{
-		UserID:      userID,
-		Title:       input.Title,
-		Type:        &input.Type,
-		Description: input.Description,
-		ExpiresAt:   input.ExpiresAt.AsTime(),
+		UserID:        userID,
+		Title:         input.Title,
+		Type:          documentType(input.Type, customTypeID),
+		Description:   input.Description,
+		ExpiresAt:     input.ExpiresAt.AsTime(),
+		RenewalMonths: &input.RenewalMonths,
+		PersonID:      personID,
+		CustomTypeID:  customTypeID,
+		Tags:          tags,
 	}
 	err = ds.App.Documents.InsertOne(ctx, &d)
 
@@ -51,6 +96,8 @@ func (ds *DocumentServer) Create(ctx context.Context, req *proto.DocumentCreateR
 		return nil, err
 	}
 
+	ds.applyPresets(ctx, &d)
+
 	// return response
 	res := &proto.ResponseDocumentCreate{
 		DocumentId: d.ID.String(),
@@ -73,16 +120,63 @@ func (ds *DocumentServer) Edit(ctx context.Context, req *proto.DocumentCreateReq
 		return nil, ErrInvalidUserID
 	}
 
+	if !isValidRenewalPeriod(input.RenewalMonths) {
+		return nil, ErrInvalidRenewalPeriod
+	}
+
+	// Shared document is updated on behalf of its owner, so it is encrypted with the owner's key
+	ownerID, err := ds.App.documentOwner(ctx, userID, id, req.GetGroupIDs(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the fields marked as set are changed, others are kept as they are
+	opts := document.UpdateOptions{
+		Person:        req.GetSetPersonID(),
+		CustomType:    req.GetSetCustomTypeID(),
+		Tags:          req.GetSetTagIDs(),
+		RenewalMonths: req.GetSetRenewalMonths(),
+	}
+
+	// Document can be assigned only to the owner's person, custom type and tags
+	var personID *uuid.UUID
+	if opts.Person {
+		personID, err = ds.findPersonID(ctx, ownerID, input.PersonID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var customTypeID *uuid.UUID
+	if opts.CustomType {
+		customTypeID, err = ds.findCustomTypeID(ctx, ownerID, input.CustomTypeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tags []tag.DocumentTag
+	if opts.Tags {
+		tags, err = ds.findTags(ctx, ownerID, input.TagIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// update document
 	d := document.Document{
-		ID:          id,
-		UserID:      userID,
-		Title:       input.Title,
-		Type:        &input.Type,
-		Description: input.Description,
-		ExpiresAt:   input.ExpiresAt.AsTime(),
+		ID:            id,
+		UserID:        ownerID,
+		Title:         input.Title,
+		Type:          documentType(input.Type, customTypeID),
+		Description:   input.Description,
+		ExpiresAt:     input.ExpiresAt.AsTime(),
+		RenewalMonths: &input.RenewalMonths,
+		PersonID:      personID,
+		CustomTypeID:  customTypeID,
+		Tags:          tags,
 	}
-	err = ds.App.Documents.UpdateOne(ctx, &d)
+	err = ds.App.Documents.UpdateOne(ctx, &d, opts)
 
 	if err != nil {
 		return nil, err
@@ -129,10 +223,15 @@ func (ds *DocumentServer) GetOne(ctx context.Context, req *proto.DocumentRequest
 		return nil, ErrInvalidUserID
 	}
 
+	ownerID, err := ds.App.documentOwner(ctx, userID, id, req.GetGroupIDs(), false)
+	if err != nil {
+		return nil, err
+	}
+
 	// Find document
 	d := document.Document{
 		ID:     id,
-		UserID: userID,
+		UserID: ownerID,
 	}
 	err = ds.App.Documents.FindOne(ctx, &d)
 
@@ -142,14 +241,7 @@ func (ds *DocumentServer) GetOne(ctx context.Context, req *proto.DocumentRequest
 
 	// return response
 	res := &proto.ResponseDocument{
-		Document: &proto.Document{
-			ID:          d.ID.String(),
-			UserID:      d.UserID.String(),
-			Title:       d.Title,
-			Type:        *d.Type,
-			Description: d.Description,
-			ExpiresAt:   timestamppb.New(d.ExpiresAt),
-		},
+		Document: utils.ConvertDocumentToProtoFormat(&d),
 	}
 
 	return res, nil
@@ -161,20 +253,105 @@ func (ds *DocumentServer) GetAll(ctx context.Context, req *proto.DocumentsReques
 		return nil, ErrInvalidUserID
 	}
 
-	// Find all documents
-	documents, err := ds.App.Documents.FindAll(ctx, userID)
+	opts, err := listOptionsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, nextCursor, err := ds.App.Documents.FindAll(ctx, userID, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// return response
 	res := &proto.ResponseDocumentsList{
-		Documents: utils.ConvertDocumentsToProtoFormat(&documents),
+		Documents:  utils.ConvertDocumentsToProtoFormat(&documents),
+		NextCursor: nextCursor,
 	}
 
 	return res, nil
 }
 
+func (ds *DocumentServer) Search(ctx context.Context, req *proto.DocumentSearchRequest) (*proto.ResponseDocumentsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" || len([]rune(query)) > MaxSearchQueryLength {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	limit := int(req.GetLimit())
+	if limit < 0 || limit > MaxDocumentsPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	if limit == 0 {
+		limit = MaxDocumentsPageSize
+	}
+
+	documents, err := ds.App.Documents.Search(ctx, userID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseDocumentsList{
+		Documents: utils.ConvertDocumentsToProtoFormat(&documents),
+	}, nil
+}
+
+// Validate pagination, sorting and filters of the documents list request.
+func listOptionsFromRequest(req *proto.DocumentsRequest) (*document.ListOptions, error) {
+	if req.GetLimit() < 0 || req.GetLimit() > MaxDocumentsPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	if req.GetExpiresWithinDays() < 0 || (req.GetExpired() && req.GetExpiresWithinDays() > 0) {
+		return nil, ErrInvalidExpiryFilter
+	}
+
+	personID, err := parsePersonFilter(req.GetPersonID())
+	if err != nil {
+		return nil, err
+	}
+
+	customTypeIDs, err := parseCustomTypeFilter(req.GetCustomTypeIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs, err := parseTagFilter(req.GetTagIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &document.ListOptions{
+		Limit:         int(req.GetLimit()),
+		SortBy:        req.GetSortBy(),
+		Descending:    req.GetDescending(),
+		Types:         req.GetTypes(),
+		CustomTypeIDs: customTypeIDs,
+		TagIDs:        tagIDs,
+		PersonID:      personID,
+		Expired:       req.GetExpired(),
+		ExpiresWithin: time.Duration(req.GetExpiresWithinDays()) * 24 * time.Hour,
+		Now:           time.Now(),
+	}
+
+	if req.GetCursor() != "" {
+		cursor, err := document.DecodeCursor(req.GetCursor())
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Cursor = cursor
+	}
+
+	return opts, nil
+}
+
 func (ds *DocumentServer) GetUserStatistics(
 	ctx context.Context,
 	req *proto.DocumentsRequest,
@@ -184,17 +361,57 @@ func (ds *DocumentServer) GetUserStatistics(
 		return nil, ErrInvalidUserID
 	}
 
-	total, err := ds.App.Documents.Count(ctx, userID)
+	personID, err := parsePersonFilter(req.GetPersonID())
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ds.App.Documents.CountTypes(ctx, userID, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+
+	if personID == uuid.Nil {
+		total, err = ds.App.Documents.Count(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, t := range types {
+			total += t.Count
+		}
+	}
+
+	latest, err := ds.App.Documents.FindLatest(ctx, userID, personID, NumberOfLatestDocuments)
+	if err != nil {
+		return nil, err
+	}
+
+	customTypes, err := ds.countCustomTypes(ctx, userID, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := ds.countTags(ctx, userID, personID)
 	if err != nil {
 		return nil, err
 	}
 
-	types, err := ds.App.Documents.CountTypes(ctx, userID)
+	now := time.Now()
+
+	expiry, err := ds.App.Documents.CountExpiry(ctx, userID, personID, now)
 	if err != nil {
 		return nil, err
 	}
 
-	latest, err := ds.App.Documents.FindLatest(ctx, userID, NumberOfLatestDocuments)
+	timeline, err := ds.App.Documents.CountExpiryTimeline(ctx, userID, personID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := ds.App.Documents.CountNotifications(ctx, userID, personID, now)
 	if err != nil {
 		return nil, err
 	}
@@ -203,5 +420,396 @@ func (ds *DocumentServer) GetUserStatistics(
 		Total:           total,
 		Types:           types,
 		LatestDocuments: utils.ConvertDocumentsToProtoFormat(&latest),
+		CustomTypes:     customTypes,
+		Tags:            tags,
+		Expiry:          expiry,
+		ExpiryTimeline:  timeline,
+		Notifications:   notifications,
+	}, nil
+}
+
+// ListDeleted returns all documents from the user's trash.
+func (ds *DocumentServer) ListDeleted(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseDocumentsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	documents, err := ds.App.Documents.FindDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseDocumentsList{
+		Documents: utils.ConvertDocumentsToProtoFormat(&documents),
+	}, nil
+}
+
+// Restore moves the document and its notifications back from the trash.
+func (ds *DocumentServer) Restore(ctx context.Context, req *proto.DocumentRequest) (*emptypb.Empty, error) {
+	id, err := uuid.Parse(req.GetDocumentID())
+	if err != nil {
+		return nil, ErrInvalidDocumentID
+	}
+
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	// Restored document counts towards the documents limit
+	count, err := ds.App.Documents.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxDocumentsPerUser {
+		return nil, ErrMaxDocumentsLimit
+	}
+
+	err = ds.App.Documents.Restore(ctx, &document.Document{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Purge permanently deletes the document from the trash together with its attachments.
+func (ds *DocumentServer) Purge(ctx context.Context, req *proto.DocumentRequest) (*emptypb.Empty, error) {
+	id, err := uuid.Parse(req.GetDocumentID())
+	if err != nil {
+		return nil, ErrInvalidDocumentID
+	}
+
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	err = ds.App.Documents.PurgeOne(ctx, &document.Document{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Document is already purged, so the orphan content is only reported
+	err = ds.App.Storage.DeleteAll(ctx, attachment.DocumentStorageKey(userID, id))
+	if err != nil {
+		sentry.CaptureException(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Renew advances the document expiration date to the requested one or by the document's renewal period.
+// Notifications are shifted by the same amount and the previous period is saved to the history.
+func (ds *DocumentServer) Renew(ctx context.Context, req *proto.DocumentRenewRequest) (*proto.ResponseRenewal, error) {
+	id, err := uuid.Parse(req.GetDocumentID())
+	if err != nil {
+		return nil, ErrInvalidDocumentID
+	}
+
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	var expiresAt time.Time
+	if req.GetExpiresAt() != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+	}
+
+	r, err := ds.App.Documents.Renew(ctx, &document.Document{
+		ID:     id,
+		UserID: userID,
+	}, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseRenewal{
+		Renewal: utils.ConvertRenewalToProtoFormat(r),
+	}, nil
+}
+
+// GetRenewals returns history of the document's previous validity periods, latest first.
+func (ds *DocumentServer) GetRenewals(ctx context.Context, req *proto.DocumentRequest) (*proto.ResponseRenewalsList, error) {
+	id, err := uuid.Parse(req.GetDocumentID())
+	if err != nil {
+		return nil, ErrInvalidDocumentID
+	}
+
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	ownerID, err := ds.App.documentOwner(ctx, userID, id, req.GetGroupIDs(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	renewals, err := ds.App.Renewals.FindAll(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseRenewalsList{
+		Renewals: utils.ConvertRenewalsToProtoFormat(&renewals),
+	}, nil
+}
+
+// GetRenewalsForUser returns renewals of all user's documents, used for the data export.
+func (ds *DocumentServer) GetRenewalsForUser(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseRenewalsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	renewals, err := ds.App.Renewals.FindAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseRenewalsList{
+		Renewals: utils.ConvertRenewalsToProtoFormat(&renewals),
+	}, nil
+}
+
+// Check that the renewal period is 0 (not renewable) or within the max period.
+func isValidRenewalPeriod(months int32) bool {
+	return months >= 0 && months <= document.MaxRenewalMonths
+}
+
+// Import documents from the CSV or JSON file. Each row is validated separately,
+// invalid rows are reported with their errors and the valid ones are inserted in one transaction.
+func (ds *DocumentServer) Import(ctx context.Context, req *proto.DocumentImportRequest) (*proto.ResponseDocumentImport, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	rows, err := importer.Parse(req.GetFormat(), req.GetContent())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid import file: %s", err)
+	}
+
+	if int64(len(rows)) > ds.App.limits.MaxDocumentsPerUser {
+		return nil, ErrMaxDocumentsLimit
+	}
+
+	documents := make([]document.Document, 0, len(rows))
+	rowErrors := []*proto.ImportRowError{}
+
+	for _, row := range rows {
+		err := row.Err
+		if err == nil {
+			row.Document.UserID = userID
+			err = row.Document.Validate()
+		}
+
+		if err != nil {
+			rowErrors = append(rowErrors, &proto.ImportRowError{
+				Row:     int32(row.Number),
+				Message: status.Convert(err).Message(),
+			})
+
+			continue
+		}
+
+		documents = append(documents, row.Document)
+	}
+
+	// Limit is checked in the same transaction, so the concurrent imports can't exceed it
+	err = ds.App.Documents.InsertMany(ctx, documents, ds.App.limits.MaxDocumentsPerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(documents))
+
+	for i := range documents {
+		ds.applyPresets(ctx, &documents[i])
+		ids = append(ids, documents[i].ID.String())
+	}
+
+	return &proto.ResponseDocumentImport{
+		DocumentIDs: ids,
+		Errors:      rowErrors,
+	}, nil
+}
+
+// Export all user's documents with decrypted fields and the dates of their reminders.
+func (ds *DocumentServer) Export(ctx context.Context, req *proto.DocumentExportRequest) (*proto.ResponseDocumentExport, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	loc, err := time.LoadLocation(req.GetTimezone())
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	// All documents are returned without the limit
+	documents, err := ds.GetAll(ctx, &proto.DocumentsRequest{
+		UserID: req.GetUserID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ns := &NotificationServer{App: ds.App}
+
+	notifications, err := ns.GetAllForUser(ctx, &proto.NotificationsAllRequest{
+		UserID: req.GetUserID(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := ds.App.Tags.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames := make(map[string]string, len(tags))
+	for _, t := range tags {
+		tagNames[t.ID.String()] = t.Name
+	}
+
+	var buf bytes.Buffer
+
+	entries := exporter.Entries(documents.GetDocuments(), notifications.GetNotifications(), tagNames)
+
+	err = exporter.Write(&buf, req.GetFormat(), entries, time.Now().In(loc))
+	if err != nil {
+		if errors.Is(err, exporter.ErrInvalidFormat) {
+			return nil, ErrInvalidExportFormat
+		}
+
+		if errors.Is(err, exporter.ErrUnsupportedText) {
+			return nil, ErrUnsupportedPDFText
+		}
+
+		sentry.CaptureException(err)
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &proto.ResponseDocumentExport{
+		Content: buf.Bytes(),
+	}, nil
+}
+
+// Create reminder rules for the new document from the user's presets for its type.
+// Document is already created, so the failed rules are reported to sentry instead of failing the request.
+// Number of rules is limited by the notifications limit.
+//
+// Presets are saved for the built-in types only, so they are not applied to the documents
+// with the custom type, which are stored with the OTHER type.
+func (ds *DocumentServer) applyPresets(ctx context.Context, d *document.Document) {
+	if d.CustomTypeID != nil {
+		return
+	}
+
+	presets, err := ds.App.Presets.FindByType(ctx, d.UserID, *d.Type)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error finding presets for document '%s': %w", d.ID, err))
+		return
+	}
+
+	if len(presets) == 0 {
+		return
+	}
+
+	count, err := ds.App.Notifications.Count(ctx, d.ID)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error counting notifications of document '%s': %w", d.ID, err))
+		return
+	}
+
+	for i := range presets {
+		if count >= ds.App.limits.MaxNotificationsPerDocument {
+			break
+		}
+
+		err := ds.App.Rules.InsertOne(ctx, &rule.Rule{
+			UserID:     d.UserID,
+			DocumentID: d.ID,
+			DaysBefore: presets[i].DaysBefore,
+		})
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("error applying preset '%s' to document '%s': %w", presets[i].ID, d.ID, err))
+			continue
+		}
+
+		count++
+	}
+}
+
+// DeleteAllForUser permanently deletes all user's documents, notifications and attachments.
+// User's data key is destroyed as well, so the data left in the backups can't be decrypted.
+func (ds *DocumentServer) DeleteAllForUser(ctx context.Context, req *proto.DocumentsRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	err = ds.App.Documents.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ds.App.Storage.DeleteAll(ctx, attachment.UserStorageKey(userID))
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	err = ds.App.DataKeys.Destroy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// CreateDataKey generates the user's data key if the user has none.
+func (ds *DocumentServer) CreateDataKey(ctx context.Context, req *proto.DocumentsRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	err = ds.App.DataKeys.Create(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetCalendarKey returns the key derived from the user's data key to encrypt the calendar file.
+// Returns NotFound if the user has no data key or it was destroyed with the account.
+func (ds *DocumentServer) GetCalendarKey(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseCalendarKey, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	key, err := ds.App.DataKeys.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseCalendarKey{
+		CalendarKey: datakey.DeriveKey(key, calendarKeyPurpose),
 	}, nil
 }
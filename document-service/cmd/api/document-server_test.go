@@ -4,10 +4,19 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	customtype_mocks "github.com/samgozman/validity.red/document/mocks/models/customtype"
+	document_mocks "github.com/samgozman/validity.red/document/mocks/models/document"
+	person_mocks "github.com/samgozman/validity.red/document/mocks/models/person"
+	tag_mocks "github.com/samgozman/validity.red/document/mocks/models/tag"
 	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -65,6 +74,128 @@ func TestDocumentServer_Create(t *testing.T) {
 			wantErr:  true,
 			errorMsg: ErrInvalidUserID,
 		},
+		{
+			name:   "should create document with reminder presets of its type",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:    "458c9061-5262-48b7-9b87-e47fa64d654c",
+						Type:      proto.Type_PASSPORT,
+						ExpiresAt: timestamppb.New(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)),
+					},
+				},
+			},
+			want:    okRes,
+			wantErr: false,
+		},
+		{
+			name:   "should create document of the person",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+						PersonID: person_mocks.TestPersonID,
+					},
+				},
+			},
+			want:    okRes,
+			wantErr: false,
+		},
+		{
+			name:   "should fail if personId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+						PersonID: "justWrongId",
+					},
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidPersonID,
+		},
+		{
+			name:   "should fail if person is not found",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+						PersonID: "45d4202d-d7ee-4d48-a4ac-f81b9448b1d9",
+					},
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrPersonNotFound,
+		},
+		{
+			name:   "should create document of the custom type with tags",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+						CustomTypeID: customtype_mocks.TestCustomTypeID,
+						TagIDs:       []string{tag_mocks.TestTagID, tag_mocks.TestTagID},
+					},
+				},
+			},
+			want:    okRes,
+			wantErr: false,
+		},
+		{
+			name:   "should fail if custom type is not found",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID:       "458c9061-5262-48b7-9b87-e47fa64d654c",
+						CustomTypeID: "45d4202d-d7ee-4d48-a4ac-f81b9448b1d9",
+					},
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrCustomTypeNotFound,
+		},
+		{
+			name:   "should fail if tagId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+						TagIDs: []string{"justWrongId"},
+					},
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidTagID,
+		},
+		{
+			name:   "should fail if tag is not found",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+						TagIDs: []string{tag_mocks.TestTagID, "45d4202d-d7ee-4d48-a4ac-f81b9448b1d9"},
+					},
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrTagNotFound,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -125,6 +256,41 @@ func TestDocumentServer_Edit(t *testing.T) {
 			want:    &emptypb.Empty{},
 			wantErr: false,
 		},
+		{
+			name:   "should ignore personId if it is not set",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						ID:       "434377cf-7509-4cc0-9895-0afa683f0e56",
+						UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+						Title:    "Edit title",
+						PersonID: "justWrongId",
+					},
+				},
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if set personId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentCreateRequest{
+					DocumentEntry: &proto.Document{
+						ID:       "434377cf-7509-4cc0-9895-0afa683f0e56",
+						UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+						Title:    "Edit title",
+						PersonID: "justWrongId",
+					},
+					SetPersonID: true,
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidPersonID,
+		},
 		{
 			name:   "should fail if userId is incorrect",
 			fields: fields{App: &testApp},
@@ -178,6 +344,65 @@ func TestDocumentServer_Edit(t *testing.T) {
 	}
 }
 
+func TestDocumentServer_Edit_RenewalMonths(t *testing.T) {
+	app := testApp
+	app.Documents = document_mocks.NewDocumentDBTest(nil)
+	ds := &DocumentServer{App: &app}
+
+	userID := "458c9061-5262-48b7-9b87-e47fa64d654c"
+
+	tests := []struct {
+		name string
+		req  *proto.DocumentCreateRequest
+		want int32
+	}{
+		{
+			name: "should keep renewal period if it is not set",
+			req: &proto.DocumentCreateRequest{
+				DocumentEntry: &proto.Document{
+					ID:     document_mocks.TestRenewableDocumentID,
+					UserID: userID,
+					Title:  "Edit title",
+				},
+			},
+			want: 12,
+		},
+		{
+			name: "should change renewal period if it is set",
+			req: &proto.DocumentCreateRequest{
+				DocumentEntry: &proto.Document{
+					ID:            document_mocks.TestRenewableDocumentID,
+					UserID:        userID,
+					Title:         "Edit title",
+					RenewalMonths: 0,
+				},
+				SetRenewalMonths: true,
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ds.Edit(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("DocumentServer.Edit() error = %v", err)
+			}
+
+			res, err := ds.GetOne(context.Background(), &proto.DocumentRequest{
+				DocumentID: document_mocks.TestRenewableDocumentID,
+				UserID:     userID,
+			})
+			if err != nil {
+				t.Fatalf("DocumentServer.GetOne() error = %v", err)
+			}
+
+			if res.Document.RenewalMonths != tt.want {
+				t.Errorf("DocumentServer.Edit() renewalMonths = %d, want %d", res.Document.RenewalMonths, tt.want)
+			}
+		})
+	}
+}
+
 func TestDocumentServer_Delete(t *testing.T) {
 	type fields struct {
 		App                                *Config
@@ -403,6 +628,46 @@ func TestDocumentServer_GetAll(t *testing.T) {
 			wantErr:  true,
 			errorMsg: ErrInvalidUserID,
 		},
+		{
+			name:   "should fail if page size is too big",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Limit:  MaxDocumentsPageSize + 1,
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidPageSize,
+		},
+		{
+			name:   "should fail if both expiry filters are set",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID:            "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Expired:           true,
+					ExpiresWithinDays: 30,
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidExpiryFilter,
+		},
+		{
+			name:   "should fail if cursor is malformed",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Cursor: "not a cursor",
+				},
+			},
+			wantErr:  true,
+			errorMsg: document.ErrInvalidCursor,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -426,6 +691,101 @@ func TestDocumentServer_GetAll(t *testing.T) {
 	}
 }
 
+func TestDocumentServer_Search(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentSearchRequest
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     *proto.ResponseDocumentsList
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should search documents",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentSearchRequest{
+					UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Query:  "passport",
+				},
+			},
+			want:    &proto.ResponseDocumentsList{Documents: []*proto.Document{}},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentSearchRequest{
+					UserID: "justWrongId",
+					Query:  "passport",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name:   "should fail if query is empty",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentSearchRequest{
+					UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Query:  "  ",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidSearchQuery,
+		},
+		{
+			name:   "should fail if limit is too big",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentSearchRequest{
+					UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+					Query:  "passport",
+					Limit:  MaxDocumentsPageSize + 1,
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidPageSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.Search(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.Search() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentServer.Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDocumentServer_GetUserStatistics(t *testing.T) {
 	type fields struct {
 		App                                *Config
@@ -443,6 +803,14 @@ func TestDocumentServer_GetUserStatistics(t *testing.T) {
 
 	okRes := &proto.ResponseDocumentsStatistics{
 		LatestDocuments: []*proto.Document{},
+		CustomTypes: []*proto.DocumentCustomTypesCount{
+			{CustomTypeID: customtype_mocks.TestCustomTypeID, Name: "Gym membership", Count: 2},
+		},
+		Tags: []*proto.DocumentTagsCount{
+			{TagID: tag_mocks.TestTagID, Name: "Work", Count: 3},
+		},
+		Expiry:        &proto.DocumentsExpiryCount{},
+		Notifications: &proto.NotificationsStatistics{},
 	}
 
 	tests := []struct {
@@ -497,3 +865,692 @@ func TestDocumentServer_GetUserStatistics(t *testing.T) {
 		})
 	}
 }
+
+func TestDocumentServer_Restore(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentRequest
+	}
+
+	okReq := &proto.DocumentRequest{
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     *emptypb.Empty
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should restore document",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: okReq,
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRequest{
+					DocumentID: "a09f7658-19f7-4854-ab1d-e1052fa2ecce",
+					UserID:     "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name:   "should fail if documentId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRequest{
+					DocumentID: "justWrongId",
+					UserID:     "76193e99-7451-408a-91b8-215761414775",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidDocumentID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.Restore(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Restore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.Restore() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentServer.Restore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_Purge(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentRequest
+	}
+
+	okReq := &proto.DocumentRequest{
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     *emptypb.Empty
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should purge document",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: okReq,
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRequest{
+					DocumentID: "a09f7658-19f7-4854-ab1d-e1052fa2ecce",
+					UserID:     "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name:   "should fail if documentId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRequest{
+					DocumentID: "justWrongId",
+					UserID:     "76193e99-7451-408a-91b8-215761414775",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidDocumentID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.Purge(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Purge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.Purge() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentServer.Purge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_ListDeleted(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentsRequest
+	}
+
+	okReq := &proto.DocumentsRequest{
+		UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+	}
+
+	okRes := &proto.ResponseDocumentsList{
+		Documents: []*proto.Document{},
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     *proto.ResponseDocumentsList
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should find all deleted documents",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: okReq,
+			},
+			want:    okRes,
+			wantErr: false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.ListDeleted(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.ListDeleted() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.ListDeleted() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentServer.ListDeleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_DeleteAllForUser(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentsRequest
+	}
+
+	okReq := &proto.DocumentsRequest{
+		UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     *emptypb.Empty
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should delete all documents",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: okReq,
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.DeleteAllForUser(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.DeleteAllForUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.DeleteAllForUser() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentServer.DeleteAllForUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_GetCalendarKey(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentsRequest
+	}
+
+	userID := uuid.New()
+	destroyedUserID := uuid.New()
+
+	for _, id := range []uuid.UUID{userID, destroyedUserID} {
+		if err := testApp.DataKeys.Create(context.Background(), id); err != nil {
+			t.Fatalf("DataKeys.Create() error = %v", err)
+		}
+	}
+
+	if err := testApp.DataKeys.Destroy(context.Background(), destroyedUserID); err != nil {
+		t.Fatalf("DataKeys.Destroy() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should return the calendar key",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: userID.String(),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if data key was destroyed",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: destroyedUserID.String(),
+				},
+			},
+			wantErr:  true,
+			errorMsg: status.Error(codes.NotFound, "data key not found"),
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentsRequest{
+					UserID: "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.GetCalendarKey(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.GetCalendarKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.GetCalendarKey() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				return
+			}
+			if !tt.wantErr && len(got.GetCalendarKey()) != 32 {
+				t.Errorf("DocumentServer.GetCalendarKey() key length = %d, want 32", len(got.GetCalendarKey()))
+			}
+		})
+	}
+}
+
+func TestDocumentServer_Renew(t *testing.T) {
+	type fields struct {
+		App                                *Config
+		UnimplementedDocumentServiceServer proto.UnimplementedDocumentServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DocumentRenewRequest
+	}
+
+	expiresAt := time.Date(2032, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantExpiresAt time.Time
+		wantErr       bool
+		errorMsg      error
+	}{
+		{
+			name:   "should renew document by its renewal period",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRenewRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				},
+			},
+			wantExpiresAt: time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC),
+			wantErr:       false,
+		},
+		{
+			name:   "should renew document to the requested date",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRenewRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+					ExpiresAt:  timestamppb.New(expiresAt),
+				},
+			},
+			wantExpiresAt: expiresAt,
+			wantErr:       false,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRenewRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name:   "should fail if documentId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DocumentRenewRequest{
+					DocumentID: "justWrongId",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidDocumentID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{
+				App:                                tt.fields.App,
+				UnimplementedDocumentServiceServer: tt.fields.UnimplementedDocumentServiceServer,
+			}
+			got, err := ds.Renew(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Renew() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.Renew() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if !got.GetRenewal().GetExpiresAt().AsTime().Equal(tt.wantExpiresAt) {
+				t.Errorf("DocumentServer.Renew() expiresAt = %v, want %v", got.GetRenewal().GetExpiresAt().AsTime(), tt.wantExpiresAt)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_Create_RenewalPeriod(t *testing.T) {
+	ds := &DocumentServer{App: &testApp}
+
+	_, err := ds.Create(context.Background(), &proto.DocumentCreateRequest{
+		DocumentEntry: &proto.Document{
+			UserID:        "458c9061-5262-48b7-9b87-e47fa64d654c",
+			Title:         "Insurance",
+			ExpiresAt:     timestamppb.Now(),
+			RenewalMonths: document.MaxRenewalMonths + 1,
+		},
+	})
+	if !errors.Is(err, ErrInvalidRenewalPeriod) {
+		t.Errorf("DocumentServer.Create() error = %v, want %v", err, ErrInvalidRenewalPeriod)
+	}
+}
+
+func TestDocumentServer_Import(t *testing.T) {
+	tooMany := "title,expiresAt\n" + strings.Repeat("Passport,2030-01-01\n", int(testApp.limits.MaxDocumentsPerUser)+1)
+
+	tests := []struct {
+		name     string
+		req      *proto.DocumentImportRequest
+		wantErr  bool
+		errorMsg error
+		wantIDs  int
+		wantCode codes.Code
+		wantRows []int32
+	}{
+		{
+			name: "should import valid rows and report invalid ones",
+			req: &proto.DocumentImportRequest{
+				UserID:  "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:  proto.ImportFormat_CSV,
+				Content: []byte("title,type,expiresAt\nPassport,passport,2030-01-01\n,visa,2030-01-01\nCard,credit card,\nCoupon,coupon,2025-01-01\n"),
+			},
+			wantIDs:  2,
+			wantRows: []int32{2, 3},
+		},
+		{
+			name: "should import json",
+			req: &proto.DocumentImportRequest{
+				UserID:  "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:  proto.ImportFormat_JSON,
+				Content: []byte(`[{"title":"Passport","type":"passport","expiresAt":"2030-01-01","renewalMonths":200}]`),
+			},
+			wantIDs:  0,
+			wantRows: []int32{1},
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.DocumentImportRequest{
+				UserID:  "justWrongId",
+				Format:  proto.ImportFormat_CSV,
+				Content: []byte("title,expiresAt\nPassport,2030-01-01\n"),
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if file is invalid",
+			req: &proto.DocumentImportRequest{
+				UserID:  "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:  proto.ImportFormat_JSON,
+				Content: []byte("title,expiresAt\nPassport,2030-01-01\n"),
+			},
+			wantErr:  true,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "should fail if documents limit is reached",
+			req: &proto.DocumentImportRequest{
+				UserID:  "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:  proto.ImportFormat_CSV,
+				Content: []byte(tooMany),
+			},
+			wantErr:  true,
+			errorMsg: ErrMaxDocumentsLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			got, err := ds.Import(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if tt.errorMsg != nil && !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.Import() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				if tt.errorMsg == nil && status.Code(err) != tt.wantCode {
+					t.Errorf("DocumentServer.Import() wrong error code = %v, want %v", status.Code(err), tt.wantCode)
+				}
+				return
+			}
+			if len(got.GetDocumentIDs()) != tt.wantIDs {
+				t.Errorf("DocumentServer.Import() imported = %d, want %d", len(got.GetDocumentIDs()), tt.wantIDs)
+			}
+
+			var rows []int32
+			for _, e := range got.GetErrors() {
+				rows = append(rows, e.GetRow())
+			}
+
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("DocumentServer.Import() rows with errors = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_Export(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *proto.DocumentExportRequest
+		wantErr    bool
+		errorMsg   error
+		wantPrefix string
+	}{
+		{
+			name: "should export csv",
+			req: &proto.DocumentExportRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format: proto.ExportFormat_EXPORT_CSV,
+			},
+			wantPrefix: "title,type,description,expiresAt,renewalMonths,reminders,person,customType,tags\n",
+		},
+		{
+			name: "should export json",
+			req: &proto.DocumentExportRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format: proto.ExportFormat_EXPORT_JSON,
+			},
+			wantPrefix: "[",
+		},
+		{
+			name: "should export pdf",
+			req: &proto.DocumentExportRequest{
+				UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:   proto.ExportFormat_EXPORT_PDF,
+				Timezone: "UTC",
+			},
+			wantPrefix: "%PDF-",
+		},
+		{
+			name: "should fail if timezone is unknown",
+			req: &proto.DocumentExportRequest{
+				UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format:   proto.ExportFormat_EXPORT_PDF,
+				Timezone: "Mars/Olympus_Mons",
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidTimezone,
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.DocumentExportRequest{
+				UserID: "justWrongId",
+				Format: proto.ExportFormat_EXPORT_CSV,
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if format is unknown",
+			req: &proto.DocumentExportRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Format: proto.ExportFormat(10),
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidExportFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			got, err := ds.Export(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.Export() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if !strings.HasPrefix(string(got.GetContent()), tt.wantPrefix) {
+				t.Errorf("DocumentServer.Export() = %s, want prefix %s", got.GetContent(), tt.wantPrefix)
+			}
+		})
+	}
+}
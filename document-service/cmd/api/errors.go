@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/samgozman/validity.red/document/internal/models/document"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -10,6 +11,34 @@ var (
 	ErrInvalidDocumentID     = status.Error(codes.InvalidArgument, "invalid document_id")
 	ErrInvalidNotificationID = status.Error(codes.InvalidArgument, "invalid notification_id")
 	ErrDocumentNotFound      = status.Error(codes.NotFound, "document not found")
-	ErrMaxDocumentsLimit     = status.Error(codes.Canceled, "max documents limit reached")
+	ErrMaxDocumentsLimit     = document.ErrMaxDocumentsLimit
 	ErrMaxNotificationsLimit = status.Error(codes.Canceled, "max notifications for this document limit reached")
+	ErrInvalidPageSize       = status.Error(codes.InvalidArgument, "invalid page size")
+	ErrInvalidExpiryFilter   = status.Error(codes.InvalidArgument, "invalid expiry filter")
+	ErrInvalidSearchQuery    = status.Error(codes.InvalidArgument, "invalid search query")
+	ErrInvalidAttachmentID   = status.Error(codes.InvalidArgument, "invalid attachment_id")
+	ErrInvalidAttachmentSize = status.Error(codes.InvalidArgument, "attachment is empty or too large")
+	ErrInvalidAttachmentType = status.Error(codes.InvalidArgument, "attachment file type is not allowed")
+	ErrMaxAttachmentsLimit   = status.Error(codes.Canceled, "max attachments for this document limit reached")
+	ErrInvalidRenewalPeriod  = status.Error(codes.InvalidArgument, "invalid renewal period")
+	ErrInvalidRuleID         = status.Error(codes.InvalidArgument, "invalid rule_id")
+	ErrInvalidPresetID       = status.Error(codes.InvalidArgument, "invalid preset_id")
+	ErrMaxPresetsLimit       = status.Error(codes.Canceled, "max reminder presets for this type limit reached")
+	ErrInvalidExportFormat   = status.Error(codes.InvalidArgument, "invalid export format")
+	ErrInvalidTimezone       = status.Error(codes.InvalidArgument, "invalid timezone")
+	ErrUnsupportedPDFText    = status.Error(codes.InvalidArgument, "documents have characters not supported by the PDF export, use CSV or JSON")
+	ErrInvalidGroupID        = status.Error(codes.InvalidArgument, "invalid group_id")
+	ErrInvalidShareID        = status.Error(codes.InvalidArgument, "invalid share_id")
+	ErrGroupNotFound         = status.Error(codes.NotFound, "group not found")
+	ErrPermissionDenied      = status.Error(codes.PermissionDenied, "permission denied")
+	ErrInvalidPersonID       = status.Error(codes.InvalidArgument, "invalid person_id")
+	ErrPersonNotFound        = status.Error(codes.NotFound, "person not found")
+	ErrMaxPersonsLimit       = status.Error(codes.Canceled, "max persons limit reached")
+	ErrInvalidCustomTypeID   = status.Error(codes.InvalidArgument, "invalid custom_type_id")
+	ErrCustomTypeNotFound    = status.Error(codes.NotFound, "custom type not found")
+	ErrMaxCustomTypesLimit   = status.Error(codes.Canceled, "max custom types limit reached")
+	ErrInvalidTagID          = status.Error(codes.InvalidArgument, "invalid tag_id")
+	ErrTagNotFound           = status.Error(codes.NotFound, "tag not found")
+	ErrMaxTagsLimit          = status.Error(codes.Canceled, "max tags limit reached")
+	ErrMaxDocumentTagsLimit  = status.Error(codes.Canceled, "max tags for this document limit reached")
 )
@@ -18,7 +18,8 @@ func (app *Config) gRPCListen() {
 		log.Fatalf("failed to listen for gRPC: %v", err)
 	}
 
-	s := grpc.NewServer()
+	// Default 4MB limit is not enough for the attachments upload
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(int(app.limits.MaxAttachmentSize) + 1024*1024))
 
 	proto.RegisterDocumentServiceServer(s, &DocumentServer{
 		App: app,
@@ -26,6 +27,9 @@ func (app *Config) gRPCListen() {
 	proto.RegisterNotificationServiceServer(s, &NotificationServer{
 		App: app,
 	})
+	proto.RegisterAttachmentServiceServer(s, &AttachmentServer{
+		App: app,
+	})
 
 	log.Printf("GRPC server listening on port %s", gRPCPort)
 
@@ -1,27 +1,64 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/validity.red/document/internal/clock"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/customtype"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
 	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/internal/models/encryptedname"
 	"github.com/samgozman/validity.red/document/internal/models/notification"
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	"github.com/samgozman/validity.red/document/internal/models/preset"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	"github.com/samgozman/validity.red/document/internal/models/share"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
+	"github.com/samgozman/validity.red/document/internal/rekey"
+	"github.com/samgozman/validity.red/document/internal/reminder"
+	"github.com/samgozman/validity.red/document/internal/trash"
+	"github.com/samgozman/validity.red/document/pkg/keyring"
+	"github.com/samgozman/validity.red/document/pkg/kms"
+	"github.com/samgozman/validity.red/document/pkg/storage"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// Limit user documents and notifications count.
+// Limit user documents, notifications and attachments count.
 type limits struct {
 	MaxDocumentsPerUser         int64
 	MaxNotificationsPerDocument int64
+	MaxAttachmentsPerDocument   int64
+	MaxAttachmentSize           int64 // In bytes
+	MaxPersonsPerUser           int64
+	MaxCustomTypesPerUser       int64
+	MaxTagsPerUser              int64
+	MaxTagsPerDocument          int64
 }
 
 type Config struct {
 	limits        limits
 	Documents     document.DocumentRepository
 	Notifications notification.NotificationRepository
+	DataKeys      datakey.DataKeyRepository
+	Attachments   attachment.AttachmentRepository
+	Renewals      renewal.RenewalRepository
+	Rules         rule.RuleRepository
+	Presets       preset.PresetRepository
+	Shares        share.ShareRepository
+	Persons       person.PersonRepository
+	CustomTypes   customtype.CustomTypeRepository
+	Tags          tag.TagRepository
+	Storage       storage.Storage // Storage of the encrypted attachments content
 }
 
 func main() {
@@ -36,26 +73,81 @@ func main() {
 
 	defer sentry.Flush(2 * time.Second)
 
+	// Load encryption keys
+	document.Keys, err = keyring.Parse(
+		os.Getenv("ENCRYPTION_KEY"),
+		os.Getenv("ENCRYPTION_KEYS"),
+		os.Getenv("ENCRYPTION_KEY_VERSION"),
+	)
+	if err != nil {
+		log.Fatalf("keyring.Parse: %s", err)
+	}
+
 	// Connect to SQL server
 	db := connectToDB()
 	if db == nil {
 		panic("Can't connect to Postgres!")
 	}
 
+	// Notification dates were stored as the time of day only
+	err = notification.MigrateDateColumn(db)
+	if err != nil {
+		panic(err)
+	}
+
 	//Automatic migration for documents table
-	err = db.AutoMigrate(&document.Document{}, &notification.Notification{})
+	err = db.AutoMigrate(
+		&person.Person{},
+		&customtype.CustomType{},
+		&tag.Tag{},
+		&document.Document{},
+		&tag.DocumentTag{},
+		&notification.Notification{},
+		&datakey.DataKey{},
+		&attachment.Attachment{},
+		&renewal.Renewal{},
+		&rule.Rule{},
+		&preset.Preset{},
+		&share.Share{},
+	)
 	if err != nil {
 		panic(err)
 	}
 
+	masterKeys, err := setupKMS()
+	if err != nil {
+		log.Fatalf("setupKMS: %s", err)
+	}
+
+	files, err := setupStorage()
+	if err != nil {
+		log.Fatalf("setupStorage: %s", err)
+	}
+
 	// Create app
 	app := Config{
 		limits: limits{
 			MaxDocumentsPerUser:         100,
 			MaxNotificationsPerDocument: 10,
+			MaxAttachmentsPerDocument:   5,
+			MaxAttachmentSize:           10 * 1024 * 1024,
+			MaxPersonsPerUser:           20,
+			MaxCustomTypesPerUser:       20,
+			MaxTagsPerUser:              50,
+			MaxTagsPerDocument:          10,
 		},
+		Storage: files,
 	}
-	app.setupRepo(db)
+	app.setupRepo(db, masterKeys)
+
+	// Start sending due notifications in the background
+	go app.setupReminders().Run(context.Background())
+
+	// Start purging old documents from the trash in the background
+	go app.setupTrashPurger().Run(context.Background())
+
+	// Migrate documents encrypted with the old keys in the background
+	go app.reencryptDocuments(context.Background())
 
 	// Start gRPC server
 	app.gRPCListen()
@@ -88,7 +180,117 @@ func connectToDB() *gorm.DB {
 	}
 }
 
-func (app *Config) setupRepo(conn *gorm.DB) {
+func (app *Config) setupRepo(conn *gorm.DB, masterKeys kms.KMS) {
 	app.Documents = document.NewDocumentDB(conn)
 	app.Notifications = notification.NewNotificationDB(conn)
+	app.DataKeys = datakey.NewDataKeyDB(conn, masterKeys)
+	app.Attachments = attachment.NewAttachmentDB(conn)
+	app.Renewals = renewal.NewRenewalDB(conn)
+	app.Rules = rule.NewRuleDB(conn)
+	app.Presets = preset.NewPresetDB(conn)
+	app.Shares = share.NewShareDB(conn)
+	app.Persons = person.NewPersonDB(conn)
+	app.CustomTypes = customtype.NewCustomTypeDB(conn)
+	app.Tags = tag.NewTagDB(conn)
+	document.DataKeys = app.DataKeys
+	attachment.DataKeys = app.DataKeys
+	encryptedname.DataKeys = app.DataKeys
+}
+
+// Create KMS used to wrap the users' data keys.
+// Master keys are loaded from KMS_MASTER_KEYS_FILE, if it is not set the encryption keys are used.
+func setupKMS() (kms.KMS, error) {
+	path := os.Getenv("KMS_MASTER_KEYS_FILE")
+	if path == "" {
+		log.Println("KMS_MASTER_KEYS_FILE is not set, encryption keys are used as master keys")
+		return &kms.LocalKMS{Keys: document.Keys}, nil
+	}
+
+	return kms.NewLocalKMSFromFile(path)
+}
+
+// Create storage of the attachments content selected by ENV (local filesystem by default).
+func setupStorage() (storage.Storage, error) {
+	switch os.Getenv("ATTACHMENTS_STORAGE") {
+	case "", "local":
+		dir := os.Getenv("ATTACHMENTS_DIR")
+		if dir == "" {
+			dir = "./attachments"
+		}
+
+		return &storage.LocalStorage{Dir: dir}, nil
+	case "s3":
+		if os.Getenv("S3_ENDPOINT") == "" || os.Getenv("S3_BUCKET") == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+		}
+
+		return &storage.S3Storage{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			Client:          &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return nil, errors.New("unknown ATTACHMENTS_STORAGE, should be local or s3")
+	}
+}
+
+// Create reminders dispatcher with the delivery channel selected by ENV.
+func (app *Config) setupReminders() *reminder.Dispatcher {
+	var sender reminder.Sender = reminder.LogSender{}
+
+	if url := os.Getenv("REMINDER_WEBHOOK_URL"); url != "" {
+		sender = &reminder.WebhookSender{
+			URL:    url,
+			Client: &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+
+	return &reminder.Dispatcher{
+		Notifications: app.Notifications,
+		Sender:        sender,
+		Clock:         clock.System{},
+		Interval:      time.Minute,
+		BatchSize:     100,
+		MaxAttempts:   5,
+		RetryDelay:    5 * time.Minute,
+	}
+}
+
+// Create trash purger with the retention period set by ENV (14 days by default).
+func (app *Config) setupTrashPurger() *trash.Purger {
+	retentionDays := 14
+
+	if days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && days > 0 {
+		retentionDays = days
+	}
+
+	return &trash.Purger{
+		Documents: app.Documents,
+		Storage:   app.Storage,
+		Clock:     clock.System{},
+		Retention: time.Duration(retentionDays) * 24 * time.Hour,
+		Interval:  time.Hour,
+	}
+}
+
+// Re-wrap data keys with the current master key and re-encrypt documents with the legacy keys.
+func (app *Config) reencryptDocuments(ctx context.Context) {
+	m := &rekey.Migrator{
+		Documents: app.Documents,
+		DataKeys:  app.DataKeys,
+		BatchSize: 100,
+	}
+
+	migrated, err := m.Run(ctx)
+	if err != nil {
+		log.Println("Error on re-encrypting documents:", err)
+		return
+	}
+
+	if migrated > 0 {
+		log.Printf("Re-encrypted %d documents with the data keys", migrated)
+	}
 }
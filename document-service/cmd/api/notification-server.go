@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/samgozman/validity.red/document/internal/models/document"
 	"github.com/samgozman/validity.red/document/internal/models/notification"
+	"github.com/samgozman/validity.red/document/internal/models/preset"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
 	"github.com/samgozman/validity.red/document/internal/utils"
 	proto "github.com/samgozman/validity.red/document/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -20,7 +22,13 @@ type NotificationServer struct {
 func (ds *NotificationServer) Create(ctx context.Context, req *proto.NotificationCreateRequest) (*emptypb.Empty, error) {
 	input := req.GetNotificationEntry()
 
-	userID, documentID, err := ds.checkInputsAndDocumentExistence(ctx, req.GetUserID(), input.GetDocumentID())
+	userID, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		input.GetDocumentID(),
+		req.GetGroupIDs(),
+		true,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +62,13 @@ func (ds *NotificationServer) Delete(ctx context.Context, req *proto.Notificatio
 	input := req.GetNotificationEntry()
 
 	// Validate input arguments
-	_, documentID, err := ds.checkInputsAndDocumentExistence(ctx, req.GetUserID(), input.GetDocumentID())
+	_, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		input.GetDocumentID(),
+		req.GetGroupIDs(),
+		true,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +97,13 @@ func (ds *NotificationServer) GetAll(
 	ctx context.Context,
 	req *proto.NotificationsRequest,
 ) (*proto.ResponseNotificationsList, error) {
-	_, documentID, err := ds.checkInputsAndDocumentExistence(ctx, req.GetUserID(), req.GetDocumentID())
+	_, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		req.GetDocumentID(),
+		req.GetGroupIDs(),
+		false,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +128,13 @@ func (ds *NotificationServer) Count(
 	ctx context.Context,
 	req *proto.NotificationsCountRequest,
 ) (*proto.ResponseCount, error) {
-	_, documentID, err := ds.checkInputsAndDocumentExistence(ctx, req.GetUserID(), req.GetDocumentID())
+	_, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		req.GetDocumentID(),
+		req.GetGroupIDs(),
+		false,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -169,11 +195,236 @@ func (ds *NotificationServer) GetAllForUser(
 	return res, nil
 }
 
+// GetAllShared returns notifications of the documents shared with the user's groups.
+func (ds *NotificationServer) GetAllShared(
+	ctx context.Context,
+	req *proto.SharedDocumentsRequest,
+) (*proto.ResponseNotificationsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	groupIDs, err := parseGroupIDs(req.GetGroupIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := ds.App.Notifications.FindAllShared(ctx, userID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseNotificationsList{
+		Notifications: utils.ConvertNotificationsToProtoFormat(&notifications),
+	}, nil
+}
+
+// CreateRule adds the reminder relative to the document expiration date.
+// Rule creates the notification, so it is subject to the notifications limit.
+func (ds *NotificationServer) CreateRule(
+	ctx context.Context,
+	req *proto.ReminderRuleCreateRequest,
+) (*proto.ResponseReminderRule, error) {
+	userID, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		req.GetDocumentID(),
+		req.GetGroupIDs(),
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := ds.App.Notifications.Count(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxNotificationsPerDocument {
+		return nil, ErrMaxNotificationsLimit
+	}
+
+	r := rule.Rule{
+		UserID:     userID,
+		DocumentID: documentID,
+		DaysBefore: req.GetDaysBefore(),
+	}
+
+	err = ds.App.Rules.InsertOne(ctx, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseReminderRule{
+		Rule: utils.ConvertRuleToProtoFormat(&r),
+	}, nil
+}
+
+// DeleteRule deletes the reminder rule with its notification.
+func (ds *NotificationServer) DeleteRule(ctx context.Context, req *proto.ReminderRuleRequest) (*emptypb.Empty, error) {
+	userID, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		req.GetDocumentID(),
+		req.GetGroupIDs(),
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleID, err := uuid.Parse(req.GetRuleID())
+	if err != nil {
+		return nil, ErrInvalidRuleID
+	}
+
+	err = ds.App.Rules.DeleteOne(ctx, &rule.Rule{
+		ID:         ruleID,
+		UserID:     userID,
+		DocumentID: documentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetRules returns the reminder rules of the document.
+func (ds *NotificationServer) GetRules(
+	ctx context.Context,
+	req *proto.NotificationsRequest,
+) (*proto.ResponseReminderRulesList, error) {
+	userID, documentID, err := ds.checkInputsAndDocumentExistence(
+		ctx,
+		req.GetUserID(),
+		req.GetDocumentID(),
+		req.GetGroupIDs(),
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ds.App.Rules.FindAll(ctx, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseReminderRulesList{
+		Rules: utils.ConvertRulesToProtoFormat(&rules),
+	}, nil
+}
+
+// GetRulesForUser returns reminder rules of all user's documents, used for the data export.
+func (ds *NotificationServer) GetRulesForUser(
+	ctx context.Context,
+	req *proto.NotificationsAllRequest,
+) (*proto.ResponseReminderRulesList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	rules, err := ds.App.Rules.FindAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseReminderRulesList{
+		Rules: utils.ConvertRulesToProtoFormat(&rules),
+	}, nil
+}
+
+// CreatePreset adds the user's default reminder for the new documents of the type.
+// Number of presets per type is limited by the notifications limit, since every preset creates one.
+func (ds *NotificationServer) CreatePreset(
+	ctx context.Context,
+	req *proto.ReminderPresetCreateRequest,
+) (*proto.ResponseReminderPreset, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	count, err := ds.App.Presets.Count(ctx, userID, req.GetType())
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxNotificationsPerDocument {
+		return nil, ErrMaxPresetsLimit
+	}
+
+	p := preset.Preset{
+		UserID:     userID,
+		Type:       req.GetType(),
+		DaysBefore: req.GetDaysBefore(),
+	}
+
+	err = ds.App.Presets.InsertOne(ctx, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseReminderPreset{
+		Preset: utils.ConvertPresetToProtoFormat(&p),
+	}, nil
+}
+
+// DeletePreset deletes the user's preset, rules already created from it are kept.
+func (ds *NotificationServer) DeletePreset(ctx context.Context, req *proto.ReminderPresetRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	presetID, err := uuid.Parse(req.GetPresetID())
+	if err != nil {
+		return nil, ErrInvalidPresetID
+	}
+
+	err = ds.App.Presets.DeleteOne(ctx, &preset.Preset{
+		ID:     presetID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetPresets returns all user's presets.
+func (ds *NotificationServer) GetPresets(
+	ctx context.Context,
+	req *proto.NotificationsAllRequest,
+) (*proto.ResponseReminderPresetsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	presets, err := ds.App.Presets.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseReminderPresetsList{
+		Presets: utils.ConvertPresetsToProtoFormat(&presets),
+	}, nil
+}
+
 // Helper to parse userId and documentId and validate document existence.
+// For the document shared with the user's groups, the owner's ID is returned as userID.
 func (ds *NotificationServer) checkInputsAndDocumentExistence(
 	ctx context.Context,
 	uID string,
 	dID string,
+	groupIDs []string,
+	edit bool,
 ) (
 	userID uuid.UUID,
 	documentID uuid.UUID,
@@ -201,9 +452,18 @@ func (ds *NotificationServer) checkInputsAndDocumentExistence(
 		return uuid.Nil, uuid.Nil, err
 	}
 
-	if !isDocumentExist {
+	if !isDocumentExist && len(groupIDs) == 0 {
 		return uuid.Nil, uuid.Nil, ErrDocumentNotFound
 	}
 
+	if !isDocumentExist {
+		ownerID, err := ds.App.sharedDocumentOwner(ctx, documentID, groupIDs, edit)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
+
+		return ownerID, documentID, nil
+	}
+
 	return userID, documentID, nil
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	share_mocks "github.com/samgozman/validity.red/document/mocks/models/share"
 	proto "github.com/samgozman/validity.red/document/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -431,14 +432,18 @@ func TestNotificationServer_checkInputsAndDocumentExistence(t *testing.T) {
 	}
 
 	type args struct {
-		ctx context.Context
-		uID string
-		dID string
+		ctx      context.Context
+		uID      string
+		dID      string
+		groupIDs []string
+		edit     bool
 	}
 
 	dID, _ := uuid.Parse("434377cf-7509-4cc0-9895-0afa683f0e56")
 	uID, _ := uuid.Parse("458c9061-5262-48b7-9b87-e47fa64d654c")
 	d404, _ := uuid.Parse("45d4202d-d7ee-4d48-a4ac-f81b9448b1d9")
+	sharedID := uuid.MustParse(share_mocks.SharedDocumentID)
+	ownerID := uuid.MustParse(share_mocks.SharedOwnerID)
 
 	tests := []struct {
 		name           string
@@ -494,6 +499,70 @@ func TestNotificationServer_checkInputsAndDocumentExistence(t *testing.T) {
 			wantErr:  true,
 			errorMsg: ErrDocumentNotFound,
 		},
+		{
+			name:   "should return owner of the document shared with the group",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx:      context.Background(),
+				dID:      sharedID.String(),
+				uID:      uID.String(),
+				groupIDs: []string{share_mocks.ReadGroupID},
+			},
+			wantUserID:     ownerID,
+			wantDocumentID: sharedID,
+			wantErr:        false,
+		},
+		{
+			name:   "should return owner of the document shared for editing",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx:      context.Background(),
+				dID:      sharedID.String(),
+				uID:      uID.String(),
+				groupIDs: []string{share_mocks.ReadGroupID, share_mocks.EditGroupID},
+				edit:     true,
+			},
+			wantUserID:     ownerID,
+			wantDocumentID: sharedID,
+			wantErr:        false,
+		},
+		{
+			name:   "should fail to edit the document shared for reading",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx:      context.Background(),
+				dID:      sharedID.String(),
+				uID:      uID.String(),
+				groupIDs: []string{share_mocks.ReadGroupID},
+				edit:     true,
+			},
+			wantErr:  true,
+			errorMsg: ErrPermissionDenied,
+		},
+		{
+			name:   "should fail if document is not shared with the groups",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx:      context.Background(),
+				dID:      d404.String(),
+				uID:      uID.String(),
+				groupIDs: []string{share_mocks.EditGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+		{
+			name:   "should fail if groupId is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx:      context.Background(),
+				dID:      sharedID.String(),
+				uID:      uID.String(),
+				groupIDs: []string{"wrongUID"},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidGroupID,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -501,7 +570,13 @@ func TestNotificationServer_checkInputsAndDocumentExistence(t *testing.T) {
 				App:                                    tt.fields.App,
 				UnimplementedNotificationServiceServer: tt.fields.UnimplementedNotificationServiceServer,
 			}
-			gotUserID, gotDocumentID, err := ds.checkInputsAndDocumentExistence(tt.args.ctx, tt.args.uID, tt.args.dID)
+			gotUserID, gotDocumentID, err := ds.checkInputsAndDocumentExistence(
+				tt.args.ctx,
+				tt.args.uID,
+				tt.args.dID,
+				tt.args.groupIDs,
+				tt.args.edit,
+			)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NotificationServer.checkInputsAndDocumentExistence() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -664,3 +739,222 @@ func TestNotificationServer_GetAllForUser(t *testing.T) {
 		})
 	}
 }
+
+func TestNotificationServer_CreateRule(t *testing.T) {
+	type fields struct {
+		App                                    *Config
+		UnimplementedNotificationServiceServer proto.UnimplementedNotificationServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.ReminderRuleCreateRequest
+	}
+
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name:   "should create rule on expiry day",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ReminderRuleCreateRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+					DaysBefore: 0,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if days before is negative",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ReminderRuleCreateRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+					DaysBefore: -30,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "should fail if userId is incorrect",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ReminderRuleCreateRequest{
+					DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+					UserID:     "justWrongId",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name:   "should fail if documentId is not exists",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ReminderRuleCreateRequest{
+					DocumentID: "00000000-0000-0000-0000-000000000000",
+					UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				},
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &NotificationServer{
+				App:                                    tt.fields.App,
+				UnimplementedNotificationServiceServer: tt.fields.UnimplementedNotificationServiceServer,
+			}
+			got, err := ds.CreateRule(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NotificationServer.CreateRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if tt.errorMsg != nil && !errors.Is(err, tt.errorMsg) {
+					t.Errorf("NotificationServer.CreateRule() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if got.GetRule().GetDaysBefore() != tt.args.req.DaysBefore || got.GetRule().GetID() == "" {
+				t.Errorf("NotificationServer.CreateRule() = %v, want rule with %d days before", got.GetRule(), tt.args.req.DaysBefore)
+			}
+		})
+	}
+}
+
+func TestNotificationServer_DeleteRule(t *testing.T) {
+	ds := &NotificationServer{App: &testApp}
+
+	_, err := ds.DeleteRule(context.Background(), &proto.ReminderRuleRequest{
+		DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+		RuleID:     "justWrongId",
+	})
+	if !errors.Is(err, ErrInvalidRuleID) {
+		t.Errorf("NotificationServer.DeleteRule() error = %v, want %v", err, ErrInvalidRuleID)
+	}
+}
+
+func TestNotificationServer_CreatePreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.ReminderPresetCreateRequest
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name: "should create preset",
+			req: &proto.ReminderPresetCreateRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Type:       proto.Type_PASSPORT,
+				DaysBefore: 180,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.ReminderPresetCreateRequest{
+				UserID:     "justWrongId",
+				Type:       proto.Type_PASSPORT,
+				DaysBefore: 180,
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if type is unknown",
+			req: &proto.ReminderPresetCreateRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Type:       proto.Type(100),
+				DaysBefore: 180,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail if days before is negative",
+			req: &proto.ReminderPresetCreateRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Type:       proto.Type_PASSPORT,
+				DaysBefore: -1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &NotificationServer{App: &testApp}
+			got, err := ds.CreatePreset(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NotificationServer.CreatePreset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if tt.errorMsg != nil && !errors.Is(err, tt.errorMsg) {
+					t.Errorf("NotificationServer.CreatePreset() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if got.GetPreset().GetType() != tt.req.Type || got.GetPreset().GetDaysBefore() != tt.req.DaysBefore {
+				t.Errorf("NotificationServer.CreatePreset() = %v, want %v", got.GetPreset(), tt.req)
+			}
+		})
+	}
+}
+
+func TestNotificationServer_DeletePreset(t *testing.T) {
+	ds := &NotificationServer{App: &testApp}
+
+	_, err := ds.DeletePreset(context.Background(), &proto.ReminderPresetRequest{
+		UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+		PresetID: "justWrongId",
+	})
+	if !errors.Is(err, ErrInvalidPresetID) {
+		t.Errorf("NotificationServer.DeletePreset() error = %v, want %v", err, ErrInvalidPresetID)
+	}
+}
+
+func TestNotificationServer_GetRulesForUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.NotificationsAllRequest
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name: "should find rules of all user's documents",
+			req:  &proto.NotificationsAllRequest{UserID: "458c9061-5262-48b7-9b87-e47fa64d654c"},
+		},
+		{
+			name:     "should fail if userId is incorrect",
+			req:      &proto.NotificationsAllRequest{UserID: "wrongId"},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &NotificationServer{App: &testApp}
+			_, err := ds.GetRulesForUser(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NotificationServer.GetRulesForUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("NotificationServer.GetRulesForUser() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	"github.com/samgozman/validity.red/document/internal/utils"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// CreatePerson adds the person (dependent) whose documents the user manages.
+func (ds *DocumentServer) CreatePerson(ctx context.Context, req *proto.PersonRequest) (*proto.ResponsePerson, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	count, err := ds.App.Persons.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxPersonsPerUser {
+		return nil, ErrMaxPersonsLimit
+	}
+
+	p := person.Person{
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.Persons.InsertOne(ctx, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name is encrypted on insert, so the requested one is returned
+	p.Name = req.GetName()
+
+	return &proto.ResponsePerson{
+		Person: utils.ConvertPersonToProtoFormat(&p),
+	}, nil
+}
+
+// EditPerson renames the user's person.
+func (ds *DocumentServer) EditPerson(ctx context.Context, req *proto.PersonRequest) (*proto.ResponsePerson, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	personID, err := uuid.Parse(req.GetPersonID())
+	if err != nil {
+		return nil, ErrInvalidPersonID
+	}
+
+	p := person.Person{
+		ID:     personID,
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.Persons.UpdateOne(ctx, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponsePerson{
+		Person: &proto.Person{
+			ID:   personID.String(),
+			Name: req.GetName(),
+		},
+	}, nil
+}
+
+// DeletePerson deletes the user's person, its documents are kept as the user's own documents.
+func (ds *DocumentServer) DeletePerson(ctx context.Context, req *proto.PersonRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	personID, err := uuid.Parse(req.GetPersonID())
+	if err != nil {
+		return nil, ErrInvalidPersonID
+	}
+
+	err = ds.App.Persons.DeleteOne(ctx, &person.Person{
+		ID:     personID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetPersons returns all persons of the user.
+func (ds *DocumentServer) GetPersons(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponsePersonsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	persons, err := ds.App.Persons.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponsePersonsList{
+		Persons: utils.ConvertPersonsToProtoFormat(&persons),
+	}, nil
+}
+
+// Parse ID of the user's person assigned to the document, nil if it is not set.
+func (ds *DocumentServer) findPersonID(ctx context.Context, userID uuid.UUID, id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	personID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidPersonID
+	}
+
+	exists, err := ds.App.Persons.Exists(ctx, &person.Person{ID: personID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrPersonNotFound
+	}
+
+	return &personID, nil
+}
+
+// Parse ID of the person to filter documents by, Nil if it is not set.
+func parsePersonFilter(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, nil
+	}
+
+	personID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidPersonID
+	}
+
+	return personID, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDocumentServer_CreatePerson(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.PersonRequest
+		wantErr  bool
+		errorMsg error
+		wantCode codes.Code
+	}{
+		{
+			name: "should create person",
+			req: &proto.PersonRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Name:   "Alice",
+			},
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.PersonRequest{
+				UserID: "justWrongId",
+				Name:   "Alice",
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if name is empty",
+			req: &proto.PersonRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+			},
+			wantErr:  true,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "should fail if name is too long",
+			req: &proto.PersonRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Name:   strings.Repeat("a", person.MaxNameLength+1),
+			},
+			wantErr:  true,
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			got, err := ds.CreatePerson(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.CreatePerson() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if tt.errorMsg != nil && !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.CreatePerson() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				if tt.errorMsg == nil && status.Code(err) != tt.wantCode {
+					t.Errorf("DocumentServer.CreatePerson() wrong error code = %v, want %v", status.Code(err), tt.wantCode)
+				}
+				return
+			}
+			if got.GetPerson().GetID() == "" || got.GetPerson().GetName() != tt.req.GetName() {
+				t.Errorf("DocumentServer.CreatePerson() = %v, want person with name %v", got.GetPerson(), tt.req.GetName())
+			}
+		})
+	}
+}
+
+func TestDocumentServer_GetAll_PersonFilter(t *testing.T) {
+	ds := &DocumentServer{App: &testApp}
+
+	_, err := ds.GetAll(context.Background(), &proto.DocumentsRequest{
+		UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+		PersonID: "justWrongId",
+	})
+	if !errors.Is(err, ErrInvalidPersonID) {
+		t.Errorf("DocumentServer.GetAll() error = %v, want %v", err, ErrInvalidPersonID)
+	}
+
+	_, err = ds.GetUserStatistics(context.Background(), &proto.DocumentsRequest{
+		UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+		PersonID: "justWrongId",
+	})
+	if !errors.Is(err, ErrInvalidPersonID) {
+		t.Errorf("DocumentServer.GetUserStatistics() error = %v, want %v", err, ErrInvalidPersonID)
+	}
+}
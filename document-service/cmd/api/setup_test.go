@@ -4,8 +4,19 @@ import (
 	"os"
 	"testing"
 
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	attachment_mocks "github.com/samgozman/validity.red/document/mocks/models/attachment"
+	customtype_mocks "github.com/samgozman/validity.red/document/mocks/models/customtype"
+	datakey_mocks "github.com/samgozman/validity.red/document/mocks/models/datakey"
 	document_mocks "github.com/samgozman/validity.red/document/mocks/models/document"
 	notification_mocks "github.com/samgozman/validity.red/document/mocks/models/notification"
+	person_mocks "github.com/samgozman/validity.red/document/mocks/models/person"
+	preset_mocks "github.com/samgozman/validity.red/document/mocks/models/preset"
+	renewal_mocks "github.com/samgozman/validity.red/document/mocks/models/renewal"
+	rule_mocks "github.com/samgozman/validity.red/document/mocks/models/rule"
+	share_mocks "github.com/samgozman/validity.red/document/mocks/models/share"
+	tag_mocks "github.com/samgozman/validity.red/document/mocks/models/tag"
+	"github.com/samgozman/validity.red/document/pkg/storage"
 )
 
 var testApp Config
@@ -14,9 +25,35 @@ func TestMain(m *testing.M) {
 	testApp.limits = limits{
 		MaxDocumentsPerUser:         100,
 		MaxNotificationsPerDocument: 10,
+		MaxAttachmentsPerDocument:   5,
+		MaxAttachmentSize:           1024,
+		MaxPersonsPerUser:           20,
+		MaxCustomTypesPerUser:       20,
+		MaxTagsPerUser:              50,
+		MaxTagsPerDocument:          10,
 	}
 	testApp.Documents = document_mocks.NewDocumentDBTest(nil)
 	testApp.Notifications = notification_mocks.NewNotificationDBTest(nil)
+	testApp.DataKeys = datakey_mocks.NewDataKeyDBTest()
+	testApp.Attachments = attachment_mocks.NewAttachmentDBTest(nil)
+	testApp.Renewals = renewal_mocks.NewRenewalDBTest(nil)
+	testApp.Rules = rule_mocks.NewRuleDBTest(nil)
+	testApp.Presets = preset_mocks.NewPresetDBTest(nil)
+	testApp.Shares = share_mocks.NewShareDBTest(nil)
+	testApp.Persons = person_mocks.NewPersonDBTest(nil)
+	testApp.CustomTypes = customtype_mocks.NewCustomTypeDBTest(nil)
+	testApp.Tags = tag_mocks.NewTagDBTest(nil)
+	attachment.DataKeys = testApp.DataKeys
 
-	os.Exit(m.Run())
+	dir, err := os.MkdirTemp("", "attachments")
+	if err != nil {
+		panic(err)
+	}
+
+	testApp.Storage = &storage.LocalStorage{Dir: dir}
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
 }
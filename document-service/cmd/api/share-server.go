@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/internal/models/share"
+	"github.com/samgozman/validity.red/document/internal/utils"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Share gives the group members access to one document or to all documents of the user.
+// Documents can be shared only with the groups the user is a member of.
+func (ds *DocumentServer) Share(ctx context.Context, req *proto.DocumentShareRequest) (*proto.ResponseShare, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	groupID, err := uuid.Parse(req.GetGroupID())
+	if err != nil {
+		return nil, ErrInvalidGroupID
+	}
+
+	groupIDs, err := parseGroupIDs(req.GetGroupIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsID(groupIDs, groupID) {
+		return nil, ErrGroupNotFound
+	}
+
+	s := share.Share{
+		UserID:     userID,
+		GroupID:    groupID,
+		Permission: req.GetPermission(),
+	}
+
+	if req.GetDocumentID() != "" {
+		documentID, err := uuid.Parse(req.GetDocumentID())
+		if err != nil {
+			return nil, ErrInvalidDocumentID
+		}
+
+		// Only own documents can be shared
+		exists, err := ds.App.Documents.Exists(ctx, &document.Document{ID: documentID, UserID: userID})
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, ErrDocumentNotFound
+		}
+
+		s.DocumentID = &documentID
+	}
+
+	err = ds.App.Shares.InsertOne(ctx, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseShare{
+		Share: utils.ConvertShareToProtoFormat(&s),
+	}, nil
+}
+
+// Unshare deletes the user's share, group members lose access to its documents.
+func (ds *DocumentServer) Unshare(ctx context.Context, req *proto.ShareRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	shareID, err := uuid.Parse(req.GetShareID())
+	if err != nil {
+		return nil, ErrInvalidShareID
+	}
+
+	err = ds.App.Shares.DeleteOne(ctx, &share.Share{ID: shareID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetShares returns the shares created by the user.
+func (ds *DocumentServer) GetShares(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseSharesList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	shares, err := ds.App.Shares.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseSharesList{
+		Shares: utils.ConvertSharesToProtoFormat(&shares),
+	}, nil
+}
+
+// GetShared returns the documents of other users shared with the user's groups.
+func (ds *DocumentServer) GetShared(ctx context.Context, req *proto.SharedDocumentsRequest) (*proto.ResponseDocumentsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	groupIDs, err := parseGroupIDs(req.GetGroupIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := ds.App.Documents.FindShared(ctx, userID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseDocumentsList{
+		Documents: utils.ConvertDocumentsToProtoFormat(&documents),
+	}, nil
+}
+
+// DeleteGroupShares deletes all shares of the deleted group or the shares of the member who left it.
+func (ds *DocumentServer) DeleteGroupShares(ctx context.Context, req *proto.GroupSharesRequest) (*emptypb.Empty, error) {
+	groupID, err := uuid.Parse(req.GetGroupID())
+	if err != nil {
+		return nil, ErrInvalidGroupID
+	}
+
+	userID := uuid.Nil
+	if req.GetUserID() != "" {
+		userID, err = uuid.Parse(req.GetUserID())
+		if err != nil {
+			return nil, ErrInvalidUserID
+		}
+	}
+
+	err = ds.App.Shares.DeleteForGroup(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Find the owner of the document available to the user: the user itself
+// or the owner of the document shared with the user's groups.
+// Edit permission of the share is required to change the document.
+func (app *Config) documentOwner(
+	ctx context.Context,
+	userID uuid.UUID,
+	documentID uuid.UUID,
+	groupIDs []string,
+	edit bool,
+) (uuid.UUID, error) {
+	// Own documents are checked by the queries themselves
+	if len(groupIDs) == 0 {
+		return userID, nil
+	}
+
+	exists, err := app.Documents.Exists(ctx, &document.Document{ID: documentID, UserID: userID})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if exists {
+		return userID, nil
+	}
+
+	return app.sharedDocumentOwner(ctx, documentID, groupIDs, edit)
+}
+
+// Find the owner of the document shared with any of the groups and check the share permission.
+func (app *Config) sharedDocumentOwner(
+	ctx context.Context,
+	documentID uuid.UUID,
+	groupIDs []string,
+	edit bool,
+) (uuid.UUID, error) {
+	groups, err := parseGroupIDs(groupIDs)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	access, err := app.Shares.FindAccess(ctx, documentID, groups)
+	if status.Code(err) == codes.NotFound {
+		return uuid.Nil, ErrDocumentNotFound
+	}
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if edit && access.Permission != proto.SharePermission_EDIT {
+		return uuid.Nil, ErrPermissionDenied
+	}
+
+	return access.OwnerID, nil
+}
+
+func parseGroupIDs(ids []string) ([]uuid.UUID, error) {
+	groupIDs := make([]uuid.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		groupID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidGroupID
+		}
+
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	return groupIDs, nil
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
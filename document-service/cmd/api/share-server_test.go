@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	share_mocks "github.com/samgozman/validity.red/document/mocks/models/share"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+func TestDocumentServer_Share(t *testing.T) {
+	tests := []struct {
+		name           string
+		req            *proto.DocumentShareRequest
+		wantErr        bool
+		errorMsg       error
+		wantDocumentID string
+	}{
+		{
+			name: "should share all documents",
+			req: &proto.DocumentShareRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupID:    share_mocks.ReadGroupID,
+				Permission: proto.SharePermission_READ,
+				GroupIDs:   []string{share_mocks.ReadGroupID},
+			},
+		},
+		{
+			name: "should share one document",
+			req: &proto.DocumentShareRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupID:    share_mocks.EditGroupID,
+				DocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+				Permission: proto.SharePermission_EDIT,
+				GroupIDs:   []string{share_mocks.ReadGroupID, share_mocks.EditGroupID},
+			},
+			wantDocumentID: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.DocumentShareRequest{
+				UserID:   "justWrongId",
+				GroupID:  share_mocks.ReadGroupID,
+				GroupIDs: []string{share_mocks.ReadGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if groupId is incorrect",
+			req: &proto.DocumentShareRequest{
+				UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupID:  "justWrongId",
+				GroupIDs: []string{share_mocks.ReadGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidGroupID,
+		},
+		{
+			name: "should fail if user is not a member of the group",
+			req: &proto.DocumentShareRequest{
+				UserID:   "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupID:  share_mocks.EditGroupID,
+				GroupIDs: []string{share_mocks.ReadGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrGroupNotFound,
+		},
+		{
+			name: "should fail if document is not found",
+			req: &proto.DocumentShareRequest{
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupID:    share_mocks.ReadGroupID,
+				DocumentID: share_mocks.SharedDocumentID,
+				GroupIDs:   []string{share_mocks.ReadGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			got, err := ds.Share(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.Share() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.Share() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				return
+			}
+			if got.GetShare().GetID() == "" {
+				t.Errorf("DocumentServer.Share() share ID is empty")
+			}
+			if got.GetShare().GetDocumentID() != tt.wantDocumentID {
+				t.Errorf("DocumentServer.Share() documentID = %v, want %v", got.GetShare().GetDocumentID(), tt.wantDocumentID)
+			}
+		})
+	}
+}
+
+func TestDocumentServer_GetOne_Shared(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.DocumentRequest
+		wantErr  bool
+		errorMsg error
+	}{
+		{
+			name: "should get the document shared with the group",
+			req: &proto.DocumentRequest{
+				DocumentID: share_mocks.SharedDocumentID,
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupIDs:   []string{share_mocks.ReadGroupID},
+			},
+		},
+		{
+			name: "should fail if document is not shared with the groups",
+			req: &proto.DocumentRequest{
+				DocumentID: "45d4202d-d7ee-4d48-a4ac-f81b9448b1d9",
+				UserID:     "458c9061-5262-48b7-9b87-e47fa64d654c",
+				GroupIDs:   []string{share_mocks.ReadGroupID},
+			},
+			wantErr:  true,
+			errorMsg: ErrDocumentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			_, err := ds.GetOne(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.GetOne() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, tt.errorMsg) {
+				t.Errorf("DocumentServer.GetOne() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+			}
+		})
+	}
+}
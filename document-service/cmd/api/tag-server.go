@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
+	"github.com/samgozman/validity.red/document/internal/utils"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// CreateTag adds the free-form label of the documents.
+func (ds *DocumentServer) CreateTag(ctx context.Context, req *proto.TagRequest) (*proto.ResponseTag, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	count, err := ds.App.Tags.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= ds.App.limits.MaxTagsPerUser {
+		return nil, ErrMaxTagsLimit
+	}
+
+	t := tag.Tag{
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.Tags.InsertOne(ctx, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name is encrypted on insert, so the requested one is returned
+	t.Name = req.GetName()
+
+	return &proto.ResponseTag{
+		Tag: utils.ConvertTagToProtoFormat(&t),
+	}, nil
+}
+
+// EditTag renames the user's tag.
+func (ds *DocumentServer) EditTag(ctx context.Context, req *proto.TagRequest) (*proto.ResponseTag, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	tagID, err := uuid.Parse(req.GetTagID())
+	if err != nil {
+		return nil, ErrInvalidTagID
+	}
+
+	t := tag.Tag{
+		ID:     tagID,
+		UserID: userID,
+		Name:   req.GetName(),
+	}
+
+	err = ds.App.Tags.UpdateOne(ctx, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseTag{
+		Tag: &proto.Tag{
+			ID:   tagID.String(),
+			Name: req.GetName(),
+		},
+	}, nil
+}
+
+// DeleteTag deletes the user's tag and removes it from all documents.
+func (ds *DocumentServer) DeleteTag(ctx context.Context, req *proto.TagRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	tagID, err := uuid.Parse(req.GetTagID())
+	if err != nil {
+		return nil, ErrInvalidTagID
+	}
+
+	err = ds.App.Tags.DeleteOne(ctx, &tag.Tag{
+		ID:     tagID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetTags returns all tags of the user.
+func (ds *DocumentServer) GetTags(ctx context.Context, req *proto.DocumentsRequest) (*proto.ResponseTagsList, error) {
+	userID, err := uuid.Parse(req.GetUserID())
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	tags, err := ds.App.Tags.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ResponseTagsList{
+		Tags: utils.ConvertTagsToProtoFormat(&tags),
+	}, nil
+}
+
+// Parse IDs of the user's tags assigned to the document, duplicates are skipped.
+func (ds *DocumentServer) findTags(ctx context.Context, userID uuid.UUID, ids []string) ([]tag.DocumentTag, error) {
+	tagIDs, err := parseTagFilter(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make([]uuid.UUID, 0, len(tagIDs))
+
+	for _, id := range tagIDs {
+		if !containsID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	if int64(len(unique)) > ds.App.limits.MaxTagsPerDocument {
+		return nil, ErrMaxDocumentTagsLimit
+	}
+
+	exist, err := ds.App.Tags.ExistAll(ctx, userID, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist {
+		return nil, ErrTagNotFound
+	}
+
+	tags := make([]tag.DocumentTag, 0, len(unique))
+	for _, id := range unique {
+		tags = append(tags, tag.DocumentTag{TagID: id})
+	}
+
+	return tags, nil
+}
+
+// Count documents with the tags with their names.
+func (ds *DocumentServer) countTags(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTagsCount, error) {
+	counts, err := ds.App.Documents.CountTags(ctx, userID, personID)
+	if err != nil || len(counts) == 0 {
+		return counts, err
+	}
+
+	tags, err := ds.App.Tags.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range counts {
+		for _, t := range tags {
+			if t.ID.String() == c.TagID {
+				c.Name = t.Name
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// Parse IDs of the tags to filter documents by.
+func parseTagFilter(ids []string) ([]uuid.UUID, error) {
+	tagIDs := make([]uuid.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		tagID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidTagID
+		}
+
+		tagIDs = append(tagIDs, tagID)
+	}
+
+	return tagIDs, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samgozman/validity.red/document/internal/models/tag"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDocumentServer_CreateTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.TagRequest
+		wantErr  bool
+		errorMsg error
+		wantCode codes.Code
+	}{
+		{
+			name: "should create tag",
+			req: &proto.TagRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Name:   "Work",
+			},
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.TagRequest{
+				UserID: "justWrongId",
+				Name:   "Work",
+			},
+			wantErr:  true,
+			errorMsg: ErrInvalidUserID,
+		},
+		{
+			name: "should fail if name is too long",
+			req: &proto.TagRequest{
+				UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+				Name:   strings.Repeat("a", tag.MaxNameLength+1),
+			},
+			wantErr:  true,
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DocumentServer{App: &testApp}
+			got, err := ds.CreateTag(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentServer.CreateTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if tt.errorMsg != nil && !errors.Is(err, tt.errorMsg) {
+					t.Errorf("DocumentServer.CreateTag() wrong error msg = %v, want %v", err.Error(), tt.errorMsg.Error())
+				}
+				if tt.errorMsg == nil && status.Code(err) != tt.wantCode {
+					t.Errorf("DocumentServer.CreateTag() wrong error code = %v, want %v", status.Code(err), tt.wantCode)
+				}
+				return
+			}
+			if got.GetTag().GetID() == "" || got.GetTag().GetName() != tt.req.GetName() {
+				t.Errorf("DocumentServer.CreateTag() = %v, want tag with name %v", got.GetTag(), tt.req.GetName())
+			}
+		})
+	}
+}
+
+func TestDocumentServer_GetAll_TagFilter(t *testing.T) {
+	ds := &DocumentServer{App: &testApp}
+
+	_, err := ds.GetAll(context.Background(), &proto.DocumentsRequest{
+		UserID: "458c9061-5262-48b7-9b87-e47fa64d654c",
+		TagIDs: []string{"justWrongId"},
+	})
+	if !errors.Is(err, ErrInvalidTagID) {
+		t.Errorf("DocumentServer.GetAll() error = %v, want %v", err, ErrInvalidTagID)
+	}
+
+	_, err = ds.GetAll(context.Background(), &proto.DocumentsRequest{
+		UserID:        "458c9061-5262-48b7-9b87-e47fa64d654c",
+		CustomTypeIDs: []string{"justWrongId"},
+	})
+	if !errors.Is(err, ErrInvalidCustomTypeID) {
+		t.Errorf("DocumentServer.GetAll() error = %v, want %v", err, ErrInvalidCustomTypeID)
+	}
+}
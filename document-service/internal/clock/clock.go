@@ -0,0 +1,16 @@
+// Package clock is used by the background jobs to get the current time.
+package clock
+
+import "time"
+
+// Clock is used to get the current time. Can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// System returns the current system time.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,298 @@
+// Package exporter writes the user's documents with their reminders to CSV, JSON or PDF files.
+//
+// CSV and JSON files have the same fields as the imported ones, so they can be imported back.
+// Person, custom type and tags are exported by their names and are ignored on import.
+// Dates of CSV and JSON files are in UTC like the imported ones, dates of the PDF summary
+// are in the user's timezone.
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("unsupported file format")
+	ErrUnsupportedText = errors.New("text has characters not supported by the PDF fonts")
+)
+
+// Documents expiring within this period are marked as expiring soon.
+const ExpiringSoonPeriod = 30 * 24 * time.Hour
+
+const dateLayout = "2006-01-02"
+
+// Exported document with the dates of its reminders.
+type Entry struct {
+	Title         string
+	Type          proto.Type
+	Description   string
+	ExpiresAt     time.Time
+	RenewalMonths int32
+	Person        string // Name of the person whose document it is
+	CustomType    string // Name of the user-defined type, the type is OTHER if it is set
+	Tags          []string
+	Reminders     []time.Time
+}
+
+// Entries joins documents with their notifications and the names of their tags by ID.
+// Reminders of each document are sorted by date, tags are sorted by name.
+func Entries(documents []*proto.Document, notifications []*proto.Notification, tagNames map[string]string) []Entry {
+	reminders := make(map[string][]time.Time, len(documents))
+	for _, n := range notifications {
+		reminders[n.DocumentID] = append(reminders[n.DocumentID], n.Date.AsTime())
+	}
+
+	entries := make([]Entry, 0, len(documents))
+
+	for _, d := range documents {
+		dates := reminders[d.ID]
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+		tags := make([]string, 0, len(d.TagIDs))
+		for _, id := range d.TagIDs {
+			if name, ok := tagNames[id]; ok {
+				tags = append(tags, name)
+			}
+		}
+
+		sort.Strings(tags)
+
+		entries = append(entries, Entry{
+			Title:         d.Title,
+			Type:          d.Type,
+			Description:   d.Description,
+			ExpiresAt:     d.ExpiresAt.AsTime(),
+			RenewalMonths: d.RenewalMonths,
+			Person:        d.PersonName,
+			CustomType:    d.CustomTypeName,
+			Tags:          tags,
+			Reminders:     dates,
+		})
+	}
+
+	return entries
+}
+
+// Write entries to w in the format. Expiry status in the PDF summary is calculated at now.
+func Write(w io.Writer, format proto.ExportFormat, entries []Entry, now time.Time) error {
+	switch format {
+	case proto.ExportFormat_EXPORT_CSV:
+		return WriteCSV(w, entries)
+	case proto.ExportFormat_EXPORT_JSON:
+		return WriteJSON(w, entries)
+	case proto.ExportFormat_EXPORT_PDF:
+		return WritePDF(w, entries, now)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// WriteCSV writes entries with the header row, tags and reminders are joined with semicolons.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{
+		"title", "type", "description", "expiresAt", "renewalMonths", "reminders", "person", "customType", "tags",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err := cw.Write([]string{
+			e.Title,
+			e.Type.String(),
+			e.Description,
+			e.ExpiresAt.UTC().Format(dateLayout),
+			strconv.Itoa(int(e.RenewalMonths)),
+			strings.Join(formatDates(e.Reminders, time.UTC), ";"),
+			e.Person,
+			e.CustomType,
+			strings.Join(e.Tags, ";"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+type entryJSON struct {
+	Title         string   `json:"title"`
+	Type          string   `json:"type"`
+	Description   string   `json:"description"`
+	ExpiresAt     string   `json:"expiresAt"`
+	RenewalMonths int32    `json:"renewalMonths"`
+	Reminders     []string `json:"reminders"`
+	Person        string   `json:"person,omitempty"`
+	CustomType    string   `json:"customType,omitempty"`
+	Tags          []string `json:"tags"`
+}
+
+// WriteJSON writes entries as the array of objects.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	items := make([]entryJSON, 0, len(entries))
+
+	for _, e := range entries {
+		items = append(items, entryJSON{
+			Title:         e.Title,
+			Type:          e.Type.String(),
+			Description:   e.Description,
+			ExpiresAt:     e.ExpiresAt.UTC().Format(dateLayout),
+			RenewalMonths: e.RenewalMonths,
+			Reminders:     formatDates(e.Reminders, time.UTC),
+			Person:        e.Person,
+			CustomType:    e.CustomType,
+			Tags:          e.Tags,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(items)
+}
+
+// Expiry status of the document in the PDF summary.
+type expiryStatus int
+
+const (
+	statusExpired expiryStatus = iota
+	statusExpiringSoon
+	statusValid
+)
+
+var statusTitles = map[expiryStatus]string{
+	statusExpired:      "Expired",
+	statusExpiringSoon: "Expiring within 30 days",
+	statusValid:        "Valid",
+}
+
+func statusOf(e Entry, now time.Time) expiryStatus {
+	switch {
+	case !e.ExpiresAt.After(now):
+		return statusExpired
+	case e.ExpiresAt.Before(now.Add(ExpiringSoonPeriod)):
+		return statusExpiringSoon
+	default:
+		return statusValid
+	}
+}
+
+// WritePDF writes the printable summary with documents grouped by type and expiry status,
+// the closest to the expiry first. Dates are written in the location of now.
+//
+// Standard PDF fonts support only the Western European characters (WinAnsi encoding),
+// so ErrUnsupportedText is returned if any text of the summary has other characters.
+func WritePDF(w io.Writer, entries []Entry, now time.Time) error {
+	for _, e := range entries {
+		texts := append([]string{e.Title, e.Person, e.CustomType}, e.Tags...)
+		for _, text := range texts {
+			if !isWinAnsi(text) {
+				return fmt.Errorf("%w: %q", ErrUnsupportedText, text)
+			}
+		}
+	}
+
+	loc := now.Location()
+
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+
+		if sorted[i].CustomType != sorted[j].CustomType {
+			return sorted[i].CustomType < sorted[j].CustomType
+		}
+
+		si, sj := statusOf(sorted[i], now), statusOf(sorted[j], now)
+		if si != sj {
+			return si < sj
+		}
+
+		return sorted[i].ExpiresAt.Before(sorted[j].ExpiresAt)
+	})
+
+	doc := newPDF()
+	doc.Heading("Validity.Red documents summary")
+	doc.Text("Generated on " + now.Format(dateLayout) + ", " + strconv.Itoa(len(entries)) + " documents")
+
+	for i, e := range sorted {
+		newType := i == 0 || sorted[i-1].Type != e.Type || sorted[i-1].CustomType != e.CustomType
+		if newType {
+			doc.Space()
+			doc.Heading(e.typeTitle())
+		}
+
+		status := statusOf(e, now)
+		if newType || statusOf(sorted[i-1], now) != status {
+			doc.Subheading(statusTitles[status])
+		}
+
+		line := e.Title
+		if e.Person != "" {
+			line += " (" + e.Person + ")"
+		}
+
+		line += " - expires " + e.ExpiresAt.In(loc).Format(dateLayout)
+		if e.RenewalMonths > 0 {
+			line += ", renewed every " + strconv.Itoa(int(e.RenewalMonths)) + " months"
+		}
+
+		if len(e.Reminders) > 0 {
+			line += ", reminders: " + strings.Join(formatDates(e.Reminders, loc), ", ")
+		}
+
+		if len(e.Tags) > 0 {
+			line += ", tags: " + strings.Join(e.Tags, ", ")
+		}
+
+		doc.Text(line)
+	}
+
+	_, err := doc.WriteTo(w)
+
+	return err
+}
+
+// Name of the custom type or the human readable name of the built-in type.
+func (e *Entry) typeTitle() string {
+	if e.CustomType != "" {
+		return e.CustomType
+	}
+
+	return typeTitle(e.Type)
+}
+
+// Human readable name of the document type, e.g. "Driver license".
+func typeTitle(t proto.Type) string {
+	name := strings.ReplaceAll(strings.ToLower(t.String()), "_", " ")
+	if t == proto.Type_DEFAULT_DOCUMENT {
+		name = "document"
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func formatDates(dates []time.Time, loc *time.Location) []string {
+	result := make([]string, 0, len(dates))
+	for _, d := range dates {
+		result = append(result, d.In(loc).Format(dateLayout))
+	}
+
+	return result
+}
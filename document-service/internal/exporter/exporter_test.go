@@ -0,0 +1,228 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samgozman/validity.red/document/internal/importer"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testEntries() []Entry {
+	documents := []*proto.Document{
+		{
+			ID:            "434377cf-7509-4cc0-9895-0afa683f0e56",
+			Type:          proto.Type_PASSPORT,
+			Title:         "Passport (old)",
+			Description:   "Renew, please",
+			ExpiresAt:     timestamppb.New(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)),
+			RenewalMonths: 120,
+			PersonName:    "Alice",
+			TagIDs:        []string{"tag-travel", "tag-family", "tag-deleted"},
+		},
+		{
+			ID:        "2a0f1f0e-0e0f-4b43-9c66-5d5a1c0b0c6e",
+			Type:      proto.Type_COUPON,
+			Title:     "Café coupon",
+			ExpiresAt: timestamppb.New(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			ID:             "9d3c8a52-6c4e-4f0e-8f5b-3a1d2c4b5e6f",
+			Type:           proto.Type_OTHER,
+			Title:          "Gym",
+			ExpiresAt:      timestamppb.New(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)),
+			CustomTypeName: "Gym membership",
+		},
+	}
+
+	notifications := []*proto.Notification{
+		{DocumentID: documents[0].ID, Date: timestamppb.New(time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC))},
+		{DocumentID: documents[0].ID, Date: timestamppb.New(time.Date(2029, 7, 1, 0, 0, 0, 0, time.UTC))},
+	}
+
+	tagNames := map[string]string{"tag-travel": "Travel", "tag-family": "Family"}
+
+	return Entries(documents, notifications, tagNames)
+}
+
+func TestEntries(t *testing.T) {
+	entries := testEntries()
+
+	if len(entries) != 3 {
+		t.Fatalf("Entries() = %d entries, want 3", len(entries))
+	}
+
+	reminders := entries[0].Reminders
+	if len(reminders) != 2 || !reminders[0].Before(reminders[1]) {
+		t.Errorf("Entries() reminders = %v, want 2 sorted dates", reminders)
+	}
+
+	if len(entries[1].Reminders) != 0 {
+		t.Errorf("Entries() reminders = %v, want none", entries[1].Reminders)
+	}
+
+	if entries[0].Person != "Alice" || strings.Join(entries[0].Tags, ",") != "Family,Travel" {
+		t.Errorf("Entries() person = %s, tags = %v, want Alice with sorted existing tags", entries[0].Person, entries[0].Tags)
+	}
+
+	if entries[2].CustomType != "Gym membership" {
+		t.Errorf("Entries() custom type = %s, want Gym membership", entries[2].CustomType)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteCSV(&buf, testEntries())
+	if err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Passport (old),PASSPORT,\"Renew, please\",2030-01-01,120,2029-07-01;2029-12-01,Alice,,Family;Travel") {
+		t.Errorf("WriteCSV() = %s", buf.String())
+	}
+
+	// Exported file can be imported back
+	rows, err := importer.Parse(proto.ImportFormat_CSV, buf.Bytes())
+	if err != nil {
+		t.Fatalf("importer.Parse() error = %v", err)
+	}
+
+	if len(rows) != 3 || rows[0].Err != nil || rows[0].Document.Title != "Passport (old)" || *rows[1].Document.Type != proto.Type_COUPON {
+		t.Errorf("importer.Parse() = %v, want exported documents", rows)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteJSON(&buf, testEntries())
+	if err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var items []entryJSON
+
+	err = json.Unmarshal(buf.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("WriteJSON() invalid json = %v", err)
+	}
+
+	if len(items) != 3 || items[0].Type != "PASSPORT" || items[0].Person != "Alice" || items[2].CustomType != "Gym membership" || len(items[0].Reminders) != 2 || items[1].Reminders == nil {
+		t.Errorf("WriteJSON() = %s", buf.String())
+	}
+
+	rows, err := importer.Parse(proto.ImportFormat_JSON, buf.Bytes())
+	if err != nil || len(rows) != 3 || rows[0].Err != nil {
+		t.Errorf("importer.Parse() = %v, %v, want exported documents", rows, err)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WritePDF(&buf, testEntries(), now)
+	if err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("WritePDF() is not a PDF file")
+	}
+
+	for _, want := range []string{
+		"(Passport)", "(Valid)", "(Passport \\(old\\) \\(Alice\\) - expires 2030-01-01", "tags: Family, Travel)",
+		"(Coupon)", "(Expired)", "(Caf\\351 coupon", "(Gym membership)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WritePDF() has no %s", want)
+		}
+	}
+}
+
+func TestWritePDF_Timezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone database is not available")
+	}
+
+	entries := []Entry{{Title: "Passport", ExpiresAt: time.Date(2030, 1, 1, 3, 0, 0, 0, time.UTC)}}
+
+	var buf bytes.Buffer
+
+	err = WritePDF(&buf, entries, now.In(newYork))
+	if err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "(Passport - expires 2029-12-31)") {
+		t.Errorf("WritePDF() should write dates in the location of now")
+	}
+}
+
+func TestWritePDF_UnsupportedText(t *testing.T) {
+	entries := []Entry{{Title: "Паспорт", ExpiresAt: now}}
+
+	err := WritePDF(&bytes.Buffer{}, entries, now)
+	if !errors.Is(err, ErrUnsupportedText) {
+		t.Errorf("WritePDF() error = %v, want %v", err, ErrUnsupportedText)
+	}
+}
+
+func TestWritePDF_Pages(t *testing.T) {
+	entries := make([]Entry, 0, 50)
+	for i := 0; i < 50; i++ {
+		entries = append(entries, Entry{Title: "Document", ExpiresAt: now})
+	}
+
+	var buf bytes.Buffer
+
+	err := WritePDF(&buf, entries, now)
+	if err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "/Count 2 ") {
+		t.Errorf("WritePDF() should have 2 pages")
+	}
+}
+
+func Test_wrap(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "short", text: "one two", want: []string{"one two"}},
+		{name: "by words", text: "one two three", want: []string{"one two", "three"}},
+		{name: "long word", text: "onetwothree", want: []string{"onetwoth", "ree"}},
+		{name: "empty", text: "", want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrap(tt.text, 8)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pdfString(t *testing.T) {
+	if got := pdfString(`a(b)\ é Ж`); got != `a\(b\)\\ \351 ?` {
+		t.Errorf("pdfString() = %s", got)
+	}
+
+	if got := pdfString("“5 €” – ok"); got != `\2235 \200\224 \226 ok` {
+		t.Errorf("pdfString() = %s", got)
+	}
+}
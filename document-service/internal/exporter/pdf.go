@@ -0,0 +1,230 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A4 page size and layout in points.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 50
+	maxLineChars = 95 // Approximate number of Helvetica characters of size 10 in the line
+)
+
+type pdfLine struct {
+	text   string
+	font   string // Font resource name
+	size   int
+	indent int
+	height int // Space taken by the line including the gap before it
+}
+
+// Minimal PDF writer of the text pages with the standard Helvetica fonts.
+// Standard fonts support only WinAnsi characters, see isWinAnsi.
+type pdfDoc struct {
+	pages [][]pdfLine
+	y     int // Space left on the current page
+}
+
+func newPDF() *pdfDoc {
+	return &pdfDoc{}
+}
+
+func (p *pdfDoc) Heading(text string) {
+	p.add(pdfLine{text: text, font: "F2", size: 14, height: 22})
+}
+
+func (p *pdfDoc) Subheading(text string) {
+	p.add(pdfLine{text: text, font: "F2", size: 11, indent: 10, height: 18})
+}
+
+// Text adds the paragraph wrapped by words to fit the page width.
+func (p *pdfDoc) Text(text string) {
+	for _, line := range wrap(text, maxLineChars) {
+		p.add(pdfLine{text: line, font: "F1", size: 10, indent: 20, height: 14})
+	}
+}
+
+// Space adds an empty line.
+func (p *pdfDoc) Space() {
+	p.add(pdfLine{height: 10})
+}
+
+func (p *pdfDoc) add(l pdfLine) {
+	if len(p.pages) == 0 || p.y < l.height {
+		p.pages = append(p.pages, nil)
+		p.y = pageHeight - 2*pageMargin
+	}
+
+	p.y -= l.height
+	p.pages[len(p.pages)-1] = append(p.pages[len(p.pages)-1], l)
+}
+
+// WriteTo writes the PDF file with the cross-reference table.
+func (p *pdfDoc) WriteTo(w io.Writer) (int64, error) {
+	if len(p.pages) == 0 {
+		p.Space()
+	}
+
+	var buf bytes.Buffer
+
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, pages tree and fonts, then every page has the page and content objects
+	kids := make([]string, 0, len(p.pages))
+	for i := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range p.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2,
+		))
+
+		content := pageContent(lines)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// Content stream of the page with lines placed from the top.
+func pageContent(lines []pdfLine) string {
+	var b strings.Builder
+
+	y := pageHeight - pageMargin
+
+	for _, l := range lines {
+		y -= l.height
+		if l.text == "" {
+			continue
+		}
+
+		fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", l.font, l.size, pageMargin+l.indent, y, pdfString(l.text))
+	}
+
+	return b.String()
+}
+
+// Codes of the WinAnsi characters which are not in Latin-1 (typographic quotes, dashes, euro sign, etc.).
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// Get the WinAnsi code of the character, whitespace characters are written as spaces.
+func winAnsiCode(r rune) (byte, bool) {
+	switch {
+	case r >= 0x20 && r <= 0x7e:
+		return byte(r), true
+	case r >= 0xa0 && r <= 0xff:
+		// Latin-1 characters have the same codes in WinAnsi encoding
+		return byte(r), true
+	case unicode.IsSpace(r):
+		return ' ', true
+	}
+
+	code, ok := winAnsiExtra[r]
+
+	return code, ok
+}
+
+// Check if all characters of the text can be written with the standard fonts.
+func isWinAnsi(text string) bool {
+	for _, r := range text {
+		if _, ok := winAnsiCode(r); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Escape the text for the PDF literal string in WinAnsi encoding.
+// Unsupported characters are replaced with "?", the text should be checked with isWinAnsi before.
+func pdfString(text string) string {
+	var b strings.Builder
+
+	for _, r := range text {
+		code, ok := winAnsiCode(r)
+
+		switch {
+		case !ok:
+			b.WriteByte('?')
+		case code == '(' || code == ')' || code == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(code)
+		case code < 0x80:
+			b.WriteByte(code)
+		default:
+			fmt.Fprintf(&b, "\\%03o", code)
+		}
+	}
+
+	return b.String()
+}
+
+// Split the text into lines of max n characters by words.
+func wrap(text string, n int) []string {
+	var lines []string
+
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > n {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			runes := []rune(word)
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= n:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,237 @@
+// Package importer parses the documents list imported from CSV or JSON files.
+//
+// Every row is parsed independently, so the errors are reported per row
+// and the valid rows can still be imported.
+// Rows are numbered from 1 without the CSV header.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+var (
+	ErrEmptyFile     = errors.New("file is empty")
+	ErrInvalidFormat = errors.New("unsupported file format")
+	ErrNoTitleColumn = errors.New("title column is required")
+)
+
+// Accepted formats of the expiration date.
+var dateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// Parsed row of the imported file, Err is set if the row can't be converted to the document.
+type Row struct {
+	Number   int
+	Document document.Document
+	Err      error
+}
+
+// Fields of the imported document as they are written in the file.
+type entry struct {
+	Title         string          `json:"title"`
+	Type          json.RawMessage `json:"type"` // Type name or its number
+	Description   string          `json:"description"`
+	ExpiresAt     string          `json:"expiresAt"`
+	RenewalMonths json.RawMessage `json:"renewalMonths"`
+}
+
+// Parse the content of the file in the format. Error is returned only if the whole file can't be read.
+func Parse(format proto.ImportFormat, content []byte) ([]Row, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	switch format {
+	case proto.ImportFormat_CSV:
+		return parseCSV(content)
+	case proto.ImportFormat_JSON:
+		return parseJSON(content)
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+// CSV file should have a header with the column names, unknown columns are ignored.
+// Column names are case insensitive, spaces, dashes and underscores are ignored (e.g. "Expires at").
+func parseCSV(content []byte) ([]Row, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[normalizeColumn(name)] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, ErrNoTitleColumn
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []Row
+
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			// Rest of the file can't be read reliably after the broken quotes
+			rows = append(rows, Row{Number: n, Err: fmt.Errorf("invalid csv: %w", err)})
+			break
+		}
+
+		rows = append(rows, convert(n, entry{
+			Title:         field(record, "title"),
+			Type:          json.RawMessage(strconv.Quote(field(record, "type"))),
+			Description:   field(record, "description"),
+			ExpiresAt:     field(record, "expiresat"),
+			RenewalMonths: json.RawMessage(strconv.Quote(field(record, "renewalmonths"))),
+		}))
+	}
+
+	return rows, nil
+}
+
+// JSON file should be an array of objects with the fields of the document.
+func parseJSON(content []byte) ([]Row, error) {
+	var items []json.RawMessage
+
+	err := json.Unmarshal(content, &items)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	rows := make([]Row, 0, len(items))
+
+	for i, item := range items {
+		e := entry{}
+
+		err := json.Unmarshal(item, &e)
+		if err != nil {
+			rows = append(rows, Row{Number: i + 1, Err: fmt.Errorf("invalid json: %w", err)})
+			continue
+		}
+
+		rows = append(rows, convert(i+1, e))
+	}
+
+	return rows, nil
+}
+
+// Convert entry fields to the document, fields are not validated here.
+func convert(n int, e entry) Row {
+	t, err := ParseType(rawString(e.Type))
+	if err != nil {
+		return Row{Number: n, Err: err}
+	}
+
+	var expiresAt time.Time
+
+	if date := strings.TrimSpace(e.ExpiresAt); date != "" {
+		expiresAt, err = parseDate(date)
+		if err != nil {
+			return Row{Number: n, Err: err}
+		}
+	}
+
+	var renewalMonths int32
+
+	if months := rawString(e.RenewalMonths); months != "" {
+		m, err := strconv.ParseInt(months, 10, 32)
+		if err != nil {
+			return Row{Number: n, Err: fmt.Errorf("invalid renewal months '%s'", months)}
+		}
+
+		renewalMonths = int32(m)
+	}
+
+	return Row{
+		Number: n,
+		Document: document.Document{
+			Title:         strings.TrimSpace(e.Title),
+			Type:          &t,
+			Description:   strings.TrimSpace(e.Description),
+			ExpiresAt:     expiresAt,
+			RenewalMonths: &renewalMonths,
+		},
+	}
+}
+
+// ParseType maps the type name (e.g. "passport", "Driver license") or its number to the document type.
+// Empty name is the default type.
+func ParseType(name string) (proto.Type, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return proto.Type_DEFAULT_DOCUMENT, nil
+	}
+
+	if n, err := strconv.ParseInt(name, 10, 32); err == nil {
+		if _, ok := proto.Type_name[int32(n)]; ok {
+			return proto.Type(n), nil
+		}
+
+		return 0, fmt.Errorf("unknown document type '%s'", name)
+	}
+
+	key := strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_").Replace(name))
+	if v, ok := proto.Type_value[key]; ok {
+		return proto.Type(v), nil
+	}
+
+	return 0, fmt.Errorf("unknown document type '%s'", name)
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid expiration date '%s', expected YYYY-MM-DD", value)
+}
+
+// Get the value of the JSON string or number as the string.
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+
+	s = strings.TrimSpace(string(raw))
+	if s == "null" {
+		return ""
+	}
+
+	return s
+}
+
+func normalizeColumn(name string) string {
+	name = strings.TrimSpace(name)
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name))
+}
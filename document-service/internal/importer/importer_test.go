@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     proto.ImportFormat
+		content    string
+		wantErr    bool
+		wantRows   int
+		wantErrors []int // Numbers of the rows with errors
+	}{
+		{
+			name:     "should parse csv",
+			format:   proto.ImportFormat_CSV,
+			content:  "Title,Type,Description,Expires at,Renewal months\nPassport,passport,,2030-01-01,\n\"Car, insurance\",Vehicle insurance,Policy,2025-05-01T00:00:00Z,12\n",
+			wantRows: 2,
+		},
+		{
+			name:       "should report invalid csv rows",
+			format:     proto.ImportFormat_CSV,
+			content:    "title,type,expiresAt,renewalMonths\nPassport,spaceship,2030-01-01,\nVisa,visa,01.01.2030,\nCard,16,2030-01-01,month\nCoupon,coupon,2030-01-01,\n",
+			wantRows:   4,
+			wantErrors: []int{1, 2, 3},
+		},
+		{
+			name:    "should fail if csv has no title column",
+			format:  proto.ImportFormat_CSV,
+			content: "name,expiresAt\nPassport,2030-01-01\n",
+			wantErr: true,
+		},
+		{
+			name:     "should parse json",
+			format:   proto.ImportFormat_JSON,
+			content:  `[{"title":"Passport","type":"PASSPORT","expiresAt":"2030-01-01"},{"title":"Card","type":16,"expiresAt":"2030-01-01","renewalMonths":36}]`,
+			wantRows: 2,
+		},
+		{
+			name:       "should report invalid json rows",
+			format:     proto.ImportFormat_JSON,
+			content:    `[{"title":"Passport","type":"passport","expiresAt":"2030-01-01"},{"title":1},"row"]`,
+			wantRows:   3,
+			wantErrors: []int{2, 3},
+		},
+		{
+			name:    "should fail if json is not an array",
+			format:  proto.ImportFormat_JSON,
+			content: `{"title":"Passport"}`,
+			wantErr: true,
+		},
+		{
+			name:    "should fail if file is empty",
+			format:  proto.ImportFormat_CSV,
+			content: " \n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Parse(tt.format, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("Parse() rows = %d, want %d", len(rows), tt.wantRows)
+				return
+			}
+
+			var gotErrors []int
+			for _, row := range rows {
+				if row.Err != nil {
+					gotErrors = append(gotErrors, row.Number)
+				}
+			}
+
+			if len(gotErrors) != len(tt.wantErrors) {
+				t.Errorf("Parse() rows with errors = %v, want %v", gotErrors, tt.wantErrors)
+				return
+			}
+
+			for i := range gotErrors {
+				if gotErrors[i] != tt.wantErrors[i] {
+					t.Errorf("Parse() rows with errors = %v, want %v", gotErrors, tt.wantErrors)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Fields(t *testing.T) {
+	content := "Title,Type,Description,Expires at,Renewal months\n\"Car, insurance\",Vehicle insurance, Policy ,2025-05-01,12\n"
+
+	rows, err := Parse(proto.ImportFormat_CSV, []byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	d := rows[0].Document
+	if d.Title != "Car, insurance" || d.Description != "Policy" {
+		t.Errorf("Parse() title = %q, description = %q", d.Title, d.Description)
+	}
+
+	if *d.Type != proto.Type_VEHICLE_INSURANCE {
+		t.Errorf("Parse() type = %v, want %v", *d.Type, proto.Type_VEHICLE_INSURANCE)
+	}
+
+	if !d.ExpiresAt.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Parse() expiresAt = %v", d.ExpiresAt)
+	}
+
+	if *d.RenewalMonths != 12 {
+		t.Errorf("Parse() renewalMonths = %d, want 12", *d.RenewalMonths)
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    proto.Type
+		wantErr bool
+	}{
+		{name: "", want: proto.Type_DEFAULT_DOCUMENT},
+		{name: "PASSPORT", want: proto.Type_PASSPORT},
+		{name: "driver license", want: proto.Type_DRIVER_LICENSE},
+		{name: "Work-permit", want: proto.Type_WORK_PERMIT},
+		{name: "255", want: proto.Type_OTHER},
+		{name: "100", wantErr: true},
+		{name: "spaceship", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseType(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// Per-user data keys used to encrypt attachments. Should be set on the service start.
+var DataKeys datakey.DataKeyRepository
+
+type AttachmentDB struct {
+	Conn *gorm.DB
+}
+
+func NewAttachmentDB(db *gorm.DB) *AttachmentDB {
+	return &AttachmentDB{
+		Conn: db.Table("attachments"),
+	}
+}
+
+const MaxFileNameLength = 255
+
+// Attachment is a file (e.g. scan or photo) of the document.
+// File content is encrypted and kept in the storage by StorageKey, only metadata is stored in the database.
+type Attachment struct {
+	ID         uuid.UUID      `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID     uuid.UUID      `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	DocumentID uuid.UUID      `gorm:"type:uuid;index;not null;" json:"document_id,omitempty"`
+	FileName   string         `gorm:"not null;" json:"file_name,omitempty"` // Encrypted with the owner's data key
+	MimeType   string         `gorm:"not null;" json:"mime_type,omitempty"`
+	Size       int64          `gorm:"not null;" json:"size,omitempty"` // Size of the not encrypted content in bytes
+	CreatedAt  time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt  time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Validate Attachment object before inserting into database.
+func (a *Attachment) Validate() error {
+	if a.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if a.DocumentID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "document_id is required")
+	}
+
+	if a.FileName == "" || len([]rune(a.FileName)) > MaxFileNameLength {
+		return status.Error(codes.InvalidArgument, "file_name length must be between 1 and 255 characters")
+	}
+
+	if a.MimeType == "" {
+		return status.Error(codes.InvalidArgument, "mime_type is required")
+	}
+
+	return nil
+}
+
+// Key of the attachment content in the storage.
+// All user's or document's attachments can be deleted by the key prefix.
+func (a *Attachment) StorageKey() string {
+	return DocumentStorageKey(a.UserID, a.DocumentID) + a.ID.String()
+}
+
+// Key prefix of all document's attachments in the storage.
+func DocumentStorageKey(userID, documentID uuid.UUID) string {
+	return UserStorageKey(userID) + documentID.String() + "/"
+}
+
+// Key prefix of all user's attachments in the storage.
+func UserStorageKey(userID uuid.UUID) string {
+	return userID.String() + "/"
+}
+
+// Encrypt attachment content with the owner's data key. Content is bound to the attachment ID.
+func (a *Attachment) EncryptContent(ctx context.Context, content []byte) ([]byte, error) {
+	key, err := DataKeys.GetOrCreate(ctx, a.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encryption.SealGCM(key, content, a.ID[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return encrypted, nil
+}
+
+// Decrypt attachment content with the owner's data key.
+func (a *Attachment) DecryptContent(ctx context.Context, encrypted []byte) ([]byte, error) {
+	key, err := DataKeys.Get(ctx, a.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := encryption.OpenGCM(key, encrypted, a.ID[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return content, nil
+}
+
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	// ID can be set before to store the content with it
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+
+	err := a.Validate()
+	if err != nil {
+		return err
+	}
+
+	key, err := DataKeys.GetOrCreate(datakey.HookContext(tx), a.UserID)
+	if err != nil {
+		return err
+	}
+
+	a.FileName, err = encryption.EncryptGCM(key, a.FileName, a.ID[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
+
+func (a *Attachment) AfterFind(tx *gorm.DB) error {
+	if !encryption.IsGCM(a.FileName) {
+		return nil
+	}
+
+	key, err := DataKeys.Get(datakey.HookContext(tx), a.UserID)
+	if err != nil {
+		return err
+	}
+
+	a.FileName, err = encryption.DecryptGCM(key, a.FileName, a.ID[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
+
+// Insert one Attachment object into database.
+func (db *AttachmentDB) InsertOne(ctx context.Context, a *Attachment) error {
+	res := db.Conn.WithContext(ctx).Create(&a)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrInvalidData) ||
+			errors.Is(res.Error, gorm.ErrInvalidValue) ||
+			errors.Is(res.Error, gorm.ErrInvalidValueOfLength) {
+			return status.Error(codes.InvalidArgument, "invalid attachment data")
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Permanently delete one attachment. Attachments are only soft deleted
+// together with their document, see DocumentDB.DeleteOne.
+func (db *AttachmentDB) DeleteOne(ctx context.Context, a *Attachment) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Unscoped().
+		Where(&Attachment{ID: a.ID, DocumentID: a.DocumentID, UserID: a.UserID}).
+		Delete(&Attachment{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "attachment not found")
+	}
+
+	return nil
+}
+
+// Find one attachment of the user's document.
+func (db *AttachmentDB) FindOne(ctx context.Context, a *Attachment) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Attachment{}).
+		Where(&Attachment{ID: a.ID, DocumentID: a.DocumentID, UserID: a.UserID}).
+		First(&a)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "attachment not found")
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Find all attachments of the document, oldest first.
+func (db *AttachmentDB) FindAll(ctx context.Context, documentID uuid.UUID) ([]Attachment, error) {
+	var attachments = []Attachment{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Attachment{}).
+		Where(&Attachment{DocumentID: documentID}).
+		Order("created_at ASC").
+		Find(&attachments)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return attachments, nil
+}
+
+// Find attachments of all user's documents, including the deleted ones.
+func (db *AttachmentDB) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Attachment, error) {
+	var attachments = []Attachment{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Attachment{}).
+		Where(&Attachment{UserID: userID}).
+		Order("created_at ASC").
+		Find(&attachments)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return attachments, nil
+}
+
+// Count attachments of the document.
+func (db *AttachmentDB) Count(ctx context.Context, documentID uuid.UUID) (int64, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Attachment{}).
+		Where(&Attachment{DocumentID: documentID}).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
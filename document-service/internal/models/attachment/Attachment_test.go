@@ -0,0 +1,93 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	datakeymocks "github.com/samgozman/validity.red/document/mocks/models/datakey"
+)
+
+func TestAttachment_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		attachment Attachment
+		wantErr    bool
+	}{
+		{
+			name:       "user_id null check",
+			attachment: Attachment{DocumentID: uuid.New(), FileName: "scan.png", MimeType: "image/png"},
+			wantErr:    true,
+		},
+		{
+			name:       "document_id null check",
+			attachment: Attachment{UserID: uuid.New(), FileName: "scan.png", MimeType: "image/png"},
+			wantErr:    true,
+		},
+		{
+			name:       "file_name empty check",
+			attachment: Attachment{UserID: uuid.New(), DocumentID: uuid.New(), MimeType: "image/png"},
+			wantErr:    true,
+		},
+		{
+			name:       "should pass",
+			attachment: Attachment{UserID: uuid.New(), DocumentID: uuid.New(), FileName: "scan.png", MimeType: "image/png"},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.attachment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Attachment.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttachment_DecryptContent(t *testing.T) {
+	DataKeys = datakeymocks.NewDataKeyDBTest()
+	ctx := context.Background()
+	content := []byte("scan content")
+
+	a := Attachment{ID: uuid.New(), UserID: uuid.New(), DocumentID: uuid.New()}
+
+	encrypted, err := a.EncryptContent(ctx, content)
+	if err != nil {
+		t.Fatalf("Attachment.EncryptContent() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		attachment Attachment
+		wantErr    bool
+	}{
+		{
+			name:       "should decrypt content",
+			attachment: a,
+			wantErr:    false,
+		},
+		{
+			name:       "should fail if content belongs to another attachment",
+			attachment: Attachment{ID: uuid.New(), UserID: a.UserID, DocumentID: a.DocumentID},
+			wantErr:    true,
+		},
+		{
+			name:       "should fail if data key belongs to another user",
+			attachment: Attachment{ID: a.ID, UserID: uuid.New(), DocumentID: a.DocumentID},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.attachment.DecryptContent(ctx, encrypted)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Attachment.DecryptContent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !bytes.Equal(got, content) {
+				t.Errorf("Attachment.DecryptContent() = %s, want %s", got, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package attachment
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type AttachmentRepository interface {
+	InsertOne(ctx context.Context, a *Attachment) error
+	DeleteOne(ctx context.Context, a *Attachment) error
+	FindOne(ctx context.Context, a *Attachment) error
+	FindAll(ctx context.Context, documentID uuid.UUID) ([]Attachment, error)
+	FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Attachment, error)
+	Count(ctx context.Context, documentID uuid.UUID) (int64, error)
+}
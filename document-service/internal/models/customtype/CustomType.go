@@ -0,0 +1,216 @@
+// Package customtype contains the document types defined by the user in addition to the built-in ones.
+package customtype
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/internal/models/encryptedname"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type CustomTypeDB struct {
+	Conn *gorm.DB
+}
+
+func NewCustomTypeDB(db *gorm.DB) *CustomTypeDB {
+	return &CustomTypeDB{
+		Conn: db.Table("custom_types"),
+	}
+}
+
+const MaxNameLength = 50
+
+type CustomType struct {
+	ID        uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	Name      string    `gorm:"not null;" json:"name,omitempty"` // Encrypted with the owner's data key
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (CustomType) TableName() string {
+	return "custom_types"
+}
+
+// Validate CustomType object before inserting into database.
+func (c *CustomType) Validate() error {
+	return encryptedname.Validate(c.UserID, c.Name, MaxNameLength)
+}
+
+// Encrypt the name with the owner's data key, it is bound to the custom type ID.
+func (c *CustomType) Encrypt(ctx context.Context) error {
+	name, err := encryptedname.Encrypt(ctx, c.UserID, c.ID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	c.Name = name
+
+	return nil
+}
+
+// Decrypt the name with the owner's data key.
+func (c *CustomType) Decrypt(ctx context.Context) error {
+	name, err := encryptedname.Decrypt(ctx, c.UserID, c.ID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	c.Name = name
+
+	return nil
+}
+
+func (c *CustomType) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+
+	err := c.Validate()
+	if err != nil {
+		return err
+	}
+
+	return c.Encrypt(datakey.HookContext(tx))
+}
+
+func (c *CustomType) BeforeUpdate(tx *gorm.DB) error {
+	err := c.Validate()
+	if err != nil {
+		return err
+	}
+
+	return c.Encrypt(datakey.HookContext(tx))
+}
+
+func (c *CustomType) AfterFind(tx *gorm.DB) error {
+	return c.Decrypt(datakey.HookContext(tx))
+}
+
+// Insert one CustomType object into database.
+func (db *CustomTypeDB) InsertOne(ctx context.Context, c *CustomType) error {
+	res := db.Conn.WithContext(ctx).Create(&c)
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Rename the user's custom type.
+func (db *CustomTypeDB) UpdateOne(ctx context.Context, c *CustomType) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&CustomType{ID: c.ID, UserID: c.UserID}).
+		Updates(&CustomType{
+			ID:     c.ID,     // Used by BeforeUpdate hook to encrypt the name
+			UserID: c.UserID, // Used by BeforeUpdate hook to get the data key
+			Name:   c.Name,
+		})
+
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "custom type not found")
+	}
+
+	return nil
+}
+
+// Delete the user's custom type, its documents are kept with the OTHER type by the foreign key.
+func (db *CustomTypeDB) DeleteOne(ctx context.Context, c *CustomType) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&CustomType{ID: c.ID, UserID: c.UserID}).
+		Delete(&CustomType{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "custom type not found")
+	}
+
+	return nil
+}
+
+// Find all custom types of the user, oldest first.
+func (db *CustomTypeDB) FindAll(ctx context.Context, userID uuid.UUID) ([]CustomType, error) {
+	var customTypes = []CustomType{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&CustomType{}).
+		Where(&CustomType{UserID: userID}).
+		Order("created_at ASC").
+		Find(&customTypes)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return customTypes, nil
+}
+
+// Checks if the custom type of the user exists.
+func (db *CustomTypeDB) Exists(ctx context.Context, c *CustomType) (bool, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&CustomType{}).
+		Where(&CustomType{ID: c.ID, UserID: c.UserID}).
+		Count(&count)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return false, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count > 0, nil
+}
+
+// Count custom types of the user.
+func (db *CustomTypeDB) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&CustomType{}).
+		Where(&CustomType{UserID: userID}).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
@@ -0,0 +1,20 @@
+package customtype
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCustomType_Validate(t *testing.T) {
+	userID := uuid.New()
+
+	if err := (&CustomType{UserID: userID, Name: strings.Repeat("a", MaxNameLength)}).Validate(); err != nil {
+		t.Errorf("CustomType.Validate() error = %v, want name of %d characters to pass", err, MaxNameLength)
+	}
+
+	if err := (&CustomType{UserID: userID, Name: strings.Repeat("a", MaxNameLength+1)}).Validate(); err == nil {
+		t.Errorf("CustomType.Validate() want error for name longer than %d characters", MaxNameLength)
+	}
+}
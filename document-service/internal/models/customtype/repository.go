@@ -0,0 +1,16 @@
+package customtype
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type CustomTypeRepository interface {
+	InsertOne(ctx context.Context, c *CustomType) error
+	UpdateOne(ctx context.Context, c *CustomType) error
+	DeleteOne(ctx context.Context, c *CustomType) error
+	FindAll(ctx context.Context, userID uuid.UUID) ([]CustomType, error)
+	Exists(ctx context.Context, c *CustomType) (bool, error)
+	Count(ctx context.Context, userID uuid.UUID) (int64, error)
+}
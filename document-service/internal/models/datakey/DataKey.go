@@ -0,0 +1,261 @@
+// Package datakey is used to manage per-user data keys (envelope encryption).
+//
+// Every user has a random data key which encrypts the user's data. Data keys are stored
+// wrapped by the KMS master key, so destroying the data key makes all user's data unreadable
+// (crypto-shredding), even in the database backups.
+//
+// Destroyed keys are replaced by a tombstone, so the key is never created again for the deleted user.
+// Unwrapped keys are cached in memory for CacheTTL, so other replicas forget the destroyed key
+// at most CacheTTL after it was destroyed.
+package datakey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/pkg/kms"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Size of the data key in bytes (AES-256).
+const KeySize = 32
+
+// How long the unwrapped data key is kept in the memory cache.
+const CacheTTL = time.Minute
+
+type DataKey struct {
+	UserID      uuid.UUID  `gorm:"primarykey;type:uuid;not null;" json:"user_id,omitempty"`
+	WrappedKey  []byte     `gorm:"not null;" json:"-"`
+	MasterKeyID string     `gorm:"index;not null;" json:"master_key_id,omitempty"` // ID of the master key used for wrapping
+	DestroyedAt *time.Time `gorm:"index" json:"destroyed_at,omitempty"`            // Set for the tombstone of the destroyed key
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+type DataKeyDB struct {
+	Conn *gorm.DB
+	KMS  kms.KMS
+	// Unwrapped data keys by user ID, so KMS is not called for every document
+	cache sync.Map
+}
+
+// Unwrapped data key in the memory cache.
+type cachedKey struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+func NewDataKeyDB(db *gorm.DB, k kms.KMS) *DataKeyDB {
+	return &DataKeyDB{
+		Conn: db.Table("data_keys"),
+		KMS:  k,
+	}
+}
+
+// Generate and save new data key for the user. Existing key or its tombstone is not replaced.
+func (db *DataKeyDB) Create(ctx context.Context, userID uuid.UUID) error {
+	key := make([]byte, KeySize)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		sentry.CaptureException(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	wrapped, keyID, err := db.KMS.Wrap(ctx, key, userID[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&DataKey{UserID: userID, WrappedKey: wrapped, MasterKeyID: keyID})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Get unwrapped user's data key. Returns NotFound if the user has no key or it was destroyed.
+func (db *DataKeyDB) Get(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	if cached, ok := db.cache.Load(userID); ok {
+		if c := cached.(cachedKey); time.Now().Before(c.expiresAt) {
+			return c.key, nil
+		}
+
+		db.cache.Delete(userID)
+	}
+
+	var dk DataKey
+
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&DataKey{UserID: userID}).
+		First(&dk)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "data key not found")
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if dk.DestroyedAt != nil {
+		return nil, status.Error(codes.NotFound, "data key not found")
+	}
+
+	key, err := db.KMS.Unwrap(ctx, dk.WrappedKey, dk.MasterKeyID, userID[:])
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error unwrapping data key of user '%s': %w", userID, err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	db.cache.Store(userID, cachedKey{key: key, expiresAt: time.Now().Add(CacheTTL)})
+
+	return key, nil
+}
+
+// Get user's data key and create it if user has none (e.g. registered before data keys).
+// Should be used only on the write paths, the destroyed key is not created again.
+func (db *DataKeyDB) GetOrCreate(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	key, err := db.Get(ctx, userID)
+	if status.Code(err) != codes.NotFound {
+		return key, err
+	}
+
+	err = db.Create(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Get(ctx, userID)
+}
+
+// Permanently delete user's data key and leave its tombstone.
+// All data encrypted with it can't be decrypted anymore.
+func (db *DataKeyDB) Destroy(ctx context.Context, userID uuid.UUID) error {
+	db.cache.Delete(userID)
+
+	now := time.Now()
+
+	res := db.Conn.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"wrapped_key":   []byte{},
+				"master_key_id": "",
+				"destroyed_at":  now,
+				"updated_at":    now,
+			}),
+		}).
+		Create(&DataKey{UserID: userID, WrappedKey: []byte{}, DestroyedAt: &now})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Re-wrap all data keys wrapped by the old master keys with the current one.
+// Keys are loaded in batches of the given size. Returns the number of re-wrapped keys.
+//
+// Keys that failed to be re-wrapped are skipped and will be retried on the next run.
+func (db *DataKeyDB) Rewrap(ctx context.Context, limit int) (int, error) {
+	currentID := db.KMS.CurrentKeyID()
+	afterID := uuid.Nil
+
+	var rewrapped int
+
+	for {
+		var keys []DataKey
+
+		res := db.Conn.
+			WithContext(ctx).
+			Where("master_key_id <> ? AND destroyed_at IS NULL AND user_id > ?", currentID, afterID).
+			Order("user_id ASC").
+			Limit(limit).
+			Find(&keys)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return rewrapped, status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if len(keys) == 0 {
+			return rewrapped, nil
+		}
+
+		for _, dk := range keys {
+			afterID = dk.UserID
+
+			key, err := db.KMS.Unwrap(ctx, dk.WrappedKey, dk.MasterKeyID, dk.UserID[:])
+			if err != nil {
+				sentry.CaptureException(fmt.Errorf("error unwrapping data key of user '%s': %w", dk.UserID, err))
+				continue
+			}
+
+			wrapped, keyID, err := db.KMS.Wrap(ctx, key, dk.UserID[:])
+			if err != nil {
+				sentry.CaptureException(fmt.Errorf("error wrapping data key of user '%s': %w", dk.UserID, err))
+				continue
+			}
+
+			res := db.Conn.
+				WithContext(ctx).
+				Model(&DataKey{}).
+				Where("user_id = ? AND master_key_id = ?", dk.UserID, dk.MasterKeyID).
+				UpdateColumns(map[string]interface{}{
+					"wrapped_key":   wrapped,
+					"master_key_id": keyID,
+					"updated_at":    time.Now(),
+				})
+
+			if res.Error != nil {
+				sentry.CaptureException(res.Error)
+				continue
+			}
+
+			rewrapped++
+		}
+	}
+}
+
+// Derive a separate key from the data key for the given purpose (HMAC-SHA256),
+// so the data key itself is never shared with other services.
+func DeriveKey(dataKey []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+// Get context of the query which called the gorm hook, so the data key is fetched with it.
+func HookContext(tx *gorm.DB) context.Context {
+	if tx == nil || tx.Statement == nil || tx.Statement.Context == nil {
+		return context.Background()
+	}
+
+	return tx.Statement.Context
+}
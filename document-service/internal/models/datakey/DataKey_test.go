@@ -0,0 +1,23 @@
+package datakey
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	dataKey := []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj")
+
+	calendarKey := DeriveKey(dataKey, "calendar")
+	if len(calendarKey) != KeySize {
+		t.Fatalf("DeriveKey() key length = %d, want %d", len(calendarKey), KeySize)
+	}
+
+	if !bytes.Equal(calendarKey, DeriveKey(dataKey, "calendar")) {
+		t.Errorf("DeriveKey() should be deterministic")
+	}
+
+	if bytes.Equal(calendarKey, DeriveKey(dataKey, "other")) || bytes.Equal(calendarKey, dataKey) {
+		t.Errorf("DeriveKey() should return different keys for different purposes")
+	}
+}
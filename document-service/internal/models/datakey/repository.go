@@ -0,0 +1,15 @@
+package datakey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type DataKeyRepository interface {
+	Create(ctx context.Context, userID uuid.UUID) error
+	Get(ctx context.Context, userID uuid.UUID) ([]byte, error)
+	GetOrCreate(ctx context.Context, userID uuid.UUID) ([]byte, error)
+	Destroy(ctx context.Context, userID uuid.UUID) error
+	Rewrap(ctx context.Context, limit int) (int, error)
+}
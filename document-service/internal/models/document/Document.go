@@ -3,13 +3,24 @@ package document
 import (
 	"context"
 	"errors"
-	"os"
+	"fmt"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/customtype"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
 	"github.com/samgozman/validity.red/document/internal/models/notification"
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	"github.com/samgozman/validity.red/document/internal/models/preset"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	"github.com/samgozman/validity.red/document/internal/models/share"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
+	"github.com/samgozman/validity.red/document/pkg/blindindex"
 	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"github.com/samgozman/validity.red/document/pkg/keyring"
 	proto "github.com/samgozman/validity.red/document/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,9 +28,12 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-var (
-	EncryptionKey = []byte(os.Getenv("ENCRYPTION_KEY"))
-)
+// Legacy keys used to decrypt document fields encrypted before per-user data keys.
+// Should be set on the service start.
+var Keys *keyring.KeyRing
+
+// Per-user data keys used to encrypt document fields. Should be set on the service start.
+var DataKeys datakey.DataKeyRepository
 
 type DocumentDB struct {
 	Conn *gorm.DB
@@ -34,6 +48,14 @@ func NewDocumentDB(db *gorm.DB) *DocumentDB {
 const MaxTitleLength = 100
 const MaxDescriptionLength = 500
 
+// Max renewal period of the document in months (10 years).
+const MaxRenewalMonths = 120
+
+var ErrMaxDocumentsLimit = status.Error(codes.Canceled, "max documents limit reached")
+
+// Purpose of the key derived from the user's data key for the search index.
+const searchKeyPurpose = "search"
+
 type Document struct {
 	ID            uuid.UUID                   `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
 	UserID        uuid.UUID                   `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
@@ -42,10 +64,24 @@ type Document struct {
 	Description   string                      `gorm:"" json:"description,omitempty"`
 	IVTitle       []byte                      `gorm:"size:16;" json:"iv_title,omitempty"`
 	IVDescription []byte                      `gorm:"size:16;" json:"iv_description,omitempty"`
+	KeyVersion    int                         `gorm:"index;default:1;not null;" json:"key_version,omitempty"`  // Version of the legacy key used for encryption
+	UserKey       bool                        `gorm:"index;default:false;not null;" json:"user_key,omitempty"` // Encrypted with the owner's data key
+	SearchIndex   string                      `gorm:"type:text;default:'';not null;" json:"-"`                 // Blind index tokens of title and description words
 	ExpiresAt     time.Time                   `gorm:"default:0" json:"expires_at,omitempty"`
+	RenewalMonths *int32                      `gorm:"default:0;not null;" json:"renewal_months,omitempty"` // Period to renew the document for, not renewable if 0
+	PersonID      *uuid.UUID                  `gorm:"type:uuid;index;" json:"person_id,omitempty"`         // Person (dependent) whose document it is, the owner's own if nil
+	Person        *person.Person              `gorm:"foreignKey:PersonID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"person,omitempty"`
+	CustomTypeID  *uuid.UUID                  `gorm:"type:uuid;index;" json:"custom_type_id,omitempty"` // User-defined type, Type is OTHER if it is set
+	CustomType    *customtype.CustomType      `gorm:"foreignKey:CustomTypeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"custom_type,omitempty"`
+	Tags          []tag.DocumentTag           `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"tags,omitempty"`
 	Notifications []notification.Notification `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;references:ID" json:"notifications,omitempty"`
+	Attachments   []attachment.Attachment     `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"attachments,omitempty"`
+	Renewals      []renewal.Renewal           `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"renewals,omitempty"`
+	Rules         []rule.Rule                 `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"rules,omitempty"`
+	Shares        []share.Share               `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"shares,omitempty"`
 	CreatedAt     time.Time                   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
 	UpdatedAt     time.Time                   `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	DeletedAt     gorm.DeletedAt              `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 // Validate Document object before inserting into database.
@@ -70,64 +106,130 @@ func (d *Document) Validate() error {
 		return status.Error(codes.InvalidArgument, "expires_at is required")
 	}
 
+	if d.RenewalMonths != nil && (*d.RenewalMonths < 0 || *d.RenewalMonths > MaxRenewalMonths) {
+		return status.Error(codes.InvalidArgument, "renewal_months must be between 0 and 120")
+	}
+
 	return nil
 }
 
-// Encrypt document title and description.
-func (d *Document) Encrypt() error {
-	ivTitle, err := encryption.GenerateRandomIV(encryption.BlockSize)
+// Get the expiration date of the next validity period.
+// The day is kept within the month, e.g. monthly period from January 31 ends on the last day of February.
+// Returns false if the document has no renewal period.
+func (d *Document) NextExpiresAt() (time.Time, bool) {
+	if d.RenewalMonths == nil || *d.RenewalMonths <= 0 {
+		return time.Time{}, false
+	}
+
+	year, month, day := d.ExpiresAt.Date()
+	hour, minute, sec := d.ExpiresAt.Clock()
+	loc := d.ExpiresAt.Location()
+
+	// Day 0 of the next month is the last day of the target month
+	lastDay := time.Date(year, month+time.Month(*d.RenewalMonths)+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+time.Month(*d.RenewalMonths), day, hour, minute, sec, d.ExpiresAt.Nanosecond(), loc), true
+}
+
+// Encrypt document title and description with the owner's data key and update the search index.
+//
+// Fields are encrypted with AES-GCM and bound to the document ID, so ID and UserID should be set before.
+func (d *Document) Encrypt(ctx context.Context) error {
+	key, err := DataKeys.GetOrCreate(ctx, d.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Index is built from the plain text, so the encrypted fields can be searched
+	d.SearchIndex = blindindex.Index(datakey.DeriveKey(key, searchKeyPurpose), d.Title, d.Description)
+
+	encryptedTitle, err := encryption.EncryptGCM(key, d.Title, d.ID[:])
 	if err != nil {
+		sentry.CaptureException(err)
 		return status.Error(codes.Internal, err.Error())
 	}
-	// TODO: Do not encrypt description if it is empty
-	ivDescription, err := encryption.GenerateRandomIV(encryption.BlockSize)
+
+	encryptedDesc, err := encryption.EncryptGCM(key, d.Description, d.ID[:])
 	if err != nil {
 		sentry.CaptureException(err)
 		return status.Error(codes.Internal, err.Error())
 	}
 
-	encryptedTitle, err := encryption.EncryptAES(EncryptionKey, ivTitle, d.Title)
+	d.Title = encryptedTitle
+	d.Description = encryptedDesc
+	// IVs are used only by legacy AES-CBC, GCM nonce is stored in the cipher text
+	d.IVTitle = nil
+	d.IVDescription = nil
+	d.UserKey = true
+
+	return nil
+}
+
+// Decrypt document title and description with the key they were encrypted with:
+// the owner's data key or the legacy key of the stored version.
+// Both AES-GCM and legacy AES-CBC encrypted fields are supported.
+func (d *Document) Decrypt(ctx context.Context) error {
+	if !d.IsEncrypted() {
+		return nil
+	}
+
+	key, err := d.decryptionKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	title, err := d.decryptField(key, d.IVTitle, d.Title)
 	if err != nil {
 		sentry.CaptureException(err)
 		return status.Error(codes.Internal, err.Error())
 	}
 
-	encryptedDesc, err := encryption.EncryptAES(EncryptionKey, ivDescription, d.Description)
+	desc, err := d.decryptField(key, d.IVDescription, d.Description)
 	if err != nil {
 		sentry.CaptureException(err)
 		return status.Error(codes.Internal, err.Error())
 	}
 
-	d.Title = string(encryptedTitle)
-	d.Description = string(encryptedDesc)
-	d.IVTitle = ivTitle
-	d.IVDescription = ivDescription
+	d.Title = title
+	d.Description = desc
 
 	return nil
 }
 
-func (d *Document) Decrypt() error {
-	if d.IVTitle != nil {
-		title, err := encryption.DecryptAES(EncryptionKey, d.IVTitle, d.Title)
-		if err != nil {
-			sentry.CaptureException(err)
-			return status.Error(codes.Internal, err.Error())
-		}
+// Get the key used to encrypt the document.
+func (d *Document) decryptionKey(ctx context.Context) ([]byte, error) {
+	if d.UserKey {
+		return DataKeys.Get(ctx, d.UserID)
+	}
 
-		d.Title = string(title)
+	key, err := Keys.Key(d.KeyVersion)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if d.IVDescription != nil {
-		desc, err := encryption.DecryptAES(EncryptionKey, d.IVDescription, d.Description)
-		if err != nil {
-			sentry.CaptureException(err)
-			return status.Error(codes.Internal, err.Error())
-		}
+	return key, nil
+}
 
-		d.Description = string(desc)
-	}
+// Check if any of the document fields is encrypted.
+func (d *Document) IsEncrypted() bool {
+	return d.IVTitle != nil || d.IVDescription != nil ||
+		encryption.IsGCM(d.Title) || encryption.IsGCM(d.Description)
+}
 
-	return nil
+// Decrypt one field by its cipher text format.
+func (d *Document) decryptField(key []byte, iv []byte, value string) (string, error) {
+	switch {
+	case encryption.IsGCM(value):
+		return encryption.DecryptGCM(key, value, d.ID[:])
+	case iv != nil:
+		return encryption.DecryptAES(key, iv, value)
+	default:
+		return value, nil
+	}
 }
 
 func (d *Document) BeforeCreate(tx *gorm.DB) error {
@@ -139,7 +241,7 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 		return status.Error(codes.Internal, err.Error())
 	}
 
-	err = d.Encrypt()
+	err = d.Encrypt(datakey.HookContext(tx))
 	if err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
@@ -149,7 +251,7 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 
 func (d *Document) BeforeUpdate(tx *gorm.DB) error {
 	// TODO: Add validation for update event
-	err := d.Encrypt()
+	err := d.Encrypt(datakey.HookContext(tx))
 	if err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
@@ -158,7 +260,7 @@ func (d *Document) BeforeUpdate(tx *gorm.DB) error {
 }
 
 func (d *Document) AfterFind(tx *gorm.DB) error {
-	err := d.Decrypt()
+	err := d.Decrypt(datakey.HookContext(tx))
 	if err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
@@ -184,54 +286,540 @@ func (db *DocumentDB) InsertOne(ctx context.Context, d *Document) error {
 	return nil
 }
 
-func (db *DocumentDB) UpdateOne(ctx context.Context, d *Document) error {
-	res := db.Conn.
-		WithContext(ctx).
-		Where(&Document{ID: d.ID, UserID: d.UserID}).
-		Updates(&Document{
-			Type:        d.Type,
-			Title:       d.Title,
-			Description: d.Description,
-			ExpiresAt:   d.ExpiresAt,
-		})
+// Insert documents of one user into database in one transaction, none of them is inserted on error.
+// ErrMaxDocumentsLimit is returned if the user would have more than limit documents.
+func (db *DocumentDB) InsertMany(ctx context.Context, documents []Document, limit int64) error {
+	if len(documents) == 0 {
+		return nil
+	}
 
-	if res.Error != nil {
-		if errors.Is(res.Error, gorm.ErrInvalidData) ||
-			errors.Is(res.Error, gorm.ErrInvalidValue) ||
-			errors.Is(res.Error, gorm.ErrInvalidValueOfLength) {
-			return status.Error(codes.InvalidArgument, "invalid document data")
+	userID := documents[0].UserID
+
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Inserts of the same user wait for each other until the commit,
+		// so the concurrent imports can't exceed the limit together
+		res := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "documents:"+userID.String())
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
 		}
 
-		sentry.CaptureException(res.Error)
+		var count int64
+
+		res = tx.Model(&Document{}).Where(&Document{UserID: userID}).Count(&count)
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if count+int64(len(documents)) > limit {
+			return ErrMaxDocumentsLimit
+		}
 
+		res = tx.Table("documents").Create(&documents)
+		if res.Error != nil {
+			if errors.Is(res.Error, gorm.ErrInvalidData) ||
+				errors.Is(res.Error, gorm.ErrInvalidValue) ||
+				errors.Is(res.Error, gorm.ErrInvalidValueOfLength) {
+				return status.Error(codes.InvalidArgument, "invalid document data")
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// UpdateOptions select the optional fields changed by UpdateOne, the fields not selected are kept.
+type UpdateOptions struct {
+	Person        bool
+	CustomType    bool
+	Tags          bool
+	RenewalMonths bool
+}
+
+// Update document fields, notifications of the reminder rules are moved with the expiration date.
+func (db *DocumentDB) UpdateOne(ctx context.Context, d *Document, opts UpdateOptions) error {
+	// Updates skips nil fields, so the renewal period is kept if it is not selected
+	var renewalMonths *int32
+	if opts.RenewalMonths {
+		renewalMonths = d.RenewalMonths
+	}
+
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("documents").
+			Where(&Document{ID: d.ID, UserID: d.UserID}).
+			Updates(&Document{
+				ID:            d.ID,     // Used by BeforeUpdate hook to encrypt fields
+				UserID:        d.UserID, // Used by BeforeUpdate hook to get the data key
+				Type:          d.Type,
+				Title:         d.Title,
+				Description:   d.Description,
+				ExpiresAt:     d.ExpiresAt,
+				RenewalMonths: renewalMonths,
+			})
+
+		if res.Error != nil {
+			if errors.Is(res.Error, gorm.ErrInvalidData) ||
+				errors.Is(res.Error, gorm.ErrInvalidValue) ||
+				errors.Is(res.Error, gorm.ErrInvalidValueOfLength) {
+				return status.Error(codes.InvalidArgument, "invalid document data")
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "document not found")
+		}
+
+		// Updates skips nil fields, so the person and custom type are set separately to be able to remove them
+		columns := map[string]interface{}{}
+
+		if opts.Person {
+			columns["person_id"] = d.PersonID
+		}
+
+		// Custom type is only kept with the OTHER type, built-in type replaces it
+		if opts.CustomType || (d.Type != nil && *d.Type != proto.Type_OTHER) {
+			columns["custom_type_id"] = d.CustomTypeID
+		}
+
+		if len(columns) > 0 {
+			res = tx.
+				Table("documents").
+				Where(&Document{ID: d.ID, UserID: d.UserID}).
+				UpdateColumns(columns)
+
+			if res.Error != nil {
+				sentry.CaptureException(res.Error)
+				return status.Error(codes.Internal, res.Error.Error())
+			}
+		}
+
+		if opts.Tags {
+			err := replaceTags(tx, d.ID, d.Tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		if d.ExpiresAt.IsZero() {
+			return nil
+		}
+
+		// Notifications of the reminder rules follow the expiration date
+		return rule.Recompute(tx, d.ID, d.ExpiresAt)
+	})
+}
+
+// Replace tags of the document with the given ones.
+func replaceTags(tx *gorm.DB, documentID uuid.UUID, tags []tag.DocumentTag) error {
+	res := tx.
+		Table("document_tags").
+		Where(&tag.DocumentTag{DocumentID: documentID}).
+		Delete(&tag.DocumentTag{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
 		return status.Error(codes.Internal, res.Error.Error())
 	}
 
-	if res.RowsAffected == 0 {
-		return status.Error(codes.NotFound, "document not found")
+	if len(tags) == 0 {
+		return nil
+	}
+
+	for i := range tags {
+		tags[i].DocumentID = documentID
+	}
+
+	res = tx.Table("document_tags").Create(&tags)
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
 	}
 
 	return nil
 }
 
-// TODO: Implement "soft delete" feature
-// TODO: Allow users to restore a document after deletion
-// TODO: Delete documents with DeletedAt timestamp > 14d with CRON job
+// Move document with its notifications and attachments to the trash.
+// Deleted documents can be restored with Restore until they are purged.
 // @see: https://gorm.io/docs/delete.html#Soft-Delete.
 func (db *DocumentDB) DeleteOne(ctx context.Context, d *Document) error {
-	res := db.Conn.
-		WithContext(ctx).
-		// Delete all associations also
-		Select(clause.Associations).
-		Delete(&Document{ID: d.ID, UserID: d.UserID})
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("documents").
+			Where(&Document{ID: d.ID, UserID: d.UserID}).
+			Delete(&Document{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "document not found")
+		}
+
+		res = tx.
+			Table("notifications").
+			Where(&notification.Notification{DocumentID: d.ID}).
+			Delete(&notification.Notification{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("attachments").
+			Where(&attachment.Attachment{DocumentID: d.ID}).
+			Delete(&attachment.Attachment{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Restore document with its notifications and attachments from the trash.
+// Notifications which date has passed in the meantime are marked as delivered.
+func (db *DocumentDB) Restore(ctx context.Context, d *Document) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("documents").
+			Unscoped().
+			Model(&Document{}).
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", d.ID, d.UserID).
+			UpdateColumn("deleted_at", nil)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "document not found")
+		}
+
+		// Notifications which date passed while the document was in the trash are restored as delivered,
+		// so they are not sent late right after the restore
+		now := time.Now()
+
+		res = tx.
+			Table("notifications").
+			Unscoped().
+			Model(&notification.Notification{}).
+			Where("document_id = ? AND deleted_at IS NOT NULL AND delivered_at IS NULL AND date < ?", d.ID, now).
+			UpdateColumn("delivered_at", now)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("notifications").
+			Unscoped().
+			Model(&notification.Notification{}).
+			Where("document_id = ? AND deleted_at IS NOT NULL", d.ID).
+			UpdateColumn("deleted_at", nil)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("attachments").
+			Unscoped().
+			Model(&attachment.Attachment{}).
+			Where("document_id = ? AND deleted_at IS NOT NULL", d.ID).
+			UpdateColumn("deleted_at", nil)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Permanently delete one document from the trash with all its notifications.
+// Attachments metadata is deleted by the foreign key cascade, their content should be deleted from the storage.
+func (db *DocumentDB) PurgeOne(ctx context.Context, d *Document) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("documents").
+			Unscoped().
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", d.ID, d.UserID).
+			Delete(&Document{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "document not found")
+		}
+
+		res = tx.
+			Table("notifications").
+			Unscoped().
+			Where(&notification.Notification{DocumentID: d.ID}).
+			Delete(&notification.Notification{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Permanently delete all documents which were moved to the trash before the given time.
+// Returns IDs and user IDs of the purged documents, so their attachments can be deleted from the storage.
+func (db *DocumentDB) PurgeDeleted(ctx context.Context, before time.Time) ([]Document, error) {
+	var purged []Document
+
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("notifications").
+			Unscoped().
+			Where(
+				"document_id IN (SELECT id FROM documents WHERE deleted_at IS NOT NULL AND deleted_at < ?)",
+				before,
+			).
+			Delete(&notification.Notification{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("documents").
+			Unscoped().
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "user_id"}}}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Delete(&purged)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+
+	return purged, err
+}
+
+// Permanently delete all user's documents, notifications, reminder presets, shares, persons,
+// custom types and tags, including the ones in the trash.
+func (db *DocumentDB) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("notifications").
+			Unscoped().
+			Where(&notification.Notification{UserID: userID}).
+			Delete(&notification.Notification{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("presets").
+			Where(&preset.Preset{UserID: userID}).
+			Delete(&preset.Preset{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("shares").
+			Where(&share.Share{UserID: userID}).
+			Delete(&share.Share{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("documents").
+			Unscoped().
+			Where(&Document{UserID: userID}).
+			Delete(&Document{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("persons").
+			Where(&person.Person{UserID: userID}).
+			Delete(&person.Person{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("custom_types").
+			Where(&customtype.CustomType{UserID: userID}).
+			Delete(&customtype.CustomType{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("tags").
+			Where(&tag.Tag{UserID: userID}).
+			Delete(&tag.Tag{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Renew the document: advance its expiration date to expiresAt (or by the renewal period if it is zero)
+// and shift its notifications by the same amount. Shifted notifications will be delivered again.
+// The replaced validity period is saved to the renewals history.
+func (db *DocumentDB) Renew(ctx context.Context, d *Document, expiresAt time.Time) (*renewal.Renewal, error) {
+	var r *renewal.Renewal
+
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := Document{}
+
+		// Encrypted fields are not selected, so the document is not decrypted by the hook
+		res := tx.
+			Table("documents").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id, user_id, expires_at, renewal_months").
+			Where(&Document{ID: d.ID, UserID: d.UserID}).
+			First(&current)
+
+		if res.Error != nil {
+			if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+				return status.Error(codes.NotFound, "document not found")
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if expiresAt.IsZero() {
+			next, ok := current.NextExpiresAt()
+			if !ok {
+				return status.Error(codes.FailedPrecondition, "document has no renewal period")
+			}
+
+			expiresAt = next
+		}
+
+		r = &renewal.Renewal{
+			UserID:            current.UserID,
+			DocumentID:        current.ID,
+			PreviousExpiresAt: current.ExpiresAt,
+			ExpiresAt:         expiresAt,
+		}
+
+		if err := r.Validate(); err != nil {
+			return err
+		}
+
+		res = tx.Table("renewals").Create(r)
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		// Columns are updated without hooks, because the encrypted fields are not changed
+		res = tx.
+			Table("documents").
+			Where(&Document{ID: current.ID}).
+			UpdateColumns(map[string]interface{}{
+				"expires_at": expiresAt,
+				"updated_at": time.Now(),
+			})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return shiftNotifications(tx, current.ID, expiresAt.Sub(current.ExpiresAt))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Shift dates of the document's notifications by the given duration.
+// Notifications moved to the future are marked as not delivered, so they are sent again.
+func shiftNotifications(tx *gorm.DB, documentID uuid.UUID, shift time.Duration) error {
+	var notifications []notification.Notification
+
+	res := tx.
+		Table("notifications").
+		Where(&notification.Notification{DocumentID: documentID}).
+		Find(&notifications)
 
 	if res.Error != nil {
 		sentry.CaptureException(res.Error)
 		return status.Error(codes.Internal, res.Error.Error())
 	}
 
-	if res.RowsAffected == 0 {
-		return status.Error(codes.NotFound, "document not found")
+	now := time.Now()
+
+	for _, n := range notifications {
+		fields := map[string]interface{}{
+			"date":       n.Date.Add(shift),
+			"updated_at": now,
+		}
+
+		if n.Date.Add(shift).After(now) {
+			notification.ResetDelivery(fields)
+		}
+
+		res = tx.
+			Table("notifications").
+			Where(&notification.Notification{ID: n.ID}).
+			UpdateColumns(fields)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
 	}
 
 	return nil
@@ -242,6 +830,9 @@ func (db *DocumentDB) FindOne(ctx context.Context, d *Document) error {
 	res := db.Conn.
 		WithContext(ctx).
 		Model(&Document{}).
+		Preload("Person").
+		Preload("CustomType").
+		Preload("Tags").
 		Where(&Document{ID: d.ID, UserID: d.UserID}).
 		First(&d)
 
@@ -267,7 +858,7 @@ func (db *DocumentDB) Exists(ctx context.Context, d *Document) (bool, error) {
 	res := db.Conn.
 		WithContext(ctx).
 		Raw(
-			"SELECT EXISTS(SELECT 1 FROM documents WHERE id = ? AND user_id = ?) as found",
+			"SELECT EXISTS(SELECT 1 FROM documents WHERE id = ? AND user_id = ? AND deleted_at IS NULL) as found",
 			d.ID,
 			d.UserID,
 		).
@@ -285,14 +876,203 @@ func (db *DocumentDB) Exists(ctx context.Context, d *Document) (bool, error) {
 	return exist.Found, nil
 }
 
-// Find all documents by UserID.
-func (db *DocumentDB) FindAll(ctx context.Context, userID uuid.UUID) ([]Document, error) {
+// Find page of user's documents sorted and filtered by the options.
+// Returns cursor of the next page or empty string if it is the last page.
+func (db *DocumentDB) FindAll(ctx context.Context, userID uuid.UUID, opts *ListOptions) ([]Document, string, error) {
+	var documents = []Document{}
+
+	column, ok := sortColumns[opts.SortBy]
+	if !ok {
+		return nil, "", status.Error(codes.InvalidArgument, "invalid sort field")
+	}
+
+	order, compare := "ASC", ">"
+	if opts.Descending {
+		order, compare = "DESC", "<"
+	}
+
+	query := db.Conn.
+		WithContext(ctx).
+		Model(&Document{}).
+		Preload("Person").
+		Preload("CustomType").
+		Preload("Tags").
+		Where(&Document{UserID: userID})
+
+	if opts.PersonID != uuid.Nil {
+		query = query.Where("person_id = ?", opts.PersonID)
+	}
+
+	if len(opts.Types) > 0 {
+		types := make([]int32, len(opts.Types))
+		for i, t := range opts.Types {
+			types[i] = int32(t)
+		}
+
+		query = query.Where("type IN ?", types)
+	}
+
+	if len(opts.CustomTypeIDs) > 0 {
+		query = query.Where("custom_type_id IN ?", opts.CustomTypeIDs)
+	}
+
+	if len(opts.TagIDs) > 0 {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM document_tags WHERE document_tags.document_id = documents.id AND document_tags.tag_id IN ?)",
+			opts.TagIDs,
+		)
+	}
+
+	if opts.Expired {
+		query = query.Where("expires_at < ?", opts.Now)
+	}
+
+	if opts.ExpiresWithin > 0 {
+		query = query.Where("expires_at >= ? AND expires_at < ?", opts.Now, opts.Now.Add(opts.ExpiresWithin))
+	}
+
+	if opts.Cursor != nil {
+		// Column name is taken from the allowlist, so it is safe to format it into the query
+		query = query.Where(
+			fmt.Sprintf("(%s, id) %s (?, ?)", column, compare),
+			opts.Cursor.value(opts.SortBy),
+			opts.Cursor.ID,
+		)
+	}
+
+	query = query.Order(column + " " + order).Order("id " + order)
+
+	if opts.Limit > 0 {
+		// Fetch one more document to know if there is a next page
+		query = query.Limit(opts.Limit + 1)
+	}
+
+	res := query.Find(&documents)
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, "", status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if opts.Limit == 0 || len(documents) <= opts.Limit {
+		return documents, "", nil
+	}
+
+	documents = documents[:opts.Limit]
+
+	return documents, NewCursor(&documents[opts.Limit-1]).Encode(), nil
+}
+
+// Find documents of other users shared with any of the groups, sorted by expiration date.
+func (db *DocumentDB) FindShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]Document, error) {
 	var documents = []Document{}
 
+	if len(groupIDs) == 0 {
+		return documents, nil
+	}
+
 	res := db.Conn.
 		WithContext(ctx).
 		Model(&Document{}).
-		Where(&Document{UserID: userID}).
+		Preload("Person").
+		Preload("CustomType").
+		Preload("Tags").
+		Where("documents.user_id <> ?", userID).
+		Where("EXISTS (?)", share.AccessQuery(db.Conn, groupIDs, "documents.user_id", "documents.id")).
+		Order("expires_at ASC").
+		Find(&documents)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return documents, nil
+}
+
+// Number of candidate documents loaded per page of Search, relative to the limit.
+const searchOverFetch = 2
+
+// Find user's documents which title or description contain all words of the query
+// (or words starting with them), sorted by expiration date. Returns up to limit documents.
+func (db *DocumentDB) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]Document, error) {
+	var documents = []Document{}
+
+	key, err := DataKeys.Get(ctx, userID)
+	if status.Code(err) == codes.NotFound {
+		// User has no data key, so there are no documents to search
+		return documents, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := blindindex.QueryTokens(datakey.DeriveKey(key, searchKeyPurpose), query)
+	if len(tokens) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "search query is empty")
+	}
+
+	q := db.Conn.
+		WithContext(ctx).
+		Model(&Document{}).
+		Preload("Person").
+		Preload("CustomType").
+		Preload("Tags").
+		Where(&Document{UserID: userID})
+
+	for _, token := range tokens {
+		q = q.Where("search_index LIKE ?", "% "+token+" %")
+	}
+
+	// Token collisions are filtered out only after decryption, so the candidates are loaded in pages
+	// larger than the limit until enough real matches are found. Collisions are rare, so usually
+	// one page is enough, but a query with many collisions can load all candidate documents.
+	pageSize := limit * searchOverFetch
+
+	for offset := 0; ; offset += pageSize {
+		var page []Document
+
+		res := q.
+			Session(&gorm.Session{}).
+			Order("expires_at ASC, id ASC").
+			Offset(offset).
+			Limit(pageSize).
+			Find(&page)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return nil, status.Error(codes.Internal, res.Error.Error())
+		}
+
+		// Documents are already decrypted by the AfterFind hook
+		for _, d := range page {
+			if !blindindex.Match(query, d.Title, d.Description) {
+				continue
+			}
+
+			documents = append(documents, d)
+
+			if len(documents) == limit {
+				return documents, nil
+			}
+		}
+
+		if len(page) < pageSize {
+			return documents, nil
+		}
+	}
+}
+
+// Find all documents in the user's trash, recently deleted first.
+func (db *DocumentDB) FindDeleted(ctx context.Context, userID uuid.UUID) ([]Document, error) {
+	var documents = []Document{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Unscoped().
+		Model(&Document{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
 		Find(&documents)
 
 	if res.Error != nil {
@@ -321,15 +1101,22 @@ func (db *DocumentDB) Count(ctx context.Context, userID uuid.UUID) (int64, error
 	return count, nil
 }
 
-// Get count for all used document types.
-func (db *DocumentDB) CountTypes(ctx context.Context, userID uuid.UUID) ([]*proto.DocumentTypesCount, error) {
+// Get count for all used document types, only of the person's documents if personID is set.
+func (db *DocumentDB) CountTypes(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTypesCount, error) {
 	var types = []*proto.DocumentTypesCount{}
-	res := db.Conn.
+
+	query := db.Conn.
 		WithContext(ctx).
-		Raw(
-			"SELECT type, COUNT(*) FROM documents WHERE user_id = ? GROUP BY type",
-			userID,
-		).
+		Model(&Document{}).
+		Select("type, COUNT(*) AS count").
+		Where(&Document{UserID: userID})
+
+	if personID != uuid.Nil {
+		query = query.Where("person_id = ?", personID)
+	}
+
+	res := query.
+		Group("type").
 		Scan(&types)
 
 	if res.Error != nil {
@@ -340,16 +1127,84 @@ func (db *DocumentDB) CountTypes(ctx context.Context, userID uuid.UUID) ([]*prot
 	return types, nil
 }
 
-// Find top N latest documents sorted by expiration date.
-func (db *DocumentDB) FindLatest(ctx context.Context, userID uuid.UUID, limit int) ([]Document, error) {
+// Get count of the documents for all used custom types, only of the person's documents if personID is set.
+// Names of the custom types are not set.
+func (db *DocumentDB) CountCustomTypes(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+) ([]*proto.DocumentCustomTypesCount, error) {
+	var customTypes = []*proto.DocumentCustomTypesCount{}
+
+	query := db.Conn.
+		WithContext(ctx).
+		Model(&Document{}).
+		Select("custom_type_id, COUNT(*) AS count").
+		Where(&Document{UserID: userID}).
+		Where("custom_type_id IS NOT NULL")
+
+	if personID != uuid.Nil {
+		query = query.Where("person_id = ?", personID)
+	}
+
+	res := query.
+		Group("custom_type_id").
+		Scan(&customTypes)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return customTypes, nil
+}
+
+// Get count of the documents for all used tags, only of the person's documents if personID is set.
+// Names of the tags are not set.
+func (db *DocumentDB) CountTags(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTagsCount, error) {
+	var tags = []*proto.DocumentTagsCount{}
+
+	query := db.Conn.
+		WithContext(ctx).
+		Model(&Document{}).
+		Select("document_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN document_tags ON document_tags.document_id = documents.id").
+		Where("documents.user_id = ?", userID)
+
+	if personID != uuid.Nil {
+		query = query.Where("documents.person_id = ?", personID)
+	}
+
+	res := query.
+		Group("document_tags.tag_id").
+		Scan(&tags)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return tags, nil
+}
+
+// Find top N latest documents sorted by expiration date, only of the person if personID is set.
+func (db *DocumentDB) FindLatest(ctx context.Context, userID, personID uuid.UUID, limit int) ([]Document, error) {
 	var documents = []Document{}
 
 	// TODO: Specify attributes to fetch
-	res := db.Conn.
+	query := db.Conn.
 		WithContext(ctx).
-		Select("id, type, title, expires_at, iv_title").
+		Select("id, user_id, type, title, expires_at, iv_title, key_version, user_key, person_id, custom_type_id").
 		Model(&Document{}).
-		Where(&Document{UserID: userID}).
+		Preload("Person").
+		Preload("CustomType").
+		Preload("Tags").
+		Where(&Document{UserID: userID})
+
+	if personID != uuid.Nil {
+		query = query.Where("person_id = ?", personID)
+	}
+
+	res := query.
 		Order("expires_at ASC").
 		Limit(limit).
 		Find(&documents)
@@ -366,3 +1221,57 @@ func (db *DocumentDB) FindLatest(ctx context.Context, userID uuid.UUID, limit in
 
 	return documents, nil
 }
+
+// Find batch of documents (including the trash) which should be re-encrypted:
+// encrypted with a legacy key instead of the owner's data key, with legacy AES-CBC (which uses IVs)
+// or without the search index.
+// Documents are ordered by ID and start after afterID, so the batches can be walked with a cursor.
+func (db *DocumentDB) FindForReencryption(ctx context.Context, afterID uuid.UUID, limit int) ([]Document, error) {
+	var documents = []Document{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Unscoped().
+		Model(&Document{}).
+		Where("(NOT user_key OR iv_title IS NOT NULL OR iv_description IS NOT NULL OR search_index = '') AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return documents, nil
+}
+
+// Save already encrypted fields of the document without calling the hooks.
+// Document is updated only if it still matches FindForReencryption,
+// so concurrent updates are not overwritten.
+func (db *DocumentDB) UpdateEncryption(ctx context.Context, d *Document) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Unscoped().
+		Model(&Document{}).
+		Where("id = ? AND (NOT user_key OR iv_title IS NOT NULL OR iv_description IS NOT NULL OR search_index = '')", d.ID).
+		UpdateColumns(map[string]interface{}{
+			"title":          d.Title,
+			"description":    d.Description,
+			"iv_title":       d.IVTitle,
+			"iv_description": d.IVDescription,
+			"user_key":       d.UserKey,
+			"search_index":   d.SearchIndex,
+		})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "document not found")
+	}
+
+	return nil
+}
@@ -1,10 +1,14 @@
 package document
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	datakeymocks "github.com/samgozman/validity.red/document/mocks/models/datakey"
+	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"github.com/samgozman/validity.red/document/pkg/keyring"
 	"gorm.io/gorm"
 )
 
@@ -55,7 +59,7 @@ func TestDocument_BeforeCreate(t *testing.T) {
 		tx *gorm.DB
 	}
 
-	EncryptionKey = []byte("8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q")
+	DataKeys = datakeymocks.NewDataKeyDBTest()
 
 	tests := []struct {
 		name     string
@@ -89,3 +93,144 @@ func TestDocument_BeforeCreate(t *testing.T) {
 }
 
 // TODO: test methods with DB call
+
+func TestDocument_Decrypt(t *testing.T) {
+	key := []byte("8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q")
+	Keys, _ = keyring.Parse(string(key), "", "")
+	DataKeys = datakeymocks.NewDataKeyDBTest()
+	ctx := context.Background()
+
+	encrypted := Document{ID: uuid.New(), UserID: uuid.New(), Title: "title", Description: "description"}
+	if err := encrypted.Encrypt(ctx); err != nil {
+		t.Fatalf("Document.Encrypt() error = %v", err)
+	}
+
+	iv := []byte("M7Z4es7yWRcduU3m")
+	legacyTitle, _ := encryption.EncryptAES(key, iv, "title")
+	legacyDescription, _ := encryption.EncryptAES(key, iv, "description")
+	legacyID := uuid.New()
+	legacyGCMTitle, _ := encryption.EncryptGCM(key, "title", legacyID[:])
+	legacyGCMDescription, _ := encryption.EncryptGCM(key, "description", legacyID[:])
+
+	tests := []struct {
+		name     string
+		document Document
+		wantErr  bool
+	}{
+		{
+			name:     "should decrypt AES-GCM fields with the data key",
+			document: encrypted,
+			wantErr:  false,
+		},
+		{
+			name: "should decrypt AES-GCM fields with the legacy key",
+			document: Document{
+				ID:          legacyID,
+				Title:       legacyGCMTitle,
+				Description: legacyGCMDescription,
+				KeyVersion:  keyring.LegacyVersion,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should decrypt legacy AES-CBC fields",
+			document: Document{
+				ID:            uuid.New(),
+				Title:         legacyTitle,
+				Description:   legacyDescription,
+				IVTitle:       iv,
+				IVDescription: iv,
+				KeyVersion:    keyring.LegacyVersion,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should fail if fields belong to another document",
+			document: Document{
+				ID:          uuid.New(),
+				UserID:      encrypted.UserID,
+				Title:       encrypted.Title,
+				Description: encrypted.Description,
+				UserKey:     true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail if data key was destroyed",
+			document: Document{
+				ID:          encrypted.ID,
+				UserID:      uuid.New(),
+				Title:       encrypted.Title,
+				Description: encrypted.Description,
+				UserKey:     true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail on unknown key version",
+			document: Document{
+				ID:          legacyID,
+				Title:       legacyGCMTitle,
+				Description: legacyGCMDescription,
+				KeyVersion:  2,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.document
+			err := d.Decrypt(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Document.Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (d.Title != "title" || d.Description != "description") {
+				t.Errorf("Document.Decrypt() = %v, %v, want title, description", d.Title, d.Description)
+			}
+		})
+	}
+}
+
+func TestDocument_NextExpiresAt(t *testing.T) {
+	expiresAt := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	months := func(m int32) *int32 { return &m }
+
+	tests := []struct {
+		name     string
+		document Document
+		want     time.Time
+		wantOk   bool
+	}{
+		{
+			name:     "should not renew without renewal period",
+			document: Document{ExpiresAt: expiresAt},
+			wantOk:   false,
+		},
+		{
+			name:     "should not renew with zero renewal period",
+			document: Document{ExpiresAt: expiresAt, RenewalMonths: months(0)},
+			wantOk:   false,
+		},
+		{
+			name:     "should renew for a year",
+			document: Document{ExpiresAt: expiresAt, RenewalMonths: months(12)},
+			want:     time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+			wantOk:   true,
+		},
+		{
+			name:     "should renew for a month",
+			document: Document{ExpiresAt: expiresAt, RenewalMonths: months(1)},
+			want:     time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			wantOk:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.document.NextExpiresAt()
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("Document.NextExpiresAt() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package document
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrInvalidCursor = status.Error(codes.InvalidArgument, "invalid cursor")
+
+// Sortable columns of the documents list.
+var sortColumns = map[proto.DocumentSort]string{
+	proto.DocumentSort_EXPIRES_AT: "expires_at",
+	proto.DocumentSort_CREATED_AT: "created_at",
+	proto.DocumentSort_TYPE:       "type",
+}
+
+// ListOptions are used to paginate, sort and filter documents list.
+type ListOptions struct {
+	Limit         int     // Max number of documents on the page, no limit if 0
+	Cursor        *Cursor // Position to start the page after
+	SortBy        proto.DocumentSort
+	Descending    bool
+	Types         []proto.Type
+	CustomTypeIDs []uuid.UUID   // Only documents of the custom types, all types if empty
+	TagIDs        []uuid.UUID   // Only documents with any of the tags, no filter if empty
+	PersonID      uuid.UUID     // Only documents of the person, all documents if Nil
+	Expired       bool          // Only expired documents
+	ExpiresWithin time.Duration // Only not expired documents which expire within the duration
+	Now           time.Time     // Time to compare expiration dates with
+}
+
+// Cursor is a position of the document in the sorted list (keyset pagination).
+type Cursor struct {
+	ExpiresAt time.Time `json:"e,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	Type      int32     `json:"t,omitempty"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// Create cursor pointing to the document.
+func NewCursor(d *Document) *Cursor {
+	c := &Cursor{
+		ExpiresAt: d.ExpiresAt,
+		CreatedAt: d.CreatedAt,
+		ID:        d.ID,
+	}
+
+	if d.Type != nil {
+		c.Type = int32(*d.Type)
+	}
+
+	return c
+}
+
+// Encode cursor to an opaque string.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode cursor from the opaque string.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// Get value of the sort column at the cursor.
+func (c *Cursor) value(sortBy proto.DocumentSort) interface{} {
+	switch sortBy {
+	case proto.DocumentSort_CREATED_AT:
+		return c.CreatedAt
+	case proto.DocumentSort_TYPE:
+		return c.Type
+	default:
+		return c.ExpiresAt
+	}
+}
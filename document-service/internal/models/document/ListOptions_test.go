@@ -0,0 +1,49 @@
+package document
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+func TestCursor_Encode(t *testing.T) {
+	d := &Document{
+		ID:        uuid.New(),
+		Type:      proto.Type_PASSPORT.Enum(),
+		ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC),
+		CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC),
+	}
+
+	c, err := DecodeCursor(NewCursor(d).Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+
+	if c.ID != d.ID || !c.ExpiresAt.Equal(d.ExpiresAt) || !c.CreatedAt.Equal(d.CreatedAt) {
+		t.Errorf("DecodeCursor() = %+v, want cursor of %+v", c, d)
+	}
+
+	if c.value(proto.DocumentSort_TYPE) != int32(proto.Type_PASSPORT) {
+		t.Errorf("Cursor.value() = %v, want %v", c.value(proto.DocumentSort_TYPE), int32(proto.Type_PASSPORT))
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor"},
+		{name: "not json", cursor: "bm90IGpzb24"},
+		{name: "without id", cursor: "e30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); err == nil {
+				t.Errorf("DecodeCursor() error = nil, want %v", ErrInvalidCursor)
+			}
+		})
+	}
+}
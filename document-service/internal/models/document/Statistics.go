@@ -0,0 +1,170 @@
+package document
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// Number of months in the expiry timeline, starting with the current one.
+const TimelineMonths = 12
+
+// Count of the documents expiring in the month.
+type monthCount struct {
+	Month time.Time `gorm:"column:month"`
+	Count int64     `gorm:"column:count"`
+}
+
+// Query of the user's documents, only of the person's documents if personID is set.
+func (db *DocumentDB) statisticsQuery(ctx context.Context, userID, personID uuid.UUID) *gorm.DB {
+	query := db.Conn.
+		WithContext(ctx).
+		Model(&Document{}).
+		Where("documents.user_id = ?", userID)
+
+	if personID != uuid.Nil {
+		query = query.Where("documents.person_id = ?", personID)
+	}
+
+	return query
+}
+
+// Count expired documents, documents expiring within 30, 90 and 365 days from now
+// and documents without any notifications in one query.
+func (db *DocumentDB) CountExpiry(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) (*proto.DocumentsExpiryCount, error) {
+	var counts struct {
+		Expired              int64 `gorm:"column:expired"`
+		Within30Days         int64 `gorm:"column:within_30_days"`
+		Within90Days         int64 `gorm:"column:within_90_days"`
+		Within365Days        int64 `gorm:"column:within_365_days"`
+		WithoutNotifications int64 `gorm:"column:without_notifications"`
+	}
+
+	day := 24 * time.Hour
+
+	res := db.statisticsQuery(ctx, userID, personID).
+		Select(
+			"COUNT(*) FILTER (WHERE expires_at < ?) AS expired, "+
+				"COUNT(*) FILTER (WHERE expires_at >= ? AND expires_at < ?) AS within_30_days, "+
+				"COUNT(*) FILTER (WHERE expires_at >= ? AND expires_at < ?) AS within_90_days, "+
+				"COUNT(*) FILTER (WHERE expires_at >= ? AND expires_at < ?) AS within_365_days, "+
+				"COUNT(*) FILTER (WHERE NOT EXISTS ("+
+				"SELECT 1 FROM notifications WHERE notifications.document_id = documents.id AND notifications.deleted_at IS NULL"+
+				")) AS without_notifications",
+			now,
+			now, now.Add(30*day),
+			now, now.Add(90*day),
+			now, now.Add(365*day),
+		).
+		Scan(&counts)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return &proto.DocumentsExpiryCount{
+		Expired:              counts.Expired,
+		Within30Days:         counts.Within30Days,
+		Within90Days:         counts.Within90Days,
+		Within365Days:        counts.Within365Days,
+		WithoutNotifications: counts.WithoutNotifications,
+	}, nil
+}
+
+// Count not expired documents for each month of the timeline starting with the current month (UTC).
+// Months without documents are included with zero count.
+func (db *DocumentDB) CountExpiryTimeline(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) ([]*proto.ExpiryMonthCount, error) {
+	var counts []monthCount
+
+	start := monthStart(now)
+
+	res := db.statisticsQuery(ctx, userID, personID).
+		Select("date_trunc('month', expires_at AT TIME ZONE 'UTC') AS month, COUNT(*) AS count").
+		Where("expires_at >= ? AND expires_at < ?", now, start.AddDate(0, TimelineMonths, 0)).
+		Group("month").
+		Order("month ASC").
+		Scan(&counts)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return expiryTimeline(start, counts), nil
+}
+
+// Count notifications of the user's documents which are not in the trash.
+func (db *DocumentDB) CountNotifications(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) (*proto.NotificationsStatistics, error) {
+	var counts struct {
+		Total     int64 `gorm:"column:total"`
+		Upcoming  int64 `gorm:"column:upcoming"`
+		Delivered int64 `gorm:"column:delivered"`
+	}
+
+	res := db.statisticsQuery(ctx, userID, personID).
+		Select(
+			"COUNT(*) AS total, "+
+				"COUNT(*) FILTER (WHERE notifications.delivered_at IS NULL AND notifications.date >= ?) AS upcoming, "+
+				"COUNT(*) FILTER (WHERE notifications.delivered_at IS NOT NULL) AS delivered",
+			now,
+		).
+		Joins("JOIN notifications ON notifications.document_id = documents.id AND notifications.deleted_at IS NULL").
+		Scan(&counts)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return &proto.NotificationsStatistics{
+		Total:     counts.Total,
+		Upcoming:  counts.Upcoming,
+		Delivered: counts.Delivered,
+	}, nil
+}
+
+// Get the first day of the month (UTC).
+func monthStart(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Create the timeline of TimelineMonths months from start with the counts of the months, missing months have zero count.
+func expiryTimeline(start time.Time, counts []monthCount) []*proto.ExpiryMonthCount {
+	timeline := make([]*proto.ExpiryMonthCount, 0, TimelineMonths)
+
+	for i := 0; i < TimelineMonths; i++ {
+		month := start.AddDate(0, i, 0)
+		item := &proto.ExpiryMonthCount{Month: timestamppb.New(month)}
+
+		for _, c := range counts {
+			if monthStart(c.Month).Equal(month) {
+				item.Count += c.Count
+			}
+		}
+
+		timeline = append(timeline, item)
+	}
+
+	return timeline
+}
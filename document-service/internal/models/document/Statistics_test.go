@@ -0,0 +1,37 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryTimeline(t *testing.T) {
+	start := monthStart(time.Date(2024, time.November, 15, 10, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)))
+
+	if !start.Equal(time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("monthStart() = %v, want first day of November (UTC)", start)
+	}
+
+	timeline := expiryTimeline(start, []monthCount{
+		{Month: time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC), Count: 2},
+		{Month: time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), Count: 5},
+		{Month: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC), Count: 1},
+	})
+
+	if len(timeline) != TimelineMonths {
+		t.Fatalf("expiryTimeline() returned %d months, want %d", len(timeline), TimelineMonths)
+	}
+
+	want := map[int]int64{0: 2, 3: 5, 11: 1}
+
+	for i, m := range timeline {
+		month := start.AddDate(0, i, 0)
+		if !m.GetMonth().AsTime().Equal(month) {
+			t.Errorf("expiryTimeline()[%d].Month = %v, want %v", i, m.GetMonth().AsTime(), month)
+		}
+
+		if m.GetCount() != want[i] {
+			t.Errorf("expiryTimeline()[%d].Count = %d, want %d", i, m.GetCount(), want[i])
+		}
+	}
+}
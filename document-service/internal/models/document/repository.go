@@ -2,19 +2,37 @@ package document
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
 	proto "github.com/samgozman/validity.red/document/proto"
 )
 
 type DocumentRepository interface {
 	InsertOne(ctx context.Context, d *Document) error
-	UpdateOne(ctx context.Context, d *Document) error
+	InsertMany(ctx context.Context, documents []Document, limit int64) error
+	UpdateOne(ctx context.Context, d *Document, opts UpdateOptions) error
 	DeleteOne(ctx context.Context, d *Document) error
+	Restore(ctx context.Context, d *Document) error
+	PurgeOne(ctx context.Context, d *Document) error
+	Renew(ctx context.Context, d *Document, expiresAt time.Time) (*renewal.Renewal, error)
+	PurgeDeleted(ctx context.Context, before time.Time) ([]Document, error)
+	DeleteAllForUser(ctx context.Context, userID uuid.UUID) error
 	FindOne(ctx context.Context, d *Document) error
 	Exists(ctx context.Context, d *Document) (bool, error)
-	FindAll(ctx context.Context, userID uuid.UUID) ([]Document, error)
+	FindAll(ctx context.Context, userID uuid.UUID, opts *ListOptions) ([]Document, string, error)
+	FindShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]Document, error)
+	Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]Document, error)
+	FindDeleted(ctx context.Context, userID uuid.UUID) ([]Document, error)
 	Count(ctx context.Context, userID uuid.UUID) (int64, error)
-	CountTypes(ctx context.Context, userID uuid.UUID) ([]*proto.DocumentTypesCount, error)
-	FindLatest(ctx context.Context, userID uuid.UUID, limit int) ([]Document, error)
+	CountTypes(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTypesCount, error)
+	CountCustomTypes(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentCustomTypesCount, error)
+	CountTags(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTagsCount, error)
+	CountExpiry(ctx context.Context, userID, personID uuid.UUID, now time.Time) (*proto.DocumentsExpiryCount, error)
+	CountExpiryTimeline(ctx context.Context, userID, personID uuid.UUID, now time.Time) ([]*proto.ExpiryMonthCount, error)
+	CountNotifications(ctx context.Context, userID, personID uuid.UUID, now time.Time) (*proto.NotificationsStatistics, error)
+	FindLatest(ctx context.Context, userID, personID uuid.UUID, limit int) ([]Document, error)
+	FindForReencryption(ctx context.Context, afterID uuid.UUID, limit int) ([]Document, error)
+	UpdateEncryption(ctx context.Context, d *Document) error
 }
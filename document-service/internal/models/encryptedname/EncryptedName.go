@@ -0,0 +1,71 @@
+// Package encryptedname contains the names of the user's entities (persons, custom types and tags)
+// which are encrypted with the owner's data key and bound to the entity ID.
+package encryptedname
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Per-user data keys used to encrypt names. Should be set on the service start.
+var DataKeys datakey.DataKeyRepository
+
+// Validate the owner and the name before inserting the entity into database.
+func Validate(userID uuid.UUID, name string, maxLength int) error {
+	if userID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if len([]rune(name)) > maxLength {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("name length must be less than %d characters", maxLength))
+	}
+
+	return nil
+}
+
+// Encrypt the name of the entity with the owner's data key.
+func Encrypt(ctx context.Context, userID, id uuid.UUID, name string) (string, error) {
+	key, err := DataKeys.GetOrCreate(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := encryption.EncryptGCM(key, name, id[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return "", status.Error(codes.Internal, err.Error())
+	}
+
+	return encrypted, nil
+}
+
+// Decrypt the name of the entity with the owner's data key. Names stored before the encryption are returned as is.
+func Decrypt(ctx context.Context, userID, id uuid.UUID, name string) (string, error) {
+	if !encryption.IsGCM(name) {
+		return name, nil
+	}
+
+	key, err := DataKeys.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	decrypted, err := encryption.DecryptGCM(key, name, id[:])
+	if err != nil {
+		sentry.CaptureException(err)
+		return "", status.Error(codes.Internal, err.Error())
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,117 @@
+package encryptedname
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	datakeymocks "github.com/samgozman/validity.red/document/mocks/models/datakey"
+)
+
+func TestValidate(t *testing.T) {
+	const maxLength = 10
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		value   string
+		wantErr bool
+	}{
+		{
+			name:    "fail if userID is empty",
+			value:   "Alice",
+			wantErr: true,
+		},
+		{
+			name:    "fail if name is empty",
+			userID:  uuid.New(),
+			wantErr: true,
+		},
+		{
+			name:    "fail if name is too long",
+			userID:  uuid.New(),
+			value:   strings.Repeat("a", maxLength+1),
+			wantErr: true,
+		},
+		{
+			name:    "should count characters, not bytes",
+			userID:  uuid.New(),
+			value:   strings.Repeat("я", maxLength),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.userID, tt.value, maxLength); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	DataKeys = datakeymocks.NewDataKeyDBTest()
+	ctx := context.Background()
+
+	id, userID := uuid.New(), uuid.New()
+
+	encrypted, err := Encrypt(ctx, userID, id, "Alice")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if encrypted == "Alice" {
+		t.Fatalf("Encrypt() name is not encrypted")
+	}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		id      uuid.UUID
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "should decrypt name",
+			userID: userID,
+			id:     id,
+			value:  encrypted,
+			want:   "Alice",
+		},
+		{
+			name:   "should return name stored before the encryption",
+			userID: userID,
+			id:     id,
+			value:  "Bob",
+			want:   "Bob",
+		},
+		{
+			name:    "should fail if name belongs to another entity",
+			userID:  userID,
+			id:      uuid.New(),
+			value:   encrypted,
+			wantErr: true,
+		},
+		{
+			name:    "should fail if data key belongs to another user",
+			userID:  uuid.New(),
+			id:      id,
+			value:   encrypted,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(ctx, tt.userID, tt.id, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/share"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
@@ -23,12 +24,26 @@ func NewNotificationDB(db *gorm.DB) *NotificationDB {
 }
 
 type Notification struct {
-	ID         uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
-	UserID     uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
-	DocumentID uuid.UUID `gorm:"type:uuid;index;not null;" json:"document_id,omitempty"`
-	Date       time.Time `gorm:"type:time;not null;" json:"date,omitempty"`
-	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
-	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	ID            uuid.UUID      `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID        uuid.UUID      `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	DocumentID    uuid.UUID      `gorm:"type:uuid;index;not null;" json:"document_id,omitempty"`
+	RuleID        *uuid.UUID     `gorm:"type:uuid;index;" json:"rule_id,omitempty"` // Set if the date is computed by the reminder rule
+	Date          time.Time      `gorm:"type:timestamptz;not null;" json:"date,omitempty"`
+	DeliveredAt   *time.Time     `gorm:"index" json:"delivered_at,omitempty"`
+	Attempts      int            `gorm:"default:0;not null;" json:"attempts,omitempty"` // Number of failed delivery attempts
+	NextAttemptAt *time.Time     `gorm:"index" json:"next_attempt_at,omitempty"`        // Delivery is retried after this time
+	FailedAt      *time.Time     `gorm:"index" json:"failed_at,omitempty"`              // Delivery is given up after too many attempts
+	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// ResetDelivery adds the columns which make the rescheduled notification to be delivered again.
+func ResetDelivery(fields map[string]interface{}) {
+	fields["delivered_at"] = nil
+	fields["attempts"] = 0
+	fields["next_attempt_at"] = nil
+	fields["failed_at"] = nil
 }
 
 // Prepare Notification object before inserting into database.
@@ -86,10 +101,15 @@ func (db *NotificationDB) InsertOne(ctx context.Context, n *Notification) error
 	return nil
 }
 
+// Permanently delete one notification. Notifications are only soft deleted
+// together with their document, see DocumentDB.DeleteOne.
+// Notifications of the reminder rules are deleted only with their rule.
 func (db *NotificationDB) DeleteOne(ctx context.Context, n *Notification) error {
 	res := db.Conn.
 		WithContext(ctx).
+		Unscoped().
 		Where(&Notification{ID: n.ID, DocumentID: n.DocumentID}).
+		Where("rule_id IS NULL").
 		Delete(&Notification{})
 
 	if res.Error != nil {
@@ -174,3 +194,93 @@ func (db *NotificationDB) FindAllForUser(ctx context.Context, userID uuid.UUID)
 
 	return notifications, nil
 }
+
+// Find notifications of other users' documents shared with any of the groups.
+func (db *NotificationDB) FindAllShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]Notification, error) {
+	var notifications = []Notification{}
+
+	if len(groupIDs) == 0 {
+		return notifications, nil
+	}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Notification{}).
+		Where("notifications.user_id <> ?", userID).
+		Where("EXISTS (?)", share.AccessQuery(db.Conn, groupIDs, "notifications.user_id", "notifications.document_id")).
+		Find(&notifications)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return notifications, nil
+}
+
+// Find notifications which date has already passed and that were not delivered yet.
+// Notifications waiting for the retry and the given up ones are skipped. Oldest notifications are returned first.
+func (db *NotificationDB) FindDue(ctx context.Context, before time.Time, limit int) ([]Notification, error) {
+	var notifications = []Notification{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Notification{}).
+		Where("date <= ? AND delivered_at IS NULL AND failed_at IS NULL", before).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", before).
+		Order("date ASC").
+		Limit(limit).
+		Find(&notifications)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return notifications, nil
+}
+
+// Mark notification as delivered, so it will not be sent again.
+func (db *NotificationDB) SetDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Notification{}).
+		Where("id = ? AND delivered_at IS NULL", id).
+		Update("delivered_at", deliveredAt)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "notification not found")
+	}
+
+	return nil
+}
+
+// Save failed delivery attempt: the attempts count, the time of the next attempt
+// or the time when the delivery was given up.
+func (db *NotificationDB) SetFailedAttempt(ctx context.Context, n *Notification) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Notification{}).
+		Where("id = ? AND delivered_at IS NULL", n.ID).
+		Updates(map[string]interface{}{
+			"attempts":        n.Attempts,
+			"next_attempt_at": n.NextAttemptAt,
+			"failed_at":       n.FailedAt,
+		})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "notification not found")
+	}
+
+	return nil
+}
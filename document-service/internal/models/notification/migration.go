@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MigrateDateColumn converts the legacy `date` column of the notifications table
+// from the time of day (`time`) to the full timestamp (`timestamptz`).
+// Must be called before AutoMigrate, which can't cast time to timestamptz.
+//
+// Legacy rows only kept the time of day, so the dates are restored where possible:
+//   - notifications of the reminder rules are recomputed from the document expiration date,
+//     the ones which date has passed are marked as delivered;
+//   - the date of the other notifications is lost, so they are marked as delivered too,
+//     otherwise all of them would be sent at once after the migration.
+//
+// The number of affected notifications and users is logged, so the users can be told
+// to set their reminders again.
+func MigrateDateColumn(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Notification{}) {
+		return nil
+	}
+
+	var dataType string
+
+	res := db.Raw(
+		"SELECT data_type FROM information_schema.columns "+
+			"WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?",
+		"notifications", "date",
+	).Scan(&dataType)
+	if res.Error != nil {
+		return fmt.Errorf("error reading notifications.date type: %w", res.Error)
+	}
+
+	if dataType != "time without time zone" {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&Notification{}, "DeliveredAt") {
+			if err := tx.Migrator().AddColumn(&Notification{}, "DeliveredAt"); err != nil {
+				return fmt.Errorf("error adding notifications.delivered_at: %w", err)
+			}
+		}
+
+		err := tx.Exec(
+			"ALTER TABLE notifications ALTER COLUMN date TYPE timestamptz " +
+				"USING (CURRENT_DATE + date) AT TIME ZONE 'UTC'",
+		).Error
+		if err != nil {
+			return fmt.Errorf("error converting notifications.date to timestamptz: %w", err)
+		}
+
+		if tx.Migrator().HasColumn(&Notification{}, "RuleID") && tx.Migrator().HasTable("rules") {
+			err = tx.Exec(
+				"UPDATE notifications SET date = documents.expires_at - rules.days_before * INTERVAL '1 day' " +
+					"FROM rules JOIN documents ON documents.id = rules.document_id " +
+					"WHERE notifications.rule_id = rules.id",
+			).Error
+			if err != nil {
+				return fmt.Errorf("error recomputing rule notifications dates: %w", err)
+			}
+		}
+
+		hasRules := tx.Migrator().HasColumn(&Notification{}, "RuleID")
+
+		if hasRules {
+			res := tx.Exec("UPDATE notifications SET delivered_at = NOW() " +
+				"WHERE delivered_at IS NULL AND rule_id IS NOT NULL AND date < NOW()")
+			if res.Error != nil {
+				return fmt.Errorf("error marking past rule notifications as delivered: %w", res.Error)
+			}
+
+			log.Printf("Notifications migration: %d past rule notifications marked as delivered", res.RowsAffected)
+		}
+
+		where := "delivered_at IS NULL"
+		if hasRules {
+			where += " AND rule_id IS NULL"
+		}
+
+		var lostUsers int64
+
+		err = tx.Table("notifications").Where(where).Distinct("user_id").Count(&lostUsers).Error
+		if err != nil {
+			return fmt.Errorf("error counting users of legacy notifications: %w", err)
+		}
+
+		res := tx.Exec("UPDATE notifications SET delivered_at = NOW() WHERE " + where)
+		if res.Error != nil {
+			return fmt.Errorf("error marking legacy notifications as delivered: %w", res.Error)
+		}
+
+		log.Printf(
+			"Notifications migration: %d pending notifications of %d users have lost their dates and are marked as delivered",
+			res.RowsAffected, lostUsers,
+		)
+
+		return nil
+	})
+}
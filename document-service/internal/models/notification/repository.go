@@ -2,6 +2,7 @@ package notification
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -13,4 +14,8 @@ type NotificationRepository interface {
 	Count(ctx context.Context, documentID uuid.UUID) (int64, error)
 	CountAll(ctx context.Context, userID uuid.UUID) (int64, error)
 	FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Notification, error)
+	FindAllShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]Notification, error)
+	FindDue(ctx context.Context, before time.Time, limit int) ([]Notification, error)
+	SetDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error
+	SetFailedAttempt(ctx context.Context, n *Notification) error
 }
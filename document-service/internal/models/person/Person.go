@@ -0,0 +1,217 @@
+// Package person contains the people without accounts (e.g. children or relatives)
+// whose documents are managed by the user.
+package person
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/internal/models/encryptedname"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type PersonDB struct {
+	Conn *gorm.DB
+}
+
+func NewPersonDB(db *gorm.DB) *PersonDB {
+	return &PersonDB{
+		Conn: db.Table("persons"),
+	}
+}
+
+const MaxNameLength = 100
+
+type Person struct {
+	ID        uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	Name      string    `gorm:"not null;" json:"name,omitempty"` // Encrypted with the owner's data key
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (Person) TableName() string {
+	return "persons"
+}
+
+// Validate Person object before inserting into database.
+func (p *Person) Validate() error {
+	return encryptedname.Validate(p.UserID, p.Name, MaxNameLength)
+}
+
+// Encrypt the name with the owner's data key, it is bound to the person ID.
+func (p *Person) Encrypt(ctx context.Context) error {
+	name, err := encryptedname.Encrypt(ctx, p.UserID, p.ID, p.Name)
+	if err != nil {
+		return err
+	}
+
+	p.Name = name
+
+	return nil
+}
+
+// Decrypt the name with the owner's data key.
+func (p *Person) Decrypt(ctx context.Context) error {
+	name, err := encryptedname.Decrypt(ctx, p.UserID, p.ID, p.Name)
+	if err != nil {
+		return err
+	}
+
+	p.Name = name
+
+	return nil
+}
+
+func (p *Person) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+
+	return p.Encrypt(datakey.HookContext(tx))
+}
+
+func (p *Person) BeforeUpdate(tx *gorm.DB) error {
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+
+	return p.Encrypt(datakey.HookContext(tx))
+}
+
+func (p *Person) AfterFind(tx *gorm.DB) error {
+	return p.Decrypt(datakey.HookContext(tx))
+}
+
+// Insert one Person object into database.
+func (db *PersonDB) InsertOne(ctx context.Context, p *Person) error {
+	res := db.Conn.WithContext(ctx).Create(&p)
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Rename the user's person.
+func (db *PersonDB) UpdateOne(ctx context.Context, p *Person) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Person{ID: p.ID, UserID: p.UserID}).
+		Updates(&Person{
+			ID:     p.ID,     // Used by BeforeUpdate hook to encrypt the name
+			UserID: p.UserID, // Used by BeforeUpdate hook to get the data key
+			Name:   p.Name,
+		})
+
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "person not found")
+	}
+
+	return nil
+}
+
+// Delete the user's person, documents of the person are kept without it by the foreign key.
+func (db *PersonDB) DeleteOne(ctx context.Context, p *Person) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Person{ID: p.ID, UserID: p.UserID}).
+		Delete(&Person{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "person not found")
+	}
+
+	return nil
+}
+
+// Find all persons of the user, oldest first.
+func (db *PersonDB) FindAll(ctx context.Context, userID uuid.UUID) ([]Person, error) {
+	var persons = []Person{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Person{}).
+		Where(&Person{UserID: userID}).
+		Order("created_at ASC").
+		Find(&persons)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return persons, nil
+}
+
+// Checks if the person of the user exists.
+func (db *PersonDB) Exists(ctx context.Context, p *Person) (bool, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Person{}).
+		Where(&Person{ID: p.ID, UserID: p.UserID}).
+		Count(&count)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return false, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count > 0, nil
+}
+
+// Count persons of the user.
+func (db *PersonDB) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Person{}).
+		Where(&Person{UserID: userID}).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
@@ -0,0 +1,20 @@
+package person
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPerson_Validate(t *testing.T) {
+	userID := uuid.New()
+
+	if err := (&Person{UserID: userID, Name: strings.Repeat("a", MaxNameLength)}).Validate(); err != nil {
+		t.Errorf("Person.Validate() error = %v, want name of %d characters to pass", err, MaxNameLength)
+	}
+
+	if err := (&Person{UserID: userID, Name: strings.Repeat("a", MaxNameLength+1)}).Validate(); err == nil {
+		t.Errorf("Person.Validate() want error for name longer than %d characters", MaxNameLength)
+	}
+}
@@ -0,0 +1,16 @@
+package person
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type PersonRepository interface {
+	InsertOne(ctx context.Context, p *Person) error
+	UpdateOne(ctx context.Context, p *Person) error
+	DeleteOne(ctx context.Context, p *Person) error
+	FindAll(ctx context.Context, userID uuid.UUID) ([]Person, error)
+	Exists(ctx context.Context, p *Person) (bool, error)
+	Count(ctx context.Context, userID uuid.UUID) (int64, error)
+}
@@ -0,0 +1,172 @@
+// Package preset contains the user's default reminders for the document types,
+// e.g. "6 months and 1 month before" for passports.
+//
+// Presets are applied as reminder rules to the new documents of the same type.
+package preset
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type PresetDB struct {
+	Conn *gorm.DB
+}
+
+func NewPresetDB(db *gorm.DB) *PresetDB {
+	return &PresetDB{
+		Conn: db.Table("presets"),
+	}
+}
+
+type Preset struct {
+	ID         uuid.UUID  `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID     uuid.UUID  `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	Type       proto.Type `gorm:"type:int;not null;" json:"type"`
+	DaysBefore int32      `gorm:"not null;" json:"days_before"` // 0 is the expiry day
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+// Validate Preset object before inserting into database.
+func (p *Preset) Validate() error {
+	if p.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if _, ok := proto.Type_name[int32(p.Type)]; !ok {
+		return status.Error(codes.InvalidArgument, "type is invalid")
+	}
+
+	if p.DaysBefore < 0 || p.DaysBefore > rule.MaxDaysBefore {
+		return status.Error(codes.InvalidArgument, "days_before must be between 0 and 3650")
+	}
+
+	return nil
+}
+
+func (p *Preset) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+
+	return p.Validate()
+}
+
+// Insert the preset if the user has no preset with the same days for this type.
+func (db *PresetDB) InsertOne(ctx context.Context, p *Preset) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+
+		res := tx.
+			Table("presets").
+			Where(&Preset{UserID: p.UserID}).
+			Where("type = ? AND days_before = ?", p.Type, p.DaysBefore).
+			Count(&count)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if count > 0 {
+			return status.Error(codes.AlreadyExists, "reminder preset already exists")
+		}
+
+		res = tx.Table("presets").Create(p)
+		if res.Error != nil {
+			if _, ok := status.FromError(res.Error); ok {
+				return res.Error
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Delete the user's preset.
+func (db *PresetDB) DeleteOne(ctx context.Context, p *Preset) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Preset{ID: p.ID, UserID: p.UserID}).
+		Delete(&Preset{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "reminder preset not found")
+	}
+
+	return nil
+}
+
+// Find all user's presets ordered by type, the closest to the expiry first.
+func (db *PresetDB) FindAll(ctx context.Context, userID uuid.UUID) ([]Preset, error) {
+	var presets = []Preset{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Preset{}).
+		Where(&Preset{UserID: userID}).
+		Order("type ASC").
+		Order("days_before ASC").
+		Find(&presets)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return presets, nil
+}
+
+// Find user's presets for the document type, the closest to the expiry first.
+func (db *PresetDB) FindByType(ctx context.Context, userID uuid.UUID, t proto.Type) ([]Preset, error) {
+	var presets = []Preset{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Preset{}).
+		Where(&Preset{UserID: userID}).
+		Where("type = ?", t).
+		Order("days_before ASC").
+		Find(&presets)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return presets, nil
+}
+
+// Count user's presets for the document type.
+func (db *PresetDB) Count(ctx context.Context, userID uuid.UUID, t proto.Type) (int64, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Preset{}).
+		Where(&Preset{UserID: userID}).
+		Where("type = ?", t).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
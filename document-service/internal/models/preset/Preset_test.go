@@ -0,0 +1,55 @@
+package preset
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+func TestPreset_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  Preset
+		wantErr bool
+	}{
+		{
+			name:    "fail if userID is empty",
+			preset:  Preset{Type: proto.Type_PASSPORT, DaysBefore: 30},
+			wantErr: true,
+		},
+		{
+			name:    "fail if type is unknown",
+			preset:  Preset{UserID: uuid.New(), Type: proto.Type(100), DaysBefore: 30},
+			wantErr: true,
+		},
+		{
+			name:    "fail if days before is negative",
+			preset:  Preset{UserID: uuid.New(), Type: proto.Type_PASSPORT, DaysBefore: -1},
+			wantErr: true,
+		},
+		{
+			name:    "fail if days before is too large",
+			preset:  Preset{UserID: uuid.New(), Type: proto.Type_PASSPORT, DaysBefore: rule.MaxDaysBefore + 1},
+			wantErr: true,
+		},
+		{
+			name:    "should pass for the default type",
+			preset:  Preset{UserID: uuid.New(), Type: proto.Type_DEFAULT_DOCUMENT, DaysBefore: 0},
+			wantErr: false,
+		},
+		{
+			name:    "should pass",
+			preset:  Preset{UserID: uuid.New(), Type: proto.Type_PASSPORT, DaysBefore: 180},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.preset.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Preset.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package preset
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+type PresetRepository interface {
+	InsertOne(ctx context.Context, p *Preset) error
+	DeleteOne(ctx context.Context, p *Preset) error
+	FindAll(ctx context.Context, userID uuid.UUID) ([]Preset, error)
+	FindByType(ctx context.Context, userID uuid.UUID, t proto.Type) ([]Preset, error)
+	Count(ctx context.Context, userID uuid.UUID, t proto.Type) (int64, error)
+}
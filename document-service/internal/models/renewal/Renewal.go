@@ -0,0 +1,94 @@
+package renewal
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type RenewalDB struct {
+	Conn *gorm.DB
+}
+
+func NewRenewalDB(db *gorm.DB) *RenewalDB {
+	return &RenewalDB{
+		Conn: db.Table("renewals"),
+	}
+}
+
+// Renewal is a record of the document validity period which was replaced by the new one.
+// Created by DocumentDB.Renew, so the history of the document periods can be shown.
+type Renewal struct {
+	ID                uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID            uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	DocumentID        uuid.UUID `gorm:"type:uuid;index;not null;" json:"document_id,omitempty"`
+	PreviousExpiresAt time.Time `gorm:"not null;" json:"previous_expires_at,omitempty"` // End of the previous period
+	ExpiresAt         time.Time `gorm:"not null;" json:"expires_at,omitempty"`          // End of the new period
+	CreatedAt         time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+// Validate Renewal object before inserting into database.
+func (r *Renewal) Validate() error {
+	if r.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if r.DocumentID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "document_id is required")
+	}
+
+	if !r.ExpiresAt.After(r.PreviousExpiresAt) {
+		return status.Error(codes.InvalidArgument, "expires_at must be after the previous expiration date")
+	}
+
+	return nil
+}
+
+func (r *Renewal) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+
+	return r.Validate()
+}
+
+// Find renewals history of the user's document, latest first.
+func (db *RenewalDB) FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]Renewal, error) {
+	var renewals = []Renewal{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Renewal{}).
+		Where(&Renewal{UserID: userID, DocumentID: documentID}).
+		Order("created_at DESC").
+		Find(&renewals)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return renewals, nil
+}
+
+// Find renewals of all user's documents, including the deleted ones.
+func (db *RenewalDB) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Renewal, error) {
+	var renewals = []Renewal{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Renewal{}).
+		Where(&Renewal{UserID: userID}).
+		Order("created_at DESC").
+		Find(&renewals)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return renewals, nil
+}
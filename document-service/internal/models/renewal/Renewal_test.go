@@ -0,0 +1,46 @@
+package renewal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRenewal_Validate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		renewal Renewal
+		wantErr bool
+	}{
+		{
+			name:    "fail if userID is empty",
+			renewal: Renewal{DocumentID: uuid.New(), PreviousExpiresAt: now, ExpiresAt: now.AddDate(1, 0, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "fail if documentID is empty",
+			renewal: Renewal{UserID: uuid.New(), PreviousExpiresAt: now, ExpiresAt: now.AddDate(1, 0, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "fail if new period ends before the previous one",
+			renewal: Renewal{UserID: uuid.New(), DocumentID: uuid.New(), PreviousExpiresAt: now, ExpiresAt: now},
+			wantErr: true,
+		},
+		{
+			name:    "should pass",
+			renewal: Renewal{UserID: uuid.New(), DocumentID: uuid.New(), PreviousExpiresAt: now, ExpiresAt: now.AddDate(1, 0, 0)},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.renewal.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Renewal.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
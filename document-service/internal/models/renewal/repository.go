@@ -0,0 +1,12 @@
+package renewal
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type RenewalRepository interface {
+	FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]Renewal, error)
+	FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Renewal, error)
+}
@@ -0,0 +1,251 @@
+// Package rule contains reminder rules relative to the document expiration date,
+// e.g. "30 days before expiry" or "on expiry day".
+//
+// Every rule owns one notification with the date computed from the document expiration date,
+// so it is delivered, counted and shown in the calendar like the absolute notifications.
+// The date is recomputed by Recompute when the document expiration date is changed.
+package rule
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/notification"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type RuleDB struct {
+	Conn *gorm.DB
+}
+
+func NewRuleDB(db *gorm.DB) *RuleDB {
+	return &RuleDB{
+		Conn: db.Table("rules"),
+	}
+}
+
+// Max number of days before the expiration date (10 years).
+const MaxDaysBefore = 3650
+
+type Rule struct {
+	ID            uuid.UUID                   `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID        uuid.UUID                   `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	DocumentID    uuid.UUID                   `gorm:"type:uuid;index;not null;" json:"document_id,omitempty"`
+	DaysBefore    int32                       `gorm:"not null;" json:"days_before"` // 0 is the expiry day
+	Notifications []notification.Notification `gorm:"foreignKey:RuleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"notifications,omitempty"`
+	CreatedAt     time.Time                   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt     time.Time                   `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+// Validate Rule object before inserting into database.
+func (r *Rule) Validate() error {
+	if r.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if r.DocumentID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "document_id is required")
+	}
+
+	if r.DaysBefore < 0 || r.DaysBefore > MaxDaysBefore {
+		return status.Error(codes.InvalidArgument, "days_before must be between 0 and 3650")
+	}
+
+	return nil
+}
+
+// Get the notification date of the rule for the document expiration date.
+func (r *Rule) NotificationDate(expiresAt time.Time) time.Time {
+	return expiresAt.AddDate(0, 0, -int(r.DaysBefore))
+}
+
+func (r *Rule) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+
+	return r.Validate()
+}
+
+// Insert the rule with its notification for the user's document.
+func (db *RuleDB) InsertOne(ctx context.Context, r *Rule) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var expiresAt time.Time
+
+		res := tx.
+			Table("documents").
+			Select("expires_at").
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", r.DocumentID, r.UserID).
+			Limit(1).
+			Scan(&expiresAt)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "document not found")
+		}
+
+		var count int64
+
+		res = tx.
+			Table("rules").
+			Where(&Rule{DocumentID: r.DocumentID}).
+			Where("days_before = ?", r.DaysBefore).
+			Count(&count)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if count > 0 {
+			return status.Error(codes.AlreadyExists, "reminder rule already exists")
+		}
+
+		res = tx.Table("rules").Create(r)
+		if res.Error != nil {
+			if _, ok := status.FromError(res.Error); ok {
+				return res.Error
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.Table("notifications").Create(&notification.Notification{
+			UserID:     r.UserID,
+			DocumentID: r.DocumentID,
+			RuleID:     &r.ID,
+			Date:       r.NotificationDate(expiresAt),
+		})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Delete the rule of the user's document with its notification.
+func (db *RuleDB) DeleteOne(ctx context.Context, r *Rule) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("rules").
+			Where(&Rule{ID: r.ID, UserID: r.UserID, DocumentID: r.DocumentID}).
+			Delete(&Rule{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "reminder rule not found")
+		}
+
+		res = tx.
+			Table("notifications").
+			Unscoped().
+			Where(&notification.Notification{RuleID: &r.ID}).
+			Delete(&notification.Notification{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Find all rules of the user's document, the closest to the expiry first.
+func (db *RuleDB) FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]Rule, error) {
+	var rules = []Rule{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Rule{}).
+		Where(&Rule{UserID: userID, DocumentID: documentID}).
+		Order("days_before ASC").
+		Find(&rules)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return rules, nil
+}
+
+// Find rules of all user's documents, including the deleted ones.
+func (db *RuleDB) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Rule, error) {
+	var rules = []Rule{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Rule{}).
+		Where(&Rule{UserID: userID}).
+		Order("created_at ASC").
+		Find(&rules)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return rules, nil
+}
+
+// Recompute dates of the notifications created by the document's rules for the new expiration date.
+// Should be called in the transaction which changes the expiration date.
+// Notifications moved to the future are marked as not delivered, so they are sent again.
+func Recompute(tx *gorm.DB, documentID uuid.UUID, expiresAt time.Time) error {
+	var rules []Rule
+
+	res := tx.
+		Table("rules").
+		Where(&Rule{DocumentID: documentID}).
+		Find(&rules)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	now := time.Now()
+
+	for i := range rules {
+		date := rules[i].NotificationDate(expiresAt)
+
+		fields := map[string]interface{}{
+			"date":       date,
+			"updated_at": now,
+		}
+
+		if date.After(now) {
+			notification.ResetDelivery(fields)
+		}
+
+		res = tx.
+			Table("notifications").
+			Unscoped().
+			Where(&notification.Notification{RuleID: &rules[i].ID}).
+			Where("date <> ?", date).
+			UpdateColumns(fields)
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package rule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{
+			name:    "fail if userID is empty",
+			rule:    Rule{DocumentID: uuid.New(), DaysBefore: 30},
+			wantErr: true,
+		},
+		{
+			name:    "fail if documentID is empty",
+			rule:    Rule{UserID: uuid.New(), DaysBefore: 30},
+			wantErr: true,
+		},
+		{
+			name:    "fail if days before is negative",
+			rule:    Rule{UserID: uuid.New(), DocumentID: uuid.New(), DaysBefore: -1},
+			wantErr: true,
+		},
+		{
+			name:    "fail if days before is too large",
+			rule:    Rule{UserID: uuid.New(), DocumentID: uuid.New(), DaysBefore: MaxDaysBefore + 1},
+			wantErr: true,
+		},
+		{
+			name:    "should pass on expiry day",
+			rule:    Rule{UserID: uuid.New(), DocumentID: uuid.New(), DaysBefore: 0},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Rule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRule_NotificationDate(t *testing.T) {
+	expiresAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		daysBefore int32
+		want       time.Time
+	}{
+		{
+			name:       "on expiry day",
+			daysBefore: 0,
+			want:       expiresAt,
+		},
+		{
+			name:       "30 days before expiry",
+			daysBefore: 30,
+			want:       time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{DaysBefore: tt.daysBefore}
+			if got := r.NotificationDate(expiresAt); !got.Equal(tt.want) {
+				t.Errorf("Rule.NotificationDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
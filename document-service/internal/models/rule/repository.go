@@ -0,0 +1,14 @@
+package rule
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type RuleRepository interface {
+	InsertOne(ctx context.Context, r *Rule) error
+	DeleteOne(ctx context.Context, r *Rule) error
+	FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]Rule, error)
+	FindAllForUser(ctx context.Context, userID uuid.UUID) ([]Rule, error)
+}
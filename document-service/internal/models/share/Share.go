@@ -0,0 +1,201 @@
+// Package share contains the documents shared by their owner with the groups of users (households).
+//
+// Share gives the members of the group access to one document or to all documents of the owner.
+// Groups are managed by the user service, so the group IDs of the user are passed by the gateway.
+package share
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type ShareDB struct {
+	Conn *gorm.DB
+}
+
+func NewShareDB(db *gorm.DB) *ShareDB {
+	return &ShareDB{
+		Conn: db.Table("shares"),
+	}
+}
+
+type Share struct {
+	ID         uuid.UUID             `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID     uuid.UUID             `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"` // Owner of the documents
+	GroupID    uuid.UUID             `gorm:"type:uuid;index;not null;" json:"group_id,omitempty"`
+	DocumentID *uuid.UUID            `gorm:"type:uuid;index;" json:"document_id,omitempty"` // All owner's documents are shared if nil
+	Permission proto.SharePermission `gorm:"type:int;default:0;not null;" json:"permission"`
+	CreatedAt  time.Time             `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+// Access of the user to the shared document.
+type Access struct {
+	OwnerID    uuid.UUID
+	Permission proto.SharePermission
+}
+
+// Validate Share object before inserting into database.
+func (s *Share) Validate() error {
+	if s.UserID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if s.GroupID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "group_id is required")
+	}
+
+	if _, ok := proto.SharePermission_name[int32(s.Permission)]; !ok {
+		return status.Error(codes.InvalidArgument, "permission is invalid")
+	}
+
+	return nil
+}
+
+func (s *Share) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+
+	return s.Validate()
+}
+
+// Subquery of the shares which give the groups access to the rows with the owner and document columns.
+// Should be used in "EXISTS (?)" condition.
+func AccessQuery(db *gorm.DB, groupIDs []uuid.UUID, ownerColumn, documentColumn string) *gorm.DB {
+	return db.
+		Session(&gorm.Session{NewDB: true}).
+		Table("shares").
+		Select("1").
+		Where("shares.user_id = "+ownerColumn).
+		Where("shares.group_id IN ?", groupIDs).
+		Where("(shares.document_id IS NULL OR shares.document_id = " + documentColumn + ")")
+}
+
+// Insert the share if the same documents are not shared with the group yet.
+func (db *ShareDB) InsertOne(ctx context.Context, s *Share) error {
+	return db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+
+		query := tx.
+			Table("shares").
+			Where(&Share{UserID: s.UserID, GroupID: s.GroupID})
+
+		if s.DocumentID == nil {
+			query = query.Where("document_id IS NULL")
+		} else {
+			query = query.Where("document_id = ?", *s.DocumentID)
+		}
+
+		res := query.Count(&count)
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if count > 0 {
+			return status.Error(codes.AlreadyExists, "documents are already shared with the group")
+		}
+
+		res = tx.Table("shares").Create(s)
+		if res.Error != nil {
+			if _, ok := status.FromError(res.Error); ok {
+				return res.Error
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Delete the share of the user.
+func (db *ShareDB) DeleteOne(ctx context.Context, s *Share) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Share{ID: s.ID, UserID: s.UserID}).
+		Delete(&Share{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "share not found")
+	}
+
+	return nil
+}
+
+// Find all shares created by the user.
+func (db *ShareDB) FindAll(ctx context.Context, userID uuid.UUID) ([]Share, error) {
+	var shares = []Share{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Share{}).
+		Where(&Share{UserID: userID}).
+		Order("created_at ASC").
+		Find(&shares)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return shares, nil
+}
+
+// Delete shares of the group, only the ones created by the user if it is set.
+func (db *ShareDB) DeleteForGroup(ctx context.Context, groupID, userID uuid.UUID) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Share{GroupID: groupID, UserID: userID}).
+		Delete(&Share{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Find the owner of the document shared with any of the groups and the highest permission of the shares.
+func (db *ShareDB) FindAccess(ctx context.Context, documentID uuid.UUID, groupIDs []uuid.UUID) (*Access, error) {
+	if len(groupIDs) == 0 {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+
+	var access Access
+
+	res := db.Conn.
+		WithContext(ctx).
+		Session(&gorm.Session{NewDB: true}).
+		Table("documents").
+		Select("documents.user_id AS owner_id, MAX(shares.permission) AS permission").
+		Joins("JOIN shares ON shares.user_id = documents.user_id AND (shares.document_id IS NULL OR shares.document_id = documents.id)").
+		Where("documents.id = ? AND documents.deleted_at IS NULL", documentID).
+		Where("shares.group_id IN ?", groupIDs).
+		Group("documents.user_id").
+		Scan(&access)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+
+	return &access, nil
+}
@@ -0,0 +1,51 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	proto "github.com/samgozman/validity.red/document/proto"
+)
+
+func TestShare_Validate(t *testing.T) {
+	documentID := uuid.New()
+
+	tests := []struct {
+		name    string
+		share   Share
+		wantErr bool
+	}{
+		{
+			name:    "fail if userID is empty",
+			share:   Share{GroupID: uuid.New()},
+			wantErr: true,
+		},
+		{
+			name:    "fail if groupID is empty",
+			share:   Share{UserID: uuid.New()},
+			wantErr: true,
+		},
+		{
+			name:    "fail if permission is unknown",
+			share:   Share{UserID: uuid.New(), GroupID: uuid.New(), Permission: proto.SharePermission(100)},
+			wantErr: true,
+		},
+		{
+			name:    "should pass for all documents",
+			share:   Share{UserID: uuid.New(), GroupID: uuid.New(), Permission: proto.SharePermission_READ},
+			wantErr: false,
+		},
+		{
+			name:    "should pass for one document",
+			share:   Share{UserID: uuid.New(), GroupID: uuid.New(), DocumentID: &documentID, Permission: proto.SharePermission_EDIT},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.share.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Share.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
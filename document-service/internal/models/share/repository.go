@@ -0,0 +1,15 @@
+package share
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ShareRepository interface {
+	InsertOne(ctx context.Context, s *Share) error
+	DeleteOne(ctx context.Context, s *Share) error
+	FindAll(ctx context.Context, userID uuid.UUID) ([]Share, error)
+	DeleteForGroup(ctx context.Context, groupID, userID uuid.UUID) error
+	FindAccess(ctx context.Context, documentID uuid.UUID, groupIDs []uuid.UUID) (*Access, error)
+}
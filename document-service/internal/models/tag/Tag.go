@@ -0,0 +1,226 @@
+// Package tag contains the free-form labels of the documents defined by the user.
+package tag
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/internal/models/encryptedname"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type TagDB struct {
+	Conn *gorm.DB
+}
+
+func NewTagDB(db *gorm.DB) *TagDB {
+	return &TagDB{
+		Conn: db.Table("tags"),
+	}
+}
+
+const MaxNameLength = 30
+
+type Tag struct {
+	ID        uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id,omitempty"`
+	Name      string    `gorm:"not null;" json:"name,omitempty"` // Encrypted with the owner's data key
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	// Assignments are deleted with the tag
+	Documents []DocumentTag `gorm:"foreignKey:TagID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"-"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// DocumentTag is the assignment of the tag to the document.
+type DocumentTag struct {
+	DocumentID uuid.UUID `gorm:"primarykey;type:uuid;not null;" json:"document_id,omitempty"`
+	TagID      uuid.UUID `gorm:"primarykey;type:uuid;index;not null;" json:"tag_id,omitempty"`
+}
+
+func (DocumentTag) TableName() string {
+	return "document_tags"
+}
+
+// Validate Tag object before inserting into database.
+func (t *Tag) Validate() error {
+	return encryptedname.Validate(t.UserID, t.Name, MaxNameLength)
+}
+
+// Encrypt the name with the owner's data key, it is bound to the tag ID.
+func (t *Tag) Encrypt(ctx context.Context) error {
+	name, err := encryptedname.Encrypt(ctx, t.UserID, t.ID, t.Name)
+	if err != nil {
+		return err
+	}
+
+	t.Name = name
+
+	return nil
+}
+
+// Decrypt the name with the owner's data key.
+func (t *Tag) Decrypt(ctx context.Context) error {
+	name, err := encryptedname.Decrypt(ctx, t.UserID, t.ID, t.Name)
+	if err != nil {
+		return err
+	}
+
+	t.Name = name
+
+	return nil
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+
+	err := t.Validate()
+	if err != nil {
+		return err
+	}
+
+	return t.Encrypt(datakey.HookContext(tx))
+}
+
+func (t *Tag) BeforeUpdate(tx *gorm.DB) error {
+	err := t.Validate()
+	if err != nil {
+		return err
+	}
+
+	return t.Encrypt(datakey.HookContext(tx))
+}
+
+func (t *Tag) AfterFind(tx *gorm.DB) error {
+	return t.Decrypt(datakey.HookContext(tx))
+}
+
+// Insert one Tag object into database.
+func (db *TagDB) InsertOne(ctx context.Context, t *Tag) error {
+	res := db.Conn.WithContext(ctx).Create(&t)
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Rename the user's tag.
+func (db *TagDB) UpdateOne(ctx context.Context, t *Tag) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Tag{ID: t.ID, UserID: t.UserID}).
+		Updates(&Tag{
+			ID:     t.ID,     // Used by BeforeUpdate hook to encrypt the name
+			UserID: t.UserID, // Used by BeforeUpdate hook to get the data key
+			Name:   t.Name,
+		})
+
+	if res.Error != nil {
+		if _, ok := status.FromError(res.Error); ok {
+			return res.Error
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "tag not found")
+	}
+
+	return nil
+}
+
+// Delete the user's tag, it is removed from the documents by the foreign key.
+func (db *TagDB) DeleteOne(ctx context.Context, t *Tag) error {
+	res := db.Conn.
+		WithContext(ctx).
+		Where(&Tag{ID: t.ID, UserID: t.UserID}).
+		Delete(&Tag{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "tag not found")
+	}
+
+	return nil
+}
+
+// Find all tags of the user, oldest first.
+func (db *TagDB) FindAll(ctx context.Context, userID uuid.UUID) ([]Tag, error) {
+	var tags = []Tag{}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Tag{}).
+		Where(&Tag{UserID: userID}).
+		Order("created_at ASC").
+		Find(&tags)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return tags, nil
+}
+
+// Checks if all tags belong to the user, IDs should be unique.
+func (db *TagDB) ExistAll(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (bool, error) {
+	var count int64
+
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Tag{}).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return false, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count == int64(len(ids)), nil
+}
+
+// Count tags of the user.
+func (db *TagDB) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+
+	res := db.Conn.
+		WithContext(ctx).
+		Model(&Tag{}).
+		Where(&Tag{UserID: userID}).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
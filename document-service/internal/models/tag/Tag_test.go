@@ -0,0 +1,20 @@
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTag_Validate(t *testing.T) {
+	userID := uuid.New()
+
+	if err := (&Tag{UserID: userID, Name: strings.Repeat("a", MaxNameLength)}).Validate(); err != nil {
+		t.Errorf("Tag.Validate() error = %v, want name of %d characters to pass", err, MaxNameLength)
+	}
+
+	if err := (&Tag{UserID: userID, Name: strings.Repeat("a", MaxNameLength+1)}).Validate(); err == nil {
+		t.Errorf("Tag.Validate() want error for name longer than %d characters", MaxNameLength)
+	}
+}
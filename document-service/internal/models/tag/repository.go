@@ -0,0 +1,16 @@
+package tag
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type TagRepository interface {
+	InsertOne(ctx context.Context, t *Tag) error
+	UpdateOne(ctx context.Context, t *Tag) error
+	DeleteOne(ctx context.Context, t *Tag) error
+	FindAll(ctx context.Context, userID uuid.UUID) ([]Tag, error)
+	ExistAll(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (bool, error)
+	Count(ctx context.Context, userID uuid.UUID) (int64, error)
+}
@@ -0,0 +1,76 @@
+// Package rekey is used to migrate documents encrypted with the legacy keys to the owners' data keys,
+// to build the search index of documents without one,
+// and to re-wrap data keys after the master key rotation.
+//
+// Migrated documents are marked as encrypted with the data key and have no IVs, so the migration
+// can be interrupted at any moment and will continue from the not yet migrated documents on the next run.
+package rekey
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+)
+
+type Migrator struct {
+	Documents document.DocumentRepository
+	DataKeys  datakey.DataKeyRepository
+	BatchSize int // Max number of documents or keys loaded at once
+}
+
+// Run re-wraps data keys with the current master key, then re-encrypts all documents
+// encrypted with the legacy keys or with AES-CBC and returns the number of migrated documents.
+//
+// Documents that failed to be migrated are skipped and will be retried on the next run.
+func (m *Migrator) Run(ctx context.Context) (int, error) {
+	rewrapped, err := m.DataKeys.Rewrap(ctx, m.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if rewrapped > 0 {
+		log.Printf("Re-wrapped %d data keys with the current master key", rewrapped)
+	}
+
+	afterID := uuid.Nil
+
+	var migrated int
+
+	for {
+		// Documents are decrypted with their old keys by the AfterFind hook
+		documents, err := m.Documents.FindForReencryption(ctx, afterID, m.BatchSize)
+		if err != nil {
+			return migrated, err
+		}
+
+		if len(documents) == 0 {
+			return migrated, nil
+		}
+
+		for i := range documents {
+			d := &documents[i]
+			afterID = d.ID
+
+			err := d.Encrypt(ctx)
+			if err != nil {
+				sentry.CaptureException(fmt.Errorf("error re-encrypting document '%s': %w", d.ID, err))
+				continue
+			}
+
+			err = m.Documents.UpdateEncryption(ctx, d)
+			if err != nil {
+				sentry.CaptureException(fmt.Errorf("error saving re-encrypted document '%s': %w", d.ID, err))
+				continue
+			}
+
+			migrated++
+		}
+
+		log.Printf("Re-encrypted %d documents with the data keys", migrated)
+	}
+}
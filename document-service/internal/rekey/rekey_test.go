@@ -0,0 +1,136 @@
+package rekey
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	datakeymocks "github.com/samgozman/validity.red/document/mocks/models/datakey"
+	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"github.com/samgozman/validity.red/document/pkg/keyring"
+)
+
+// In-memory documents repository which stores documents encrypted.
+type fakeRepository struct {
+	document.DocumentRepository
+	rows    []document.Document
+	queries int
+}
+
+func (r *fakeRepository) FindForReencryption(ctx context.Context, afterID uuid.UUID, limit int) ([]document.Document, error) {
+	r.queries++
+
+	sort.Slice(r.rows, func(i, j int) bool {
+		return bytes.Compare(r.rows[i].ID[:], r.rows[j].ID[:]) < 0
+	})
+
+	var found []document.Document
+
+	for _, d := range r.rows {
+		migrated := d.UserKey && d.IVTitle == nil && d.SearchIndex != "" && d.IVDescription == nil
+		if migrated || bytes.Compare(d.ID[:], afterID[:]) <= 0 {
+			continue
+		}
+
+		// Same as the AfterFind hook
+		if err := d.Decrypt(ctx); err != nil {
+			return nil, err
+		}
+
+		found = append(found, d)
+
+		if len(found) == limit {
+			break
+		}
+	}
+
+	return found, nil
+}
+
+func (r *fakeRepository) UpdateEncryption(ctx context.Context, d *document.Document) error {
+	for i := range r.rows {
+		if r.rows[i].ID == d.ID && (!r.rows[i].UserKey || r.rows[i].SearchIndex == "") {
+			r.rows[i] = *d
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func TestMigrator_Run(t *testing.T) {
+	const key1 = "8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q"
+	const key2 = "f149VI7P9EsUkirKOnGNy9YKQtbZKEAj"
+
+	ctx := context.Background()
+	document.Keys, _ = keyring.Parse(key1, "2:"+key2, "")
+	document.DataKeys = datakeymocks.NewDataKeyDBTest()
+	repo := &fakeRepository{}
+
+	// Documents encrypted with the legacy keys of both versions
+	for i := 0; i < 5; i++ {
+		d := document.Document{ID: uuid.New(), UserID: uuid.New(), KeyVersion: 1 + i%2}
+		key, _ := document.Keys.Key(d.KeyVersion)
+		d.Title, _ = encryption.EncryptGCM(key, "title", d.ID[:])
+		d.Description, _ = encryption.EncryptGCM(key, "description", d.ID[:])
+
+		repo.rows = append(repo.rows, d)
+	}
+
+	// Legacy AES-CBC document
+	iv := []byte("M7Z4es7yWRcduU3m")
+	title, _ := encryption.EncryptAES([]byte(key1), iv, "title")
+	description, _ := encryption.EncryptAES([]byte(key1), iv, "description")
+	repo.rows = append(repo.rows, document.Document{
+		ID:            uuid.New(),
+		UserID:        uuid.New(),
+		Title:         title,
+		Description:   description,
+		IVTitle:       iv,
+		IVDescription: iv,
+		KeyVersion:    keyring.LegacyVersion,
+	})
+
+	// Already migrated document
+	migratedDoc := document.Document{ID: uuid.New(), UserID: uuid.New(), Title: "title", Description: "description", ExpiresAt: time.Now()}
+	if err := migratedDoc.Encrypt(ctx); err != nil {
+		t.Fatalf("Document.Encrypt() error = %v", err)
+	}
+
+	repo.rows = append(repo.rows, migratedDoc)
+
+	m := &Migrator{Documents: repo, DataKeys: document.DataKeys, BatchSize: 2}
+
+	migrated, err := m.Run(ctx)
+	if err != nil || migrated != 6 {
+		t.Fatalf("Migrator.Run() = %d, %v, want 6, nil", migrated, err)
+	}
+
+	// 3 batches and one empty query
+	if repo.queries != 4 {
+		t.Errorf("Migrator.Run() made %d queries, want 4", repo.queries)
+	}
+
+	// Legacy keys are not needed anymore
+	document.Keys, _ = keyring.Parse("", "3:"+key2, "")
+
+	for _, d := range repo.rows {
+		if !d.UserKey || !encryption.IsGCM(d.Title) || d.IVTitle != nil || d.SearchIndex == "" {
+			t.Errorf("Migrator.Run() document is not migrated to the data key and AES-GCM")
+		}
+
+		if err := d.Decrypt(ctx); err != nil || d.Title != "title" || d.Description != "description" {
+			t.Errorf("Migrator.Run() document is not decryptable with the data key: %v", err)
+		}
+	}
+
+	// Nothing to migrate on the next run
+	migrated, err = m.Run(ctx)
+	if err != nil || migrated != 0 {
+		t.Errorf("Migrator.Run() second run = %d, %v, want 0, nil", migrated, err)
+	}
+}
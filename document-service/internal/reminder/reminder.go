@@ -0,0 +1,114 @@
+// Package reminder is used to deliver notifications which date has passed.
+//
+// Dispatcher periodically scans the notifications table for due notifications,
+// hands them to the Sender and marks them as delivered, so nothing is sent twice
+// after the service restarts. Failed notifications are retried with exponential backoff
+// and given up after MaxAttempts, so they can't starve the newer ones.
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/validity.red/document/internal/clock"
+	"github.com/samgozman/validity.red/document/internal/models/notification"
+)
+
+// Sender is a delivery channel for the due notifications.
+type Sender interface {
+	Send(ctx context.Context, n *notification.Notification) error
+}
+
+type Dispatcher struct {
+	Notifications notification.NotificationRepository
+	Sender        Sender
+	Clock         clock.Clock
+	Interval      time.Duration // Time between two scans
+	BatchSize     int           // Max number of notifications handled per scan
+	MaxAttempts   int           // Delivery is given up after this number of failed attempts
+	RetryDelay    time.Duration // Delay after the first failed attempt, doubled for every next one
+}
+
+// Run dispatches due notifications every Interval until ctx is canceled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := d.Dispatch(ctx)
+		if err != nil {
+			log.Println("Error on dispatching notifications:", err)
+		} else if sent > 0 {
+			log.Printf("Dispatched %d notifications", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends one batch of due notifications and returns the number of delivered ones.
+//
+// Notifications that failed to be sent are retried after the backoff delay.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	due, err := d.Notifications.FindDue(ctx, d.Clock.Now(), d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+
+	for i := range due {
+		n := &due[i]
+
+		err := d.Sender.Send(ctx, n)
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("error sending notification '%s': %w", n.ID, err))
+
+			err = d.Notifications.SetFailedAttempt(ctx, d.failedAttempt(n))
+			if err != nil {
+				return sent, err
+			}
+
+			continue
+		}
+
+		err = d.Notifications.SetDelivered(ctx, n.ID, d.Clock.Now())
+		if err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Count the failed attempt and schedule the next one, or give up after MaxAttempts.
+func (d *Dispatcher) failedAttempt(n *notification.Notification) *notification.Notification {
+	now := d.Clock.Now()
+	n.Attempts++
+
+	if n.Attempts >= d.MaxAttempts {
+		n.FailedAt = &now
+		n.NextAttemptAt = nil
+
+		return n
+	}
+
+	delay := d.RetryDelay
+	for i := 1; i < n.Attempts; i++ {
+		delay *= 2
+	}
+
+	next := now.Add(delay)
+	n.NextAttemptAt = &next
+
+	return n
+}
@@ -0,0 +1,266 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/notification"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type fakeSender struct {
+	sent []uuid.UUID
+	err  error
+}
+
+func (s *fakeSender) Send(ctx context.Context, n *notification.Notification) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.sent = append(s.sent, n.ID)
+
+	return nil
+}
+
+// In-memory notifications repository, only due related methods are used by Dispatcher.
+type fakeRepository struct {
+	notification.NotificationRepository
+	rows []notification.Notification
+}
+
+func (r *fakeRepository) FindDue(ctx context.Context, before time.Time, limit int) ([]notification.Notification, error) {
+	var due []notification.Notification
+
+	for _, n := range r.rows {
+		waiting := n.NextAttemptAt != nil && n.NextAttemptAt.After(before)
+		if n.DeliveredAt == nil && n.FailedAt == nil && !waiting && !n.Date.After(before) {
+			due = append(due, n)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Date.Before(due[j].Date) })
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (r *fakeRepository) SetDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error {
+	for i := range r.rows {
+		if r.rows[i].ID == id {
+			r.rows[i].DeliveredAt = &deliveredAt
+			return nil
+		}
+	}
+
+	return errors.New("notification not found")
+}
+
+func (r *fakeRepository) SetFailedAttempt(ctx context.Context, n *notification.Notification) error {
+	for i := range r.rows {
+		if r.rows[i].ID == n.ID {
+			r.rows[i].Attempts = n.Attempts
+			r.rows[i].NextAttemptAt = n.NextAttemptAt
+			r.rows[i].FailedAt = n.FailedAt
+
+			return nil
+		}
+	}
+
+	return errors.New("notification not found")
+}
+
+// Sender failing only for the given notifications.
+type failingSender struct {
+	fakeSender
+	failFor map[uuid.UUID]bool
+}
+
+func (s *failingSender) Send(ctx context.Context, n *notification.Notification) error {
+	if s.failFor[n.ID] {
+		return errors.New("recipient is unreachable")
+	}
+
+	return s.fakeSender.Send(ctx, n)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	past := notification.Notification{ID: uuid.New(), Date: now.Add(-time.Hour)}
+	older := notification.Notification{ID: uuid.New(), Date: now.Add(-48 * time.Hour)}
+	future := notification.Notification{ID: uuid.New(), Date: now.Add(time.Hour)}
+
+	repo := &fakeRepository{rows: []notification.Notification{past, future, older}}
+	clock := &fakeClock{now: now}
+	sender := &fakeSender{}
+
+	d := &Dispatcher{
+		Notifications: repo,
+		Sender:        sender,
+		Clock:         clock,
+		BatchSize:     10,
+	}
+
+	sent, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+	}
+
+	if sent != 2 || len(sender.sent) != 2 {
+		t.Fatalf("Dispatcher.Dispatch() sent = %d, want 2", sent)
+	}
+
+	if sender.sent[0] != older.ID || sender.sent[1] != past.ID {
+		t.Errorf("Dispatcher.Dispatch() want oldest notifications to be sent first")
+	}
+
+	// Nothing should be sent twice
+	sent, err = d.Dispatch(context.Background())
+	if err != nil || sent != 0 {
+		t.Errorf("Dispatcher.Dispatch() second call sent = %d, err = %v, want 0", sent, err)
+	}
+
+	// Future notification becomes due
+	clock.now = now.Add(2 * time.Hour)
+
+	sent, err = d.Dispatch(context.Background())
+	if err != nil || sent != 1 || sender.sent[2] != future.ID {
+		t.Errorf("Dispatcher.Dispatch() want future notification to be sent after it is due")
+	}
+}
+
+func TestDispatcher_DispatchSenderError(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	n := notification.Notification{ID: uuid.New(), Date: now.Add(-time.Hour)}
+
+	repo := &fakeRepository{rows: []notification.Notification{n}}
+	sender := &fakeSender{err: errors.New("channel is down")}
+	clock := &fakeClock{now: now}
+
+	d := &Dispatcher{
+		Notifications: repo,
+		Sender:        sender,
+		Clock:         clock,
+		BatchSize:     10,
+		MaxAttempts:   5,
+		RetryDelay:    time.Minute,
+	}
+
+	sent, err := d.Dispatch(context.Background())
+	if err != nil || sent != 0 {
+		t.Fatalf("Dispatcher.Dispatch() sent = %d, err = %v, want 0", sent, err)
+	}
+
+	if repo.rows[0].DeliveredAt != nil || repo.rows[0].Attempts != 1 {
+		t.Fatalf("Dispatcher.Dispatch() want failed notification to stay undelivered with 1 attempt")
+	}
+
+	// Retry after the channel is back, but not before the backoff delay
+	sender.err = nil
+
+	sent, err = d.Dispatch(context.Background())
+	if err != nil || sent != 0 {
+		t.Errorf("Dispatcher.Dispatch() retry before the delay sent = %d, err = %v, want 0", sent, err)
+	}
+
+	clock.now = now.Add(time.Minute)
+
+	sent, err = d.Dispatch(context.Background())
+	if err != nil || sent != 1 {
+		t.Errorf("Dispatcher.Dispatch() retry sent = %d, err = %v, want 1", sent, err)
+	}
+}
+
+func TestDispatcher_DispatchGivesUp(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	broken := notification.Notification{ID: uuid.New(), Date: now.Add(-48 * time.Hour)}
+	newer := notification.Notification{ID: uuid.New(), Date: now.Add(time.Hour)}
+
+	repo := &fakeRepository{rows: []notification.Notification{broken, newer}}
+	sender := &failingSender{failFor: map[uuid.UUID]bool{broken.ID: true}}
+	clock := &fakeClock{now: now}
+
+	d := &Dispatcher{
+		Notifications: repo,
+		Sender:        sender,
+		Clock:         clock,
+		BatchSize:     1,
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+	}
+
+	// Delays are 1 and 2 minutes, the third attempt is the last one
+	for _, after := range []time.Duration{0, time.Minute, 3 * time.Minute} {
+		clock.now = now.Add(after)
+
+		if _, err := d.Dispatch(context.Background()); err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+	}
+
+	if repo.rows[0].FailedAt == nil || repo.rows[0].Attempts != 3 {
+		t.Fatalf("Dispatcher.Dispatch() want notification to be given up after 3 attempts, got %d", repo.rows[0].Attempts)
+	}
+
+	// Given up notification does not take the batch of the newer one
+	clock.now = now.Add(2 * time.Hour)
+
+	sent, err := d.Dispatch(context.Background())
+	if err != nil || sent != 1 || sender.sent[0] != newer.ID {
+		t.Errorf("Dispatcher.Dispatch() sent = %d, err = %v, want the newer notification", sent, err)
+	}
+}
+
+func TestWebhookSender_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:    "should send notification",
+			status:  http.StatusNoContent,
+			wantErr: false,
+		},
+		{
+			name:    "should fail on error status",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("WebhookSender.Send() want JSON content type")
+				}
+
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			s := &WebhookSender{URL: server.URL, Client: server.Client()}
+
+			err := s.Send(context.Background(), &notification.Notification{ID: uuid.New()})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookSender.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/samgozman/validity.red/document/internal/models/notification"
+)
+
+// LogSender only writes due notifications to the log.
+// Used when no other delivery channel is configured.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, n *notification.Notification) error {
+	log.Printf("Notification '%s' for document '%s' is due", n.ID, n.DocumentID)
+	return nil
+}
+
+// WebhookSender posts due notifications as JSON to the given URL.
+type WebhookSender struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookSender) Send(ctx context.Context, n *notification.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package trash is used to permanently remove documents
+// which stayed in the trash longer than the retention period.
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/validity.red/document/internal/clock"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/pkg/storage"
+)
+
+type Purger struct {
+	Documents document.DocumentRepository
+	Storage   storage.Storage // Storage of the attachments content
+	Clock     clock.Clock
+	Retention time.Duration // How long deleted documents are kept in the trash
+	Interval  time.Duration // Time between two purges
+}
+
+// Run purges expired documents every Interval until ctx is canceled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Purge(ctx)
+		if err != nil {
+			log.Println("Error on purging deleted documents:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted documents", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes documents which were deleted more than Retention ago
+// together with their attachments content.
+func (p *Purger) Purge(ctx context.Context) (int64, error) {
+	purged, err := p.Documents.PurgeDeleted(ctx, p.Clock.Now().Add(-p.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, d := range purged {
+		err := p.Storage.DeleteAll(ctx, attachment.DocumentStorageKey(d.UserID, d.ID))
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("error deleting attachments of document '%s': %w", d.ID, err))
+		}
+	}
+
+	return int64(len(purged)), nil
+}
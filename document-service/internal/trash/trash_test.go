@@ -0,0 +1,71 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/pkg/storage"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type fakeRepository struct {
+	document.DocumentRepository
+	before time.Time
+	purged []document.Document
+}
+
+func (r *fakeRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]document.Document, error) {
+	r.before = before
+	return r.purged, nil
+}
+
+func TestPurger_Purge(t *testing.T) {
+	now := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	purged := document.Document{ID: uuid.New(), UserID: uuid.New()}
+	kept := document.Document{ID: uuid.New(), UserID: purged.UserID}
+	repo := &fakeRepository{purged: []document.Document{purged}}
+	files := &storage.LocalStorage{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	purgedFile := (&attachment.Attachment{ID: uuid.New(), UserID: purged.UserID, DocumentID: purged.ID}).StorageKey()
+	keptFile := (&attachment.Attachment{ID: uuid.New(), UserID: kept.UserID, DocumentID: kept.ID}).StorageKey()
+	_ = files.Put(ctx, purgedFile, []byte("scan"))
+	_ = files.Put(ctx, keptFile, []byte("scan"))
+
+	p := &Purger{
+		Documents: repo,
+		Storage:   files,
+		Clock:     &fakeClock{now: now},
+		Retention: 14 * 24 * time.Hour,
+	}
+
+	count, err := p.Purge(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("Purger.Purge() = %d, %v, want 1, nil", count, err)
+	}
+
+	want := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !repo.before.Equal(want) {
+		t.Errorf("Purger.Purge() purged documents deleted before %v, want %v", repo.before, want)
+	}
+
+	if _, err := files.Get(ctx, purgedFile); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Purger.Purge() attachment of the purged document is not deleted: %v", err)
+	}
+
+	if _, err := files.Get(ctx, keptFile); err != nil {
+		t.Errorf("Purger.Purge() attachment of another document is deleted: %v", err)
+	}
+}
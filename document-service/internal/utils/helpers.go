@@ -1,8 +1,16 @@
 package utils
 
 import (
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"github.com/samgozman/validity.red/document/internal/models/customtype"
 	"github.com/samgozman/validity.red/document/internal/models/document"
 	"github.com/samgozman/validity.red/document/internal/models/notification"
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	"github.com/samgozman/validity.red/document/internal/models/preset"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	"github.com/samgozman/validity.red/document/internal/models/share"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
 	proto "github.com/samgozman/validity.red/document/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -11,11 +19,17 @@ func ConvertNotificationsToProtoFormat(n *[]notification.Notification) []*proto.
 	var result = []*proto.Notification{}
 
 	for _, n := range *n {
-		result = append(result, &proto.Notification{
+		pn := &proto.Notification{
 			ID:         n.ID.String(),
 			DocumentID: n.DocumentID.String(),
 			Date:       timestamppb.New(n.Date),
-		})
+		}
+
+		if n.RuleID != nil {
+			pn.RuleID = n.RuleID.String()
+		}
+
+		result = append(result, pn)
 	}
 
 	return result
@@ -24,16 +38,202 @@ func ConvertNotificationsToProtoFormat(n *[]notification.Notification) []*proto.
 func ConvertDocumentsToProtoFormat(d *[]document.Document) []*proto.Document {
 	var result = []*proto.Document{}
 
-	for _, d := range *d {
-		result = append(result, &proto.Document{
-			ID:          d.ID.String(),
-			UserID:      d.UserID.String(),
-			Title:       d.Title,
-			Type:        *d.Type,
-			Description: d.Description,
-			ExpiresAt:   timestamppb.New(d.ExpiresAt),
-		})
+	for i := range *d {
+		result = append(result, ConvertDocumentToProtoFormat(&(*d)[i]))
+	}
+
+	return result
+}
+
+func ConvertDocumentToProtoFormat(d *document.Document) *proto.Document {
+	pd := &proto.Document{
+		ID:          d.ID.String(),
+		UserID:      d.UserID.String(),
+		Title:       d.Title,
+		Type:        *d.Type,
+		Description: d.Description,
+		ExpiresAt:   timestamppb.New(d.ExpiresAt),
+	}
+
+	if d.RenewalMonths != nil {
+		pd.RenewalMonths = *d.RenewalMonths
+	}
+
+	if d.PersonID != nil {
+		pd.PersonID = d.PersonID.String()
+	}
+
+	if d.Person != nil {
+		pd.PersonName = d.Person.Name
+	}
+
+	if d.CustomTypeID != nil {
+		pd.CustomTypeID = d.CustomTypeID.String()
+	}
+
+	if d.CustomType != nil {
+		pd.CustomTypeName = d.CustomType.Name
+	}
+
+	for _, t := range d.Tags {
+		pd.TagIDs = append(pd.TagIDs, t.TagID.String())
+	}
+
+	if d.DeletedAt.Valid {
+		pd.DeletedAt = timestamppb.New(d.DeletedAt.Time)
+	}
+
+	return pd
+}
+
+func ConvertAttachmentsToProtoFormat(a *[]attachment.Attachment) []*proto.Attachment {
+	var result = []*proto.Attachment{}
+
+	for i := range *a {
+		result = append(result, ConvertAttachmentToProtoFormat(&(*a)[i]))
+	}
+
+	return result
+}
+
+func ConvertAttachmentToProtoFormat(a *attachment.Attachment) *proto.Attachment {
+	return &proto.Attachment{
+		ID:         a.ID.String(),
+		DocumentID: a.DocumentID.String(),
+		FileName:   a.FileName,
+		MimeType:   a.MimeType,
+		Size:       a.Size,
+		CreatedAt:  timestamppb.New(a.CreatedAt),
+	}
+}
+
+func ConvertRenewalsToProtoFormat(r *[]renewal.Renewal) []*proto.Renewal {
+	var result = []*proto.Renewal{}
+
+	for i := range *r {
+		result = append(result, ConvertRenewalToProtoFormat(&(*r)[i]))
+	}
+
+	return result
+}
+
+func ConvertRenewalToProtoFormat(r *renewal.Renewal) *proto.Renewal {
+	return &proto.Renewal{
+		ID:                r.ID.String(),
+		DocumentID:        r.DocumentID.String(),
+		PreviousExpiresAt: timestamppb.New(r.PreviousExpiresAt),
+		ExpiresAt:         timestamppb.New(r.ExpiresAt),
+		CreatedAt:         timestamppb.New(r.CreatedAt),
+	}
+}
+
+func ConvertRulesToProtoFormat(r *[]rule.Rule) []*proto.ReminderRule {
+	var result = []*proto.ReminderRule{}
+
+	for i := range *r {
+		result = append(result, ConvertRuleToProtoFormat(&(*r)[i]))
 	}
 
 	return result
 }
+
+func ConvertRuleToProtoFormat(r *rule.Rule) *proto.ReminderRule {
+	return &proto.ReminderRule{
+		ID:         r.ID.String(),
+		DocumentID: r.DocumentID.String(),
+		DaysBefore: r.DaysBefore,
+	}
+}
+
+func ConvertPresetsToProtoFormat(p *[]preset.Preset) []*proto.ReminderPreset {
+	var result = []*proto.ReminderPreset{}
+
+	for i := range *p {
+		result = append(result, ConvertPresetToProtoFormat(&(*p)[i]))
+	}
+
+	return result
+}
+
+func ConvertPresetToProtoFormat(p *preset.Preset) *proto.ReminderPreset {
+	return &proto.ReminderPreset{
+		ID:         p.ID.String(),
+		Type:       p.Type,
+		DaysBefore: p.DaysBefore,
+	}
+}
+
+func ConvertSharesToProtoFormat(s *[]share.Share) []*proto.Share {
+	var result = []*proto.Share{}
+
+	for i := range *s {
+		result = append(result, ConvertShareToProtoFormat(&(*s)[i]))
+	}
+
+	return result
+}
+
+func ConvertShareToProtoFormat(s *share.Share) *proto.Share {
+	res := &proto.Share{
+		ID:         s.ID.String(),
+		GroupID:    s.GroupID.String(),
+		Permission: s.Permission,
+	}
+
+	if s.DocumentID != nil {
+		res.DocumentID = s.DocumentID.String()
+	}
+
+	return res
+}
+
+func ConvertPersonsToProtoFormat(p *[]person.Person) []*proto.Person {
+	var result = []*proto.Person{}
+
+	for i := range *p {
+		result = append(result, ConvertPersonToProtoFormat(&(*p)[i]))
+	}
+
+	return result
+}
+
+func ConvertPersonToProtoFormat(p *person.Person) *proto.Person {
+	return &proto.Person{
+		ID:   p.ID.String(),
+		Name: p.Name,
+	}
+}
+
+func ConvertCustomTypesToProtoFormat(c *[]customtype.CustomType) []*proto.CustomType {
+	var result = []*proto.CustomType{}
+
+	for i := range *c {
+		result = append(result, ConvertCustomTypeToProtoFormat(&(*c)[i]))
+	}
+
+	return result
+}
+
+func ConvertCustomTypeToProtoFormat(c *customtype.CustomType) *proto.CustomType {
+	return &proto.CustomType{
+		ID:   c.ID.String(),
+		Name: c.Name,
+	}
+}
+
+func ConvertTagsToProtoFormat(t *[]tag.Tag) []*proto.Tag {
+	var result = []*proto.Tag{}
+
+	for i := range *t {
+		result = append(result, ConvertTagToProtoFormat(&(*t)[i]))
+	}
+
+	return result
+}
+
+func ConvertTagToProtoFormat(t *tag.Tag) *proto.Tag {
+	return &proto.Tag{
+		ID:   t.ID.String(),
+		Name: t.Name,
+	}
+}
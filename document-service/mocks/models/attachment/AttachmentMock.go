@@ -0,0 +1,48 @@
+package attachmentmocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/attachment"
+	"gorm.io/gorm"
+)
+
+type AttachmentDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewAttachmentDBTest(db *gorm.DB) *AttachmentDBTest {
+	return &AttachmentDBTest{
+		Conn: db,
+	}
+}
+
+func (db *AttachmentDBTest) InsertOne(ctx context.Context, a *attachment.Attachment) error {
+	return nil
+}
+
+func (db *AttachmentDBTest) DeleteOne(ctx context.Context, a *attachment.Attachment) error {
+	return nil
+}
+
+func (db *AttachmentDBTest) FindOne(ctx context.Context, a *attachment.Attachment) error {
+	a.FileName = "scan.png"
+	a.MimeType = "image/png"
+
+	return nil
+}
+
+func (db *AttachmentDBTest) FindAll(ctx context.Context, documentID uuid.UUID) ([]attachment.Attachment, error) {
+	var attachments []attachment.Attachment
+	return attachments, nil
+}
+
+func (db *AttachmentDBTest) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]attachment.Attachment, error) {
+	var attachments []attachment.Attachment
+	return attachments, nil
+}
+
+func (db *AttachmentDBTest) Count(ctx context.Context, documentID uuid.UUID) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,49 @@
+package customtypemocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/customtype"
+	"gorm.io/gorm"
+)
+
+// CustomType of the test user.
+const TestCustomTypeID = "9a8b7c6d-5e4f-4a3b-9c2d-1e0f2a3b4c5d"
+
+type CustomTypeDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewCustomTypeDBTest(db *gorm.DB) *CustomTypeDBTest {
+	return &CustomTypeDBTest{
+		Conn: db,
+	}
+}
+
+func (db *CustomTypeDBTest) InsertOne(ctx context.Context, c *customtype.CustomType) error {
+	c.ID = uuid.New()
+	return c.Validate()
+}
+
+func (db *CustomTypeDBTest) UpdateOne(ctx context.Context, c *customtype.CustomType) error {
+	return c.Validate()
+}
+
+func (db *CustomTypeDBTest) DeleteOne(ctx context.Context, c *customtype.CustomType) error {
+	return nil
+}
+
+func (db *CustomTypeDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]customtype.CustomType, error) {
+	return []customtype.CustomType{
+		{ID: uuid.MustParse(TestCustomTypeID), UserID: userID, Name: "Gym membership"},
+	}, nil
+}
+
+func (db *CustomTypeDBTest) Exists(ctx context.Context, c *customtype.CustomType) (bool, error) {
+	return c.ID.String() == TestCustomTypeID, nil
+}
+
+func (db *CustomTypeDBTest) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
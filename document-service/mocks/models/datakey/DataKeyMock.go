@@ -0,0 +1,72 @@
+package datakeymocks
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/datakey"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// In-memory data keys, key of every user is filled with the first byte of the user ID.
+type DataKeyDBTest struct {
+	mu        sync.Mutex
+	keys      map[uuid.UUID][]byte
+	destroyed map[uuid.UUID]bool
+}
+
+func NewDataKeyDBTest() *DataKeyDBTest {
+	return &DataKeyDBTest{
+		keys:      make(map[uuid.UUID][]byte),
+		destroyed: make(map[uuid.UUID]bool),
+	}
+}
+
+func (db *DataKeyDBTest) Create(ctx context.Context, userID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.keys[userID]; !ok && !db.destroyed[userID] {
+		db.keys[userID] = bytes.Repeat(userID[:1], datakey.KeySize)
+	}
+
+	return nil
+}
+
+func (db *DataKeyDBTest) Get(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key, ok := db.keys[userID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "data key not found")
+	}
+
+	return key, nil
+}
+
+func (db *DataKeyDBTest) GetOrCreate(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	err := db.Create(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Get(ctx, userID)
+}
+
+func (db *DataKeyDBTest) Destroy(ctx context.Context, userID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.keys, userID)
+	db.destroyed[userID] = true
+
+	return nil
+}
+
+func (db *DataKeyDBTest) Rewrap(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
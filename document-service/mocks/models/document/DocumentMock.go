@@ -2,20 +2,31 @@ package documentmocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/samgozman/validity.red/document/internal/models/document"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
+	customtypemocks "github.com/samgozman/validity.red/document/mocks/models/customtype"
+	tagmocks "github.com/samgozman/validity.red/document/mocks/models/tag"
 	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 )
 
+// Document with the renewal period kept by the mock between the calls.
+const TestRenewableDocumentID = "6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b"
+
 type DocumentDBTest struct {
-	Conn *gorm.DB
+	Conn          *gorm.DB
+	renewalMonths int32 // Renewal period of the TestRenewableDocumentID document
 }
 
 func NewDocumentDBTest(db *gorm.DB) *DocumentDBTest {
 	return &DocumentDBTest{
-		Conn: db,
+		Conn:          db,
+		renewalMonths: 12,
 	}
 }
 
@@ -23,7 +34,23 @@ func (db *DocumentDBTest) InsertOne(ctx context.Context, d *document.Document) e
 	return nil
 }
 
-func (db *DocumentDBTest) UpdateOne(ctx context.Context, d *document.Document) error {
+func (db *DocumentDBTest) InsertMany(ctx context.Context, documents []document.Document, limit int64) error {
+	if int64(len(documents)) > limit {
+		return document.ErrMaxDocumentsLimit
+	}
+
+	for i := range documents {
+		documents[i].ID = uuid.New()
+	}
+
+	return nil
+}
+
+func (db *DocumentDBTest) UpdateOne(ctx context.Context, d *document.Document, opts document.UpdateOptions) error {
+	if d.ID.String() == TestRenewableDocumentID && opts.RenewalMonths {
+		db.renewalMonths = *d.RenewalMonths
+	}
+
 	return nil
 }
 
@@ -31,8 +58,49 @@ func (db *DocumentDBTest) DeleteOne(ctx context.Context, d *document.Document) e
 	return nil
 }
 
+func (db *DocumentDBTest) Restore(ctx context.Context, d *document.Document) error {
+	return nil
+}
+
+func (db *DocumentDBTest) PurgeOne(ctx context.Context, d *document.Document) error {
+	return nil
+}
+
+func (db *DocumentDBTest) Renew(ctx context.Context, d *document.Document, expiresAt time.Time) (*renewal.Renewal, error) {
+	if d.ID.String() != "434377cf-7509-4cc0-9895-0afa683f0e56" {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+
+	previous := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if expiresAt.IsZero() {
+		expiresAt = previous.AddDate(1, 0, 0)
+	}
+
+	return &renewal.Renewal{
+		ID:                uuid.New(),
+		UserID:            d.UserID,
+		DocumentID:        d.ID,
+		PreviousExpiresAt: previous,
+		ExpiresAt:         expiresAt,
+	}, nil
+}
+
+func (db *DocumentDBTest) PurgeDeleted(ctx context.Context, before time.Time) ([]document.Document, error) {
+	return nil, nil
+}
+
+func (db *DocumentDBTest) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
 func (db *DocumentDBTest) FindOne(ctx context.Context, d *document.Document) error {
 	d.Type = proto.Type_DEFAULT_DOCUMENT.Enum()
+
+	if d.ID.String() == TestRenewableDocumentID {
+		months := db.renewalMonths
+		d.RenewalMonths = &months
+	}
+
 	return nil
 }
 
@@ -44,7 +112,25 @@ func (db *DocumentDBTest) Exists(ctx context.Context, d *document.Document) (boo
 	return true, nil
 }
 
-func (db *DocumentDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]document.Document, error) {
+func (db *DocumentDBTest) FindAll(ctx context.Context, userID uuid.UUID, opts *document.ListOptions) ([]document.Document, string, error) {
+	var documents []document.Document
+
+	return documents, "", nil
+}
+
+func (db *DocumentDBTest) FindShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]document.Document, error) {
+	var documents []document.Document
+
+	return documents, nil
+}
+
+func (db *DocumentDBTest) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]document.Document, error) {
+	var documents []document.Document
+
+	return documents, nil
+}
+
+func (db *DocumentDBTest) FindDeleted(ctx context.Context, userID uuid.UUID) ([]document.Document, error) {
 	var documents []document.Document
 
 	return documents, nil
@@ -54,12 +140,61 @@ func (db *DocumentDBTest) Count(ctx context.Context, userID uuid.UUID) (int64, e
 	return 0, nil
 }
 
-func (db *DocumentDBTest) CountTypes(ctx context.Context, userID uuid.UUID) ([]*proto.DocumentTypesCount, error) {
+func (db *DocumentDBTest) CountTypes(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTypesCount, error) {
 	var types []*proto.DocumentTypesCount
 	return types, nil
 }
 
-func (db *DocumentDBTest) FindLatest(ctx context.Context, userID uuid.UUID, limit int) ([]document.Document, error) {
+func (db *DocumentDBTest) CountCustomTypes(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+) ([]*proto.DocumentCustomTypesCount, error) {
+	return []*proto.DocumentCustomTypesCount{
+		{CustomTypeID: customtypemocks.TestCustomTypeID, Count: 2},
+	}, nil
+}
+
+func (db *DocumentDBTest) CountTags(ctx context.Context, userID, personID uuid.UUID) ([]*proto.DocumentTagsCount, error) {
+	return []*proto.DocumentTagsCount{
+		{TagID: tagmocks.TestTagID, Count: 3},
+	}, nil
+}
+
+func (db *DocumentDBTest) CountExpiry(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) (*proto.DocumentsExpiryCount, error) {
+	return &proto.DocumentsExpiryCount{}, nil
+}
+
+func (db *DocumentDBTest) CountExpiryTimeline(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) ([]*proto.ExpiryMonthCount, error) {
+	var timeline []*proto.ExpiryMonthCount
+	return timeline, nil
+}
+
+func (db *DocumentDBTest) CountNotifications(
+	ctx context.Context,
+	userID, personID uuid.UUID,
+	now time.Time,
+) (*proto.NotificationsStatistics, error) {
+	return &proto.NotificationsStatistics{}, nil
+}
+
+func (db *DocumentDBTest) FindLatest(ctx context.Context, userID, personID uuid.UUID, limit int) ([]document.Document, error) {
 	var documents []document.Document
 	return documents, nil
 }
+
+func (db *DocumentDBTest) FindForReencryption(ctx context.Context, afterID uuid.UUID, limit int) ([]document.Document, error) {
+	var documents []document.Document
+	return documents, nil
+}
+
+func (db *DocumentDBTest) UpdateEncryption(ctx context.Context, d *document.Document) error {
+	return nil
+}
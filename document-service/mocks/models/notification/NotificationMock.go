@@ -2,6 +2,7 @@ package notificationmocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/samgozman/validity.red/document/internal/models/notification"
@@ -43,3 +44,21 @@ func (db *NotificationDBTest) FindAllForUser(ctx context.Context, userID uuid.UU
 	var notifications []notification.Notification
 	return notifications, nil
 }
+
+func (db *NotificationDBTest) FindAllShared(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID) ([]notification.Notification, error) {
+	var notifications []notification.Notification
+	return notifications, nil
+}
+
+func (db *NotificationDBTest) FindDue(ctx context.Context, before time.Time, limit int) ([]notification.Notification, error) {
+	var notifications []notification.Notification
+	return notifications, nil
+}
+
+func (db *NotificationDBTest) SetDelivered(ctx context.Context, id uuid.UUID, deliveredAt time.Time) error {
+	return nil
+}
+
+func (db *NotificationDBTest) SetFailedAttempt(ctx context.Context, n *notification.Notification) error {
+	return nil
+}
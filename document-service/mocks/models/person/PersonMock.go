@@ -0,0 +1,48 @@
+package personmocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/person"
+	"gorm.io/gorm"
+)
+
+// Person of the test user.
+const TestPersonID = "c3a1d5e2-7b4f-4c8a-9d6e-1f2a3b4c5d6e"
+
+type PersonDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewPersonDBTest(db *gorm.DB) *PersonDBTest {
+	return &PersonDBTest{
+		Conn: db,
+	}
+}
+
+func (db *PersonDBTest) InsertOne(ctx context.Context, p *person.Person) error {
+	p.ID = uuid.New()
+	return p.Validate()
+}
+
+func (db *PersonDBTest) UpdateOne(ctx context.Context, p *person.Person) error {
+	return p.Validate()
+}
+
+func (db *PersonDBTest) DeleteOne(ctx context.Context, p *person.Person) error {
+	return nil
+}
+
+func (db *PersonDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]person.Person, error) {
+	var persons []person.Person
+	return persons, nil
+}
+
+func (db *PersonDBTest) Exists(ctx context.Context, p *person.Person) (bool, error) {
+	return p.ID.String() == TestPersonID, nil
+}
+
+func (db *PersonDBTest) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,52 @@
+package presetmocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/preset"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"gorm.io/gorm"
+)
+
+type PresetDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewPresetDBTest(db *gorm.DB) *PresetDBTest {
+	return &PresetDBTest{
+		Conn: db,
+	}
+}
+
+func (db *PresetDBTest) InsertOne(ctx context.Context, p *preset.Preset) error {
+	p.ID = uuid.New()
+	return p.Validate()
+}
+
+func (db *PresetDBTest) DeleteOne(ctx context.Context, p *preset.Preset) error {
+	return nil
+}
+
+func (db *PresetDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]preset.Preset, error) {
+	var presets []preset.Preset
+	return presets, nil
+}
+
+// Passports have 6 months and 1 month presets, other types have no presets.
+func (db *PresetDBTest) FindByType(ctx context.Context, userID uuid.UUID, t proto.Type) ([]preset.Preset, error) {
+	var presets []preset.Preset
+
+	if t == proto.Type_PASSPORT {
+		presets = append(presets,
+			preset.Preset{ID: uuid.New(), UserID: userID, Type: t, DaysBefore: 30},
+			preset.Preset{ID: uuid.New(), UserID: userID, Type: t, DaysBefore: 180},
+		)
+	}
+
+	return presets, nil
+}
+
+func (db *PresetDBTest) Count(ctx context.Context, userID uuid.UUID, t proto.Type) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,29 @@
+package renewalmocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/renewal"
+	"gorm.io/gorm"
+)
+
+type RenewalDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewRenewalDBTest(db *gorm.DB) *RenewalDBTest {
+	return &RenewalDBTest{
+		Conn: db,
+	}
+}
+
+func (db *RenewalDBTest) FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]renewal.Renewal, error) {
+	var renewals []renewal.Renewal
+	return renewals, nil
+}
+
+func (db *RenewalDBTest) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]renewal.Renewal, error) {
+	var renewals []renewal.Renewal
+	return renewals, nil
+}
@@ -0,0 +1,38 @@
+package rulemocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/rule"
+	"gorm.io/gorm"
+)
+
+type RuleDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewRuleDBTest(db *gorm.DB) *RuleDBTest {
+	return &RuleDBTest{
+		Conn: db,
+	}
+}
+
+func (db *RuleDBTest) InsertOne(ctx context.Context, r *rule.Rule) error {
+	r.ID = uuid.New()
+	return r.Validate()
+}
+
+func (db *RuleDBTest) DeleteOne(ctx context.Context, r *rule.Rule) error {
+	return nil
+}
+
+func (db *RuleDBTest) FindAll(ctx context.Context, userID, documentID uuid.UUID) ([]rule.Rule, error) {
+	var rules []rule.Rule
+	return rules, nil
+}
+
+func (db *RuleDBTest) FindAllForUser(ctx context.Context, userID uuid.UUID) ([]rule.Rule, error) {
+	var rules []rule.Rule
+	return rules, nil
+}
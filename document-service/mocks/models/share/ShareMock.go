@@ -0,0 +1,72 @@
+package sharemocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/share"
+	proto "github.com/samgozman/validity.red/document/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	// Document of another user shared with the test groups.
+	SharedDocumentID = "7d5c5a0e-3f7e-4a8b-9c1d-2e6f4b8a0c31"
+	SharedOwnerID    = "b8e1f2a3-4c5d-4e6f-8a9b-0c1d2e3f4a5b"
+	ReadGroupID      = "0f4e9c1a-2b3d-4c5e-8f6a-7b8c9d0e1f2a"
+	EditGroupID      = "5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d"
+)
+
+type ShareDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewShareDBTest(db *gorm.DB) *ShareDBTest {
+	return &ShareDBTest{
+		Conn: db,
+	}
+}
+
+func (db *ShareDBTest) InsertOne(ctx context.Context, s *share.Share) error {
+	s.ID = uuid.New()
+	return s.Validate()
+}
+
+func (db *ShareDBTest) DeleteOne(ctx context.Context, s *share.Share) error {
+	return nil
+}
+
+func (db *ShareDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]share.Share, error) {
+	var shares []share.Share
+	return shares, nil
+}
+
+func (db *ShareDBTest) DeleteForGroup(ctx context.Context, groupID, userID uuid.UUID) error {
+	return nil
+}
+
+// Shared document is readable by the read group and editable by the edit group.
+func (db *ShareDBTest) FindAccess(ctx context.Context, documentID uuid.UUID, groupIDs []uuid.UUID) (*share.Access, error) {
+	if documentID.String() != SharedDocumentID {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+
+	var access *share.Access
+
+	for _, groupID := range groupIDs {
+		switch groupID.String() {
+		case EditGroupID:
+			return &share.Access{OwnerID: uuid.MustParse(SharedOwnerID), Permission: proto.SharePermission_EDIT}, nil
+		case ReadGroupID:
+			access = &share.Access{OwnerID: uuid.MustParse(SharedOwnerID), Permission: proto.SharePermission_READ}
+		}
+	}
+
+	if access == nil {
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+
+	return access, nil
+}
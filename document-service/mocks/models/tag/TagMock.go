@@ -0,0 +1,55 @@
+package tagmocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/document/internal/models/tag"
+	"gorm.io/gorm"
+)
+
+// Tag of the test user.
+const TestTagID = "e4b7c1d9-2a6f-4e3b-8c5d-7f1a9b2c4d6e"
+
+type TagDBTest struct {
+	Conn *gorm.DB
+}
+
+func NewTagDBTest(db *gorm.DB) *TagDBTest {
+	return &TagDBTest{
+		Conn: db,
+	}
+}
+
+func (db *TagDBTest) InsertOne(ctx context.Context, t *tag.Tag) error {
+	t.ID = uuid.New()
+	return t.Validate()
+}
+
+func (db *TagDBTest) UpdateOne(ctx context.Context, t *tag.Tag) error {
+	return t.Validate()
+}
+
+func (db *TagDBTest) DeleteOne(ctx context.Context, t *tag.Tag) error {
+	return nil
+}
+
+func (db *TagDBTest) FindAll(ctx context.Context, userID uuid.UUID) ([]tag.Tag, error) {
+	return []tag.Tag{
+		{ID: uuid.MustParse(TestTagID), UserID: userID, Name: "Work"},
+	}, nil
+}
+
+func (db *TagDBTest) ExistAll(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (bool, error) {
+	for _, id := range ids {
+		if id.String() != TestTagID {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (db *TagDBTest) Count(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,103 @@
+// Package blindindex is used to search encrypted text without decrypting it.
+//
+// Text is split into normalized words and every word (with its prefixes) is turned into a token
+// with the keyed HMAC. Tokens are stored next to the encrypted text and the search query
+// is turned into tokens with the same key, so the database can match them without knowing the words.
+package blindindex
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Min length of the word prefix to be indexed, so "pass" matches "passport".
+const MinPrefixLength = 3
+
+// Length of the token in bytes. Tokens are truncated to leak less about the words,
+// so rare collisions are possible and matches should be verified after decryption.
+const tokenSize = 8
+
+// Split text into lowercase words of letters and digits.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Token of the normalized word.
+func Token(key []byte, word string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(word))
+
+	return hex.EncodeToString(mac.Sum(nil)[:tokenSize])
+}
+
+// Build the index of the texts: unique tokens of all words and their prefixes, separated by spaces.
+// Index starts and ends with a space, so a token can be matched with LIKE '% token %'.
+func Index(key []byte, texts ...string) string {
+	seen := map[string]bool{}
+
+	var sb strings.Builder
+
+	sb.WriteString(" ")
+
+	for _, text := range texts {
+		for _, word := range Words(text) {
+			runes := []rune(word)
+
+			for i := MinPrefixLength; i <= len(runes); i++ {
+				seen[string(runes[:i])] = true
+			}
+
+			// Short words are indexed as a whole
+			seen[word] = true
+		}
+	}
+
+	for word := range seen {
+		sb.WriteString(Token(key, word))
+		sb.WriteString(" ")
+	}
+
+	return sb.String()
+}
+
+// Tokens of the search query words.
+func QueryTokens(key []byte, query string) []string {
+	var tokens []string
+
+	for _, word := range Words(query) {
+		tokens = append(tokens, Token(key, word))
+	}
+
+	return tokens
+}
+
+// Check if every query word is a prefix of some word of the texts.
+// Used to filter out token collisions after decryption.
+func Match(query string, texts ...string) bool {
+	var words []string
+	for _, text := range texts {
+		words = append(words, Words(text)...)
+	}
+
+	for _, q := range Words(query) {
+		found := false
+
+		for _, w := range words {
+			if w == q || (len([]rune(q)) >= MinPrefixLength && strings.HasPrefix(w, q)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
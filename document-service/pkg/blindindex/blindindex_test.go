@@ -0,0 +1,55 @@
+package blindindex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var key = []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj")
+
+func TestWords(t *testing.T) {
+	got := Words("Passport, Über-Card #42!")
+	want := []string{"passport", "über", "card", "42"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %v, want %v", got, want)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	index := Index(key, "Passport RU", "Renew at the embassy")
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "passport", want: true},
+		{query: "PASS", want: true},
+		{query: "ru", want: true},
+		{query: "embassy renew", want: true},
+		{query: "pa", want: false},
+		{query: "visa", want: false},
+		{query: "sport", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := true
+			for _, token := range QueryTokens(key, tt.query) {
+				got = got && strings.Contains(index, " "+token+" ")
+			}
+
+			if got != tt.want {
+				t.Errorf("Index() matches %q = %v, want %v", tt.query, got, tt.want)
+			}
+
+			if Match(tt.query, "Passport RU", "Renew at the embassy") != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.query, !tt.want, tt.want)
+			}
+		})
+	}
+
+	if strings.Contains(index, Token([]byte("another key"), "passport")) {
+		t.Errorf("Index() tokens should depend on the key")
+	}
+}
@@ -7,17 +7,25 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"strings"
 
 	"github.com/getsentry/sentry-go"
 )
 
 var (
-	ErrWrongIVSize = errors.New("iv length must equal block size")
+	ErrWrongIVSize         = errors.New("iv length must equal block size")
+	ErrMalformedCipherText = errors.New("malformed cipher text")
 )
 
 const BlockSize = aes.BlockSize
 
-// Encrypt "text" string with AES
+// Prefix of the cipher text encrypted with EncryptGCM.
+// Cipher texts without prefix are legacy AES-CBC hex strings from EncryptAES.
+const GCMPrefix = "v2:"
+
+// Encrypt "text" string with AES-CBC
+//
+// Deprecated: AES-CBC is not authenticated, use EncryptGCM instead. Kept for legacy data.
 //
 // "key" - should be the AES key, either 16, 24, or 32 bytes to select AES-128, AES-192, or AES-256.
 //
@@ -103,3 +111,93 @@ func GenerateRandomIV(length uint) ([]byte, error) {
 
 	return b, nil
 }
+
+// Encrypt "text" string with authenticated AES-GCM.
+//
+// "key" - should be the AES key, either 16, 24, or 32 bytes to select AES-128, AES-192, or AES-256.
+//
+// "additionalData" - not encrypted data bound to the cipher text, e.g. the record ID.
+// The same data should be passed to DecryptGCM, so the cipher text can not be moved to another record.
+//
+// Returns the cipher text in "v2:<hex nonce + sealed text>" format.
+// Nonce is randomly generated for each encryption.
+func EncryptGCM(key []byte, text string, additionalData []byte) (string, error) {
+	sealed, err := SealGCM(key, []byte(text), additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return GCMPrefix + hex.EncodeToString(sealed), nil
+}
+
+// Decrypt "cipherText" string produced by EncryptGCM.
+// Fails if the cipher text or additional data was modified.
+func DecryptGCM(key []byte, cipherText string, additionalData []byte) (string, error) {
+	if !IsGCM(cipherText) {
+		return "", ErrMalformedCipherText
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(cipherText, GCMPrefix))
+	if err != nil {
+		return "", ErrMalformedCipherText
+	}
+
+	text, err := OpenGCM(key, data, additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return string(text), nil
+}
+
+// Encrypt binary data with authenticated AES-GCM, same as EncryptGCM.
+// Returns random nonce followed by the sealed data.
+func SealGCM(key []byte, data []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, additionalData), nil
+}
+
+// Decrypt binary data produced by SealGCM.
+// Fails if the data or additional data was modified.
+func OpenGCM(key []byte, data []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformedCipherText
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+// Check if the cipher text was produced by EncryptGCM.
+func IsGCM(cipherText string) bool {
+	return strings.HasPrefix(cipherText, GCMPrefix)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -1,6 +1,8 @@
 package encryption
 
 import (
+	"bytes"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -225,3 +227,117 @@ func TestGenerateRandomIV(t *testing.T) {
 		})
 	}
 }
+
+func TestEncryptGCM(t *testing.T) {
+	ad := []byte("434377cf-7509-4cc0-9895-0afa683f0e56")
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "should encrypt short text", text: "Test"},
+		{name: "should encrypt empty text", text: ""},
+		{name: "should encrypt text with newline symbol", text: "First \n Second \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cipherText, err := EncryptGCM(okKey, tt.text, ad)
+			if err != nil {
+				t.Fatalf("EncryptGCM() error = %v", err)
+			}
+
+			if !IsGCM(cipherText) {
+				t.Errorf("EncryptGCM() = %v, want %v prefix", cipherText, GCMPrefix)
+			}
+
+			got, err := DecryptGCM(okKey, cipherText, ad)
+			if err != nil || got != tt.text {
+				t.Errorf("DecryptGCM() = %v, %v, want %v", got, err, tt.text)
+			}
+		})
+	}
+}
+
+func TestDecryptGCM(t *testing.T) {
+	ad := []byte("434377cf-7509-4cc0-9895-0afa683f0e56")
+
+	cipherText, err := EncryptGCM(okKey, "Test", ad)
+	if err != nil {
+		t.Fatalf("EncryptGCM() error = %v", err)
+	}
+
+	// Flip the last hex symbol of the sealed text
+	last := cipherText[len(cipherText)-1]
+	flipped := byte('0')
+	if last == '0' {
+		flipped = '1'
+	}
+
+	tests := []struct {
+		name       string
+		key        []byte
+		cipherText string
+		ad         []byte
+	}{
+		{
+			name:       "should fail if cipher text was modified",
+			key:        okKey,
+			cipherText: cipherText[:len(cipherText)-1] + string(flipped),
+			ad:         ad,
+		},
+		{
+			name:       "should fail if additional data is different",
+			key:        okKey,
+			cipherText: cipherText,
+			ad:         []byte("c87fc6e0-8c7c-4b1c-9f4b-1b8d4b9b8c1a"),
+		},
+		{
+			name:       "should fail with wrong key",
+			key:        []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj"),
+			cipherText: cipherText,
+			ad:         ad,
+		},
+		{
+			name:       "should fail on legacy cipher text",
+			key:        okKey,
+			cipherText: "6e99f097fb170326dbb136d2e518548c",
+			ad:         ad,
+		},
+		{
+			name:       "should fail on malformed cipher text",
+			key:        okKey,
+			cipherText: GCMPrefix + "zz",
+			ad:         ad,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecryptGCM(tt.key, tt.cipherText, tt.ad); err == nil {
+				t.Errorf("DecryptGCM() want error")
+			}
+		})
+	}
+}
+
+func TestSealGCM(t *testing.T) {
+	ad := []byte("434377cf-7509-4cc0-9895-0afa683f0e56")
+	data := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+
+	sealed, err := SealGCM(okKey, data, ad)
+	if err != nil {
+		t.Fatalf("SealGCM() error = %v", err)
+	}
+
+	got, err := OpenGCM(okKey, sealed, ad)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("OpenGCM() = %v, %v, want %v", got, err, data)
+	}
+
+	if _, err := OpenGCM(okKey, sealed, []byte("another")); err == nil {
+		t.Errorf("OpenGCM() with other additional data want error")
+	}
+
+	if _, err := OpenGCM(okKey, sealed[:4], ad); !errors.Is(err, ErrMalformedCipherText) {
+		t.Errorf("OpenGCM() on short data error = %v, want %v", err, ErrMalformedCipherText)
+	}
+}
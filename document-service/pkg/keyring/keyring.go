@@ -0,0 +1,119 @@
+// Package keyring holds versioned encryption keys.
+//
+// New data is always encrypted with the current key, while all the previous keys
+// are kept to decrypt data which was not migrated to the current key yet.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LegacyVersion is the version of the single key used before key versioning.
+const LegacyVersion = 1
+
+var (
+	ErrNoKeys            = errors.New("no encryption keys provided")
+	ErrInvalidKey        = errors.New("key must be 16, 24 or 32 bytes long")
+	ErrUnknownKeyVersion = errors.New("unknown key version")
+)
+
+type KeyRing struct {
+	keys    map[int][]byte
+	current int
+}
+
+// New creates key ring from the keys by version. Current is the version used for encryption.
+func New(keys map[int][]byte, current int) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	for version, key := range keys {
+		switch len(key) {
+		case 16, 24, 32: //nolint:gomnd // AES-128, AES-192 and AES-256
+		default:
+			return nil, fmt.Errorf("key version %d: %w", version, ErrInvalidKey)
+		}
+	}
+
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key version %d: %w", current, ErrUnknownKeyVersion)
+	}
+
+	return &KeyRing{keys: keys, current: current}, nil
+}
+
+// Parse creates key ring from the ENV-like values.
+//
+// legacyKey - key with LegacyVersion, can be empty if it is not used anymore.
+//
+// keys - comma separated "version:key" pairs, e.g. "2:someKey,3:anotherKey".
+//
+// current - version used for encryption. The latest version is used if empty.
+func Parse(legacyKey, keys, current string) (*KeyRing, error) {
+	ring := map[int][]byte{}
+
+	if legacyKey != "" {
+		ring[LegacyVersion] = []byte(legacyKey)
+	}
+
+	for _, pair := range strings.Split(keys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		v, key, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, fmt.Errorf("key pair must be in 'version:key' format, got '%s'", v)
+		}
+
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid key version '%s'", v)
+		}
+
+		if _, exists := ring[version]; exists {
+			return nil, fmt.Errorf("duplicated key version %d", version)
+		}
+
+		ring[version] = []byte(key)
+	}
+
+	currentVersion := 0
+
+	if current != "" {
+		v, err := strconv.Atoi(current)
+		if err != nil {
+			return nil, fmt.Errorf("invalid current key version '%s'", current)
+		}
+
+		currentVersion = v
+	} else {
+		for version := range ring {
+			if version > currentVersion {
+				currentVersion = version
+			}
+		}
+	}
+
+	return New(ring, currentVersion)
+}
+
+// Current returns the key and its version which should be used for encryption.
+func (k *KeyRing) Current() (int, []byte) {
+	return k.current, k.keys[k.current]
+}
+
+// Key returns the key by version.
+func (k *KeyRing) Key(version int) ([]byte, error) {
+	key, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("key version %d: %w", version, ErrUnknownKeyVersion)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,101 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	key1 = "8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q"
+	key2 = "f149VI7P9EsUkirKOnGNy9YKQtbZKEAj"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		legacyKey   string
+		keys        string
+		current     string
+		wantCurrent int
+		wantErr     bool
+	}{
+		{
+			name:        "should use legacy key only",
+			legacyKey:   key1,
+			wantCurrent: LegacyVersion,
+		},
+		{
+			name:        "should use the latest version by default",
+			legacyKey:   key1,
+			keys:        "2:" + key2,
+			wantCurrent: 2,
+		},
+		{
+			name:        "should use selected current version",
+			legacyKey:   key1,
+			keys:        "2:" + key2,
+			current:     "1",
+			wantCurrent: 1,
+		},
+		{
+			name:    "should fail without keys",
+			wantErr: true,
+		},
+		{
+			name:      "should fail on unknown current version",
+			legacyKey: key1,
+			current:   "3",
+			wantErr:   true,
+		},
+		{
+			name:      "should fail on malformed pair",
+			legacyKey: key1,
+			keys:      key2,
+			wantErr:   true,
+		},
+		{
+			name:      "should fail on duplicated version",
+			legacyKey: key1,
+			keys:      "1:" + key2,
+			wantErr:   true,
+		},
+		{
+			name:      "should fail on wrong key length",
+			legacyKey: key1,
+			keys:      "2:short",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.legacyKey, tt.keys, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if version, _ := got.Current(); version != tt.wantCurrent {
+				t.Errorf("Parse() current version = %v, want %v", version, tt.wantCurrent)
+			}
+		})
+	}
+}
+
+func TestKeyRing_Key(t *testing.T) {
+	ring, err := Parse(key1, "2:"+key2, "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	key, err := ring.Key(1)
+	if err != nil || string(key) != key1 {
+		t.Errorf("KeyRing.Key() = %s, %v, want legacy key", key, err)
+	}
+
+	_, err = ring.Key(3)
+	if !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Errorf("KeyRing.Key() error = %v, want ErrUnknownKeyVersion", err)
+	}
+}
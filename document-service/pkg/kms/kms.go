@@ -0,0 +1,93 @@
+// Package kms is used to wrap (encrypt) and unwrap data keys with the master key,
+// so data keys can be stored next to the data they protect.
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/samgozman/validity.red/document/pkg/encryption"
+	"github.com/samgozman/validity.red/document/pkg/keyring"
+)
+
+var ErrUnknownMasterKey = errors.New("unknown master key id")
+
+// KMS is a key management service interface, which can be implemented by the cloud providers.
+type KMS interface {
+	// Wrap encrypts the data key with the current master key.
+	// Returns the wrapped key and ID of the master key used.
+	Wrap(ctx context.Context, dataKey, additionalData []byte) (wrapped []byte, keyID string, err error)
+	// Unwrap decrypts the data key with the master key by its ID.
+	Unwrap(ctx context.Context, wrapped []byte, keyID string, additionalData []byte) ([]byte, error)
+	// CurrentKeyID returns ID of the master key used for wrapping.
+	CurrentKeyID() string
+}
+
+// LocalKMS keeps versioned master keys in memory. Master key ID is the key version.
+type LocalKMS struct {
+	Keys *keyring.KeyRing
+}
+
+// NewLocalKMSFromFile loads master keys from the file with one "version:key" pair per line.
+// The latest version is used for wrapping.
+func NewLocalKMSFromFile(path string) (*LocalKMS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			pairs = append(pairs, line)
+		}
+	}
+
+	keys, err := keyring.Parse("", strings.Join(pairs, ","), "")
+	if err != nil {
+		return nil, fmt.Errorf("master keys file '%s': %w", path, err)
+	}
+
+	return &LocalKMS{Keys: keys}, nil
+}
+
+func (k *LocalKMS) Wrap(ctx context.Context, dataKey, additionalData []byte) ([]byte, string, error) {
+	version, key := k.Keys.Current()
+
+	wrapped, err := encryption.EncryptGCM(key, string(dataKey), additionalData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return []byte(wrapped), strconv.Itoa(version), nil
+}
+
+func (k *LocalKMS) Unwrap(ctx context.Context, wrapped []byte, keyID string, additionalData []byte) ([]byte, error) {
+	version, err := strconv.Atoi(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownMasterKey, keyID)
+	}
+
+	key, err := k.Keys.Key(version)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := encryption.DecryptGCM(key, string(wrapped), additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(dataKey), nil
+}
+
+func (k *LocalKMS) CurrentKeyID() string {
+	version, _ := k.Keys.Current()
+	return strconv.Itoa(version)
+}
@@ -0,0 +1,62 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalKMS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.keys")
+	content := "# master keys\n1:8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := NewLocalKMSFromFile(path)
+	if err != nil {
+		t.Fatalf("NewLocalKMSFromFile() error = %v", err)
+	}
+
+	ctx := context.Background()
+	dataKey := []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj")
+	ad := []byte("434377cf-7509-4cc0-9895-0afa683f0e56")
+
+	wrapped, keyID, err := k.Wrap(ctx, dataKey, ad)
+	if err != nil || keyID != "1" {
+		t.Fatalf("LocalKMS.Wrap() = %v, %v, want key id 1", keyID, err)
+	}
+
+	if bytes.Contains(wrapped, dataKey) {
+		t.Errorf("LocalKMS.Wrap() returned plain data key")
+	}
+
+	// Rotate master key, old one is still used to unwrap
+	content += "2:Ut3LgmhVa5YeGqSx7cQ2nPzK9wB4dFjR\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err = NewLocalKMSFromFile(path)
+	if err != nil || k.CurrentKeyID() != "2" {
+		t.Fatalf("NewLocalKMSFromFile() = %v, %v, want current key id 2", k, err)
+	}
+
+	got, err := k.Unwrap(ctx, wrapped, keyID, ad)
+	if err != nil || !bytes.Equal(got, dataKey) {
+		t.Errorf("LocalKMS.Unwrap() = %s, %v, want %s", got, err, dataKey)
+	}
+
+	_, err = k.Unwrap(ctx, wrapped, keyID, []byte("another user"))
+	if err == nil {
+		t.Errorf("LocalKMS.Unwrap() with other additional data should fail")
+	}
+
+	_, err = k.Unwrap(ctx, wrapped, "3", ad)
+	if err == nil {
+		t.Errorf("LocalKMS.Unwrap() with unknown key id should fail")
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// Common behavior of all storage implementations.
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	data := []byte{0x89, 'P', 'N', 'G'}
+
+	if err := s.Put(ctx, "user/doc/a", data); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if err := s.Put(ctx, "user/doc/b", data); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if err := s.Put(ctx, "user/other/c", data); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	got, err := s.Get(ctx, "user/doc/a")
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Get() = %v, %v, want %v", got, err, data)
+	}
+
+	if err := s.Delete(ctx, "user/doc/a"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+
+	if _, err := s.Get(ctx, "user/doc/a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() deleted object error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Delete(ctx, "user/doc/a"); err != nil {
+		t.Errorf("Delete() missing object error = %v", err)
+	}
+
+	if err := s.DeleteAll(ctx, "user/doc/"); err != nil {
+		t.Errorf("DeleteAll() error = %v", err)
+	}
+
+	if _, err := s.Get(ctx, "user/doc/b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() object deleted by prefix error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, err := s.Get(ctx, "user/other/c"); err != nil {
+		t.Errorf("Get() object with other prefix error = %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "user//a", "user/./a"} {
+		if err := s.Put(ctx, key, data); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	testStorage(t, &LocalStorage{Dir: t.TempDir()})
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage keeps objects in the bucket of S3-compatible storage (AWS S3, MinIO, etc.).
+// Requests are signed with AWS Signature Version 4 and use path-style URLs.
+type S3Storage struct {
+	Endpoint        string // e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Client          *http.Client
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, "/"+key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, http.StatusOK)
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(ctx, http.MethodGet, "/"+key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err := checkStatus(res, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodDelete, "/"+key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return checkStatus(res, http.StatusNoContent, http.StatusOK)
+}
+
+// S3 has no directories, so all objects with the prefix are listed and deleted one by one.
+func (s *S3Storage) DeleteAll(ctx context.Context, prefix string) error {
+	if err := validateKey(prefix); err != nil {
+		return err
+	}
+
+	keys, err := s.list(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List keys of all objects with the prefix.
+func (s *S3Storage) list(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+
+	for {
+		res, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+
+		err = checkStatus(res, http.StatusOK)
+		if err == nil {
+			err = xml.NewDecoder(res.Body).Decode(&result)
+		}
+
+		res.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+
+		if !result.IsTruncated {
+			return keys, nil
+		}
+
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// Send signed request to the bucket. Path is the object key with the leading slash or empty for the bucket.
+func (s *S3Storage) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	uri := "/" + s.Bucket + path
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		strings.TrimSuffix(s.Endpoint, "/")+encodePath(uri)+encodeQuery(query),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, uri, query, body, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+// Sign request with AWS Signature Version 4.
+// @see: https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3Storage) sign(req *http.Request, uri string, query url.Values, body []byte, now time.Time) {
+	const algorithm = "AWS4-HMAC-SHA256"
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(uri),
+		strings.TrimPrefix(encodeQuery(query), "?"),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, s.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func checkStatus(res *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if res.StatusCode == code {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unexpected S3 response status: %s", res.Status)
+}
+
+// Encode URI path as required by AWS: every byte except unreserved characters and slashes is escaped.
+func encodePath(path string) string {
+	var sb strings.Builder
+
+	for _, b := range []byte(path) {
+		if b == '/' || isUnreserved(b) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+
+	return sb.String()
+}
+
+// Encode query string with sorted keys, starting with "?" if not empty.
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var parts []string
+
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, encodeQueryValue(k)+"="+encodeQueryValue(v))
+		}
+	}
+
+	return "?" + strings.Join(parts, "&")
+}
+
+func encodeQueryValue(s string) string {
+	var sb strings.Builder
+
+	for _, b := range []byte(s) {
+		if isUnreserved(b) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+
+	return sb.String()
+}
+
+func isUnreserved(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+		b == '-' || b == '_' || b == '.' || b == '~'
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,41 @@
+// Package storage is used to keep binary objects (e.g. attachment files) by their keys.
+//
+// Keys are slash separated paths like "<userID>/<documentID>/<attachmentID>",
+// so all objects of the user or the document can be deleted by the key prefix.
+// Objects should be encrypted before they are stored.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete the object, deleting missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// Delete all objects which keys start with the prefix
+	DeleteAll(ctx context.Context, prefix string) error
+}
+
+// Check that the key is a relative path without empty, "." or ".." segments.
+func validateKey(key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(strings.TrimSuffix(key, "/"), "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, "\\") {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -1,11 +1,18 @@
 import { setIsAuthenticated, setCalendarId } from "@/state";
+import { QueryMaker } from "@/services/QueryMaker";
 
 /**
- * Logout from current user: clear state, cookie and local storage
+ * Logout from current user: revoke session, clear state, cookie and local storage
  */
 export const logout = () => {
   setIsAuthenticated(false);
   setCalendarId("");
   localStorage.removeItem("user");
-  document.cookie = "token=;expires=Thu, 01 Jan 1970 00:00:01 GMT;";
+  // Cookies are cleared only after the session is revoked, because the request needs them
+  new QueryMaker({ route: "/auth/logout" })
+    .post()
+    .catch(() => console.error("Session revocation failed!"))
+    .finally(() => {
+      document.cookie = "token=;expires=Thu, 01 Jan 1970 00:00:01 GMT;";
+    });
 };
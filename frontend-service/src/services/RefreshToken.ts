@@ -1,7 +1,7 @@
 import { QueryMaker } from "@/services/QueryMaker";
 
 /**
- * Refresh JWT token
+ * Refresh JWT token by rotating http-only refresh token cookie
  */
 export class RefreshToken {
   public static async call() {
@@ -9,7 +9,7 @@ export class RefreshToken {
     if (!token) return;
 
     try {
-      await new QueryMaker({ route: "/user/token/refresh" }).get();
+      await new QueryMaker({ route: "/auth/token/refresh" }).post();
     } catch (error) {
       console.error("Token refresh failed!");
       return;
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/utils"
+	"github.com/samgozman/validity.red/broker/proto/calendar"
+	"github.com/samgozman/validity.red/broker/proto/document"
+	"github.com/samgozman/validity.red/broker/proto/user"
+)
+
+type accountDeletePayload struct {
+	Password string `json:"password" binding:"required,max=64"`
+}
+
+// Delete user account with all its data from every service.
+//
+// The user row is deleted last, so if any step fails the account still exists
+// and the deletion can be safely repeated.
+func (app *Config) userDeleteAccount(c *gin.Context) {
+	const requestTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	id := userID.(string)
+
+	payload := accountDeletePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	profile, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		UserId: id,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Confirm password before deleting anything
+	err = app.confirmPassword(ctx, c, profile.Email, payload.Password)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	calendarOptions, err := app.usersClient.userService.GetCalendarOptions(ctx, &user.GetCalendarIdRequest{
+		UserId: id,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetCalendarOptions method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	groups, err := app.userGroups(ctx, id)
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{
+			// Owned groups are deleted with the user, so the members' shares in them are deleted too
+			name: "document-service::DeleteGroupShares",
+			run: func() error {
+				for _, g := range groups {
+					if g.OwnerId != id {
+						continue
+					}
+
+					_, err := app.documentsClient.documentService.DeleteGroupShares(ctx, &document.GroupSharesRequest{
+						GroupID: g.Id,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		},
+		{
+			name: "document-service::DeleteAllForUser",
+			run: func() error {
+				_, err := app.documentsClient.documentService.DeleteAllForUser(ctx, &document.DocumentsRequest{
+					UserID: id,
+				})
+				return err
+			},
+		},
+		{
+			name: "calendar-service::DeleteCalendar",
+			run: func() error {
+				_, err := app.calendarsClient.calendarService.DeleteCalendar(ctx, &calendar.DeleteCalendarRequest{
+					CalendarID: calendarOptions.CalendarId,
+				})
+				return err
+			},
+		},
+		{
+			// Sessions are revoked before the user is deleted, valid-after key expires by itself
+			name: "redis::revokeUserSessions",
+			run: func() error {
+				return app.revokeUserSessions(ctx, id)
+			},
+		},
+		{
+			name: "redis::deleteTwoFactorChallenges",
+			run: func() error {
+				return app.deleteTwoFactorChallenges(ctx, id)
+			},
+		},
+		{
+			name: "redis::Del",
+			run: func() error {
+				email := normalizeLoginEmail(profile.Email)
+
+				return app.redisClient.Del(
+					ctx,
+					"user:verification:"+id,
+					"user:verification:resend:"+strings.ToLower(profile.Email),
+					"user:password-reset:"+id,
+					"user:email-change:"+id,
+					loginFailuresKey("account", email),
+					loginLockKey(email),
+				).Err()
+			},
+		},
+		{
+			name: "user-service::DeleteUser",
+			run: func() error {
+				_, err := app.usersClient.userService.DeleteUser(ctx, &user.DeleteUserRequest{
+					UserId: id,
+				})
+				return err
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			log.Printf("Error on deleting account in step '%s': %s", step.name, err)
+			sentry.CaptureException(fmt.Errorf("userDeleteAccount step '%s' error: %w", step.name, err))
+			_ = c.Error(err)
+
+			return
+		}
+	}
+
+	app.clearSessionCookies(c)
+	c.Status(http.StatusOK)
+}
+
+// Export all user data as a JSON file: the profile and settings, documents with the deleted ones
+// and everything attached to them, persons, custom types, tags, reminder presets, shares and groups.
+// Attachments are exported without their content, the files are downloaded one by one.
+func (app *Config) userExportData(c *gin.Context) {
+	const requestTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	profile, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	settings, err := app.usersClient.userService.GetSettings(ctx, &user.GetSettingsRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetSettings method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	groups, err := app.userGroups(ctx, userID.(string))
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	invitations, err := app.usersClient.groupService.GetInvitations(ctx, &user.GetGroupsRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetInvitations method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	documentsReq := &document.DocumentsRequest{
+		UserID: userID.(string),
+	}
+
+	documents, err := app.documentsClient.documentService.GetAll(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	deletedDocuments, err := app.documentsClient.documentService.ListDeleted(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::ListDeleted method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	renewals, err := app.documentsClient.documentService.GetRenewalsForUser(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetRenewalsForUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	attachments, err := app.documentsClient.attachmentService.GetAllForUser(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::attachment::GetAllForUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	persons, err := app.documentsClient.documentService.GetPersons(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetPersons method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	customTypes, err := app.documentsClient.documentService.GetCustomTypes(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetCustomTypes method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	tags, err := app.documentsClient.documentService.GetTags(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetTags method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	shares, err := app.documentsClient.documentService.GetShares(ctx, documentsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::GetShares method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	notificationsReq := &document.NotificationsAllRequest{
+		UserID: userID.(string),
+	}
+
+	notifications, err := app.documentsClient.notificationService.GetAllForUser(ctx, notificationsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::notification::GetAllForUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	rules, err := app.documentsClient.notificationService.GetRulesForUser(ctx, notificationsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::notification::GetRulesForUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	presets, err := app.documentsClient.notificationService.GetPresets(ctx, notificationsReq)
+	if err != nil {
+		log.Println("Error on calling document-service::notification::GetPresets method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=validity-export.json")
+	c.JSON(http.StatusOK, convertUserExportToJSON(&userExport{
+		Profile:          profile,
+		Settings:         settings,
+		Groups:           groups,
+		Invitations:      invitations.Invitations,
+		Documents:        documents.Documents,
+		DeletedDocuments: deletedDocuments.Documents,
+		Renewals:         renewals.Renewals,
+		Attachments:      attachments.Attachments,
+		Persons:          persons.Persons,
+		CustomTypes:      customTypes.CustomTypes,
+		Tags:             tags.Tags,
+		Shares:           shares.Shares,
+		Notifications:    notifications.Notifications,
+		Rules:            rules.Rules,
+		Presets:          presets.Presets,
+	}))
+}
+
+// All user's data collected from the services for the export.
+type userExport struct {
+	Profile          *user.GetUserResponse
+	Settings         *user.Settings
+	Groups           []*user.Group
+	Invitations      []*user.GroupInvitation
+	Documents        []*document.Document
+	DeletedDocuments []*document.Document
+	Renewals         []*document.Renewal
+	Attachments      []*document.Attachment
+	Persons          []*document.Person
+	CustomTypes      []*document.CustomType
+	Tags             []*document.Tag
+	Shares           []*document.Share
+	Notifications    []*document.Notification
+	Rules            []*document.ReminderRule
+	Presets          []*document.ReminderPreset
+}
+
+type exportProfileJSON struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	Timezone   string `json:"timezone"`
+	IsVerified bool   `json:"isVerified"`
+}
+
+type userExportJSON struct {
+	ExportedAt       string                       `json:"exportedAt"`
+	Profile          exportProfileJSON            `json:"profile"`
+	Settings         settingsPayload              `json:"settings"`
+	Groups           []*groupJSON                 `json:"groups"`
+	Invitations      []*groupInvitationJSON       `json:"invitations"`
+	Documents        []*document.DocumentJSON     `json:"documents"`
+	DeletedDocuments []*document.DocumentJSON     `json:"deletedDocuments"`
+	Renewals         []*document.RenewalJSON      `json:"renewals"`
+	Attachments      []*document.AttachmentJSON   `json:"attachments"`
+	Persons          []*personJSON                `json:"persons"`
+	CustomTypes      []*customTypeJSON            `json:"customTypes"`
+	Tags             []*tagJSON                   `json:"tags"`
+	Shares           []*shareJSON                 `json:"shares"`
+	Notifications    []*document.NotificationJSON `json:"notifications"`
+	ReminderRules    []reminderRuleJSON           `json:"reminderRules"`
+	ReminderPresets  []reminderPresetJSON         `json:"reminderPresets"`
+}
+
+func convertUserExportToJSON(e *userExport) *userExportJSON {
+	res := &userExportJSON{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Profile: exportProfileJSON{
+			ID:         e.Profile.UserId,
+			Email:      e.Profile.Email,
+			Timezone:   e.Profile.Timezone,
+			IsVerified: e.Profile.IsVerified,
+		},
+		Settings:         convertSettingsToJSON(e.Settings),
+		Groups:           make([]*groupJSON, 0, len(e.Groups)),
+		Invitations:      make([]*groupInvitationJSON, 0, len(e.Invitations)),
+		Documents:        utils.ConvertDocumentsToJSON(e.Documents),
+		DeletedDocuments: utils.ConvertDocumentsToJSON(e.DeletedDocuments),
+		Renewals:         utils.ConvertRenewalsToJSON(e.Renewals),
+		Attachments:      utils.ConvertAttachmentsToJSON(e.Attachments),
+		Persons:          make([]*personJSON, 0, len(e.Persons)),
+		CustomTypes:      make([]*customTypeJSON, 0, len(e.CustomTypes)),
+		Tags:             make([]*tagJSON, 0, len(e.Tags)),
+		Shares:           make([]*shareJSON, 0, len(e.Shares)),
+		Notifications:    utils.ConvertNotificationsToJSON(e.Notifications),
+		ReminderRules:    make([]reminderRuleJSON, 0, len(e.Rules)),
+		ReminderPresets:  make([]reminderPresetJSON, 0, len(e.Presets)),
+	}
+
+	for _, g := range e.Groups {
+		res.Groups = append(res.Groups, convertGroupToJSON(g))
+	}
+
+	for _, inv := range e.Invitations {
+		res.Invitations = append(res.Invitations, convertInvitationToJSON(inv))
+	}
+
+	for _, p := range e.Persons {
+		res.Persons = append(res.Persons, &personJSON{ID: p.ID, Name: p.Name})
+	}
+
+	for _, ct := range e.CustomTypes {
+		res.CustomTypes = append(res.CustomTypes, &customTypeJSON{ID: ct.ID, Name: ct.Name})
+	}
+
+	for _, t := range e.Tags {
+		res.Tags = append(res.Tags, &tagJSON{ID: t.ID, Name: t.Name})
+	}
+
+	for _, s := range e.Shares {
+		res.Shares = append(res.Shares, convertShareToJSON(s))
+	}
+
+	for _, r := range e.Rules {
+		res.ReminderRules = append(res.ReminderRules, reminderRuleJSON{
+			ID:         r.ID,
+			DocumentID: r.DocumentID,
+			DaysBefore: r.DaysBefore,
+		})
+	}
+
+	for _, p := range e.Presets {
+		res.ReminderPresets = append(res.ReminderPresets, reminderPresetJSON{
+			ID:         p.ID,
+			Type:       int32(p.Type),
+			DaysBefore: p.DaysBefore,
+		})
+	}
+
+	return res
+}
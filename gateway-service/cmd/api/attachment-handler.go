@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/utils"
+	"github.com/samgozman/validity.red/broker/proto/document"
+	"google.golang.org/grpc"
+)
+
+// Attachments are larger than other requests, so they have more time to be transferred.
+const attachmentTimeout = 10 * time.Second
+
+// Space for the multipart form fields and headers besides the file itself.
+const multipartOverhead = 64 * 1024
+
+type attachmentModifyPayload struct {
+	ID         string `uri:"id" binding:"required,uuid"`
+	DocumentID string `uri:"documentId" binding:"required,uuid"`
+}
+
+// Call Upload method on Attachment in `document-service`.
+// File is sent as the "file" field of the multipart form.
+func (app *Config) documentAttachmentUpload(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentTimeout)
+	defer cancel()
+
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, app.options.MaxAttachmentSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			_ = c.Error(ErrFileTooLarge)
+			return
+		}
+
+		_ = c.Error(ErrInvalidInputs)
+
+		return
+	}
+
+	if fileHeader.Size > app.options.MaxAttachmentSize {
+		_ = c.Error(ErrFileTooLarge)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.attachmentService.Upload(ctx, &document.AttachmentUploadRequest{
+		UserID:     userID.(string),
+		DocumentID: uri.DocumentID,
+		FileName:   fileHeader.Filename,
+		Content:    content,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::attachment::Upload method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		Attachment *document.AttachmentJSON `json:"attachment"`
+	}{
+		Attachment: utils.ConvertAttachmentToJSON(res.Attachment),
+	})
+}
+
+// Call GetAll method on Attachment in `document-service`.
+func (app *Config) documentAttachmentGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.attachmentService.GetAll(ctx, &document.AttachmentsRequest{
+		UserID:     userID.(string),
+		DocumentID: uri.DocumentID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::attachment::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Attachments []*document.AttachmentJSON `json:"attachments"`
+	}{
+		Attachments: utils.ConvertAttachmentsToJSON(res.Attachments),
+	})
+}
+
+// Call Download method on Attachment in `document-service` and send the file.
+func (app *Config) documentAttachmentDownload(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentTimeout)
+	defer cancel()
+
+	uri := attachmentModifyPayload{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.attachmentService.Download(
+		ctx,
+		&document.AttachmentRequest{
+			UserID:       userID.(string),
+			DocumentID:   uri.DocumentID,
+			AttachmentID: uri.ID,
+		},
+		// Default 4MB limit is not enough for the attachments
+		grpc.MaxCallRecvMsgSize(int(app.options.MaxAttachmentSize)+multipartOverhead),
+	)
+	if err != nil {
+		log.Println("Error on calling document-service::attachment::Download method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// File name is set by the user, so it is escaped by the media type formatter
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": res.Attachment.FileName})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+
+	c.Header("Content-Disposition", disposition)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, res.Attachment.MimeType, res.Content)
+}
+
+// Call Delete method on Attachment in `document-service`.
+func (app *Config) documentAttachmentDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := attachmentModifyPayload{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	_, err := app.documentsClient.attachmentService.Delete(ctx, &document.AttachmentRequest{
+		UserID:       userID.(string),
+		DocumentID:   uri.DocumentID,
+		AttachmentID: uri.ID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::attachment::Delete method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
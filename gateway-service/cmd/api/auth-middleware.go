@@ -1,37 +1,60 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/token"
 )
 
 // AuthGuard middleware that checks if the user is authenticated
 // and passes the UserId to the next handler via context.
 func (app *Config) AuthGuard() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var (
-			userID string
-			token  string
-		)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
 
 		// Get token from cookie
-		token, err := c.Cookie("token")
+		authToken, err := c.Cookie("token")
 		if err != nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		// Verify token and decode UserID from it
-		userID, err = app.token.Verify(token)
-		if err != nil {
+		// Verify token and decode UserID from it. Only auth tokens of the refresh token sessions are accepted,
+		// so the 2FA challenge and the sessions revocation can't be bypassed with the tokens sent by email
+		claims, err := app.token.VerifyClaims(authToken)
+		if err != nil || claims.Id == "" || claims.SessionID == "" || claims.Purpose != token.PurposeAuth {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// Check that user's sessions were not revoked after the token was issued
+		revoked, err := app.isSessionRevoked(ctx, claims.UserID, claims.IssuedAt)
+		if err != nil || revoked {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// Check that the token itself was not revoked on logout
+		revoked, err = app.isAuthTokenRevoked(ctx, claims.Id)
+		if err != nil || revoked {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// Check that the token's session was not revoked from another device or on refresh token reuse
+		active, err := app.isSessionActive(ctx, claims.UserID, claims.SessionID)
+		if err != nil || !active {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
 		// Add decoded user id for the context
-		c.Set("UserId", userID)
-		c.Set("Token", token)
+		c.Set("UserId", claims.UserID)
+		c.Set("Token", authToken)
 
 		c.Next()
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -47,7 +48,16 @@ func (app *Config) getCalendar(c *gin.Context) {
 		return
 	}
 
-	calendarArr := createCalendar(documents.Documents, notifications.Notifications)
+	sharedDocuments, sharedNotifications, err := app.getSharedCalendarEntries(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	calendarArr := createCalendar(
+		append(documents.Documents, sharedDocuments...),
+		append(notifications.Notifications, sharedNotifications...),
+	)
 
 	c.JSON(http.StatusOK, struct {
 		Calendar []*calendar.CalendarEntityJSON `json:"calendar"`
@@ -78,11 +88,23 @@ func (app *Config) getCalendarIcs(c *gin.Context) {
 		return
 	}
 
+	keyResp, err := app.documentsClient.documentService.GetCalendarKey(ctx, &document.DocumentsRequest{
+		UserID: ivResp.UserId,
+	})
+	if err != nil {
+		log.Println("Error on calling DocumentService.GetCalendarKey method for getCalendarIcs:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
 	calendarIcs, err := app.calendarsClient.calendarService.GetCalendar(ctx, &calendar.GetCalendarRequest{
-		CalendarID: uri.ID,
-		CalendarIV: ivResp.CalendarIv,
+		CalendarID:  uri.ID,
+		CalendarIV:  ivResp.CalendarIv,
+		CalendarKey: keyResp.CalendarKey,
 	})
 	if err != nil {
+		// Calendar is also re-created if it was encrypted with another key
 		if st, ok := status.FromError(err); ok && st.Code() != codes.NotFound {
 			// Create calendar
 			newCalendarIv, err := app.updateIcsCalendar(ivResp.UserId)
@@ -93,8 +115,9 @@ func (app *Config) getCalendarIcs(c *gin.Context) {
 
 			// Fetch calendar
 			calendarIcs, err = app.calendarsClient.calendarService.GetCalendar(ctx, &calendar.GetCalendarRequest{
-				CalendarID: uri.ID,
-				CalendarIV: newCalendarIv,
+				CalendarID:  uri.ID,
+				CalendarIV:  newCalendarIv,
+				CalendarKey: keyResp.CalendarKey,
 			})
 			if err != nil {
 				_ = c.Error(err)
@@ -152,8 +175,35 @@ func (app *Config) updateIcsCalendar(userID string) (iv []byte, err error) {
 		return nil, err
 	}
 
-	// 4. create calendar
-	calendarArr := createCalendar(documents.Documents, notifications.Notifications)
+	// 4. get documents and notifications shared with the user's groups
+	sharedDocuments, sharedNotifications, err := app.getSharedCalendarEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. create calendar
+	calendarArr := createCalendar(
+		append(documents.Documents, sharedDocuments...),
+		append(notifications.Notifications, sharedNotifications...),
+	)
+
+	// Calendar file is encrypted with the key derived from the user's data key.
+	// Key is created for the users registered before data keys, destroyed keys are not created again
+	_, err = app.documentsClient.documentService.CreateDataKey(ctx, &document.DocumentsRequest{
+		UserID: userID,
+	})
+	if err != nil {
+		log.Println("Error on calling DocumentService.CreateDataKey:", err)
+		return nil, err
+	}
+
+	keyResp, err := app.documentsClient.documentService.GetCalendarKey(ctx, &document.DocumentsRequest{
+		UserID: userID,
+	})
+	if err != nil {
+		log.Println("Error on calling DocumentService.GetCalendarKey:", err)
+		return nil, err
+	}
 
 	// Create new IV
 	ivCalendar := make([]byte, calendarIVLength)
@@ -170,6 +220,7 @@ func (app *Config) updateIcsCalendar(userID string) (iv []byte, err error) {
 		CalendarIV:       ivCalendar,
 		CalendarEntities: calendarArr,
 		Timezone:         calendarIDResp.Timezone,
+		CalendarKey:      keyResp.CalendarKey,
 	})
 	if err != nil {
 		log.Println("Error on calling CalendarService.CreateCalendar:", err)
@@ -189,21 +240,75 @@ func (app *Config) updateIcsCalendar(userID string) (iv []byte, err error) {
 	return ivCalendar, nil
 }
 
+// Get documents of other users shared with the user's groups and their notifications.
+func (app *Config) getSharedCalendarEntries(
+	ctx context.Context,
+	userID string,
+) ([]*document.Document, []*document.Notification, error) {
+	groupIDs, err := app.userGroupIDs(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(groupIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	documents, err := app.documentsClient.documentService.GetShared(ctx, &document.SharedDocumentsRequest{
+		UserID:   userID,
+		GroupIDs: groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling GetShared method:", err)
+		return nil, nil, err
+	}
+
+	notifications, err := app.documentsClient.notificationService.GetAllShared(ctx, &document.SharedDocumentsRequest{
+		UserID:   userID,
+		GroupIDs: groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling Notification.GetAllShared:", err)
+		return nil, nil, err
+	}
+
+	return documents.Documents, notifications.Notifications, nil
+}
+
 // Combine array of documents with array of notifications
 // into array of CalendarEntity.
+//
+// Notifications of the reminder rules are merged with the absolute ones:
+// if both remind about the same document at the same time, only the absolute one is added.
 func createCalendar(
 	documents []*document.Document,
 	notifications []*document.Notification,
 ) []*calendar.CalendarEntity {
 	var calendarArr = []*calendar.CalendarEntity{}
 
-	for _, notification := range notifications {
+	// Absolute notifications go first, so they are kept on duplicates
+	sorted := make([]*document.Notification, len(notifications))
+	copy(sorted, notifications)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].RuleID == "" && sorted[j].RuleID != ""
+	})
+
+	added := make(map[string]bool, len(sorted))
+
+	for _, notification := range sorted {
+		key := notification.DocumentID + "/" + utils.ParseProtobufDateToString(notification.Date)
+		if added[key] {
+			continue
+		}
+
+		added[key] = true
+
 		d := findDocumentByID(documents, notification.DocumentID)
 
 		calendarArr = append(calendarArr, &calendar.CalendarEntity{
 			DocumentID:       d.ID,
 			NotificationID:   notification.ID,
-			DocumentTitle:    d.Title,
+			DocumentTitle:    calendarTitle(d),
 			NotificationDate: notification.Date,
 			ExpiresAt:        d.ExpiresAt,
 		})
@@ -212,6 +317,15 @@ func createCalendar(
 	return calendarArr
 }
 
+// Title of the calendar event, documents of the persons (dependents) are marked with their names.
+func calendarTitle(d *document.Document) string {
+	if d.PersonName == "" {
+		return d.Title
+	}
+
+	return d.Title + " (" + d.PersonName + ")"
+}
+
 // Find document by ID in array of documents.
 func findDocumentByID(documents []*document.Document, id string) *document.Document {
 	for _, document := range documents {
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/document"
+)
+
+type customTypePayload struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// Custom document type in JSON format.
+type customTypeJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Call GetCustomTypes method on `document-service`.
+func (app *Config) customTypeGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.documentsClient.documentService.GetCustomTypes(ctx, &document.DocumentsRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetCustomTypes method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	customTypes := make([]*customTypeJSON, 0, len(res.CustomTypes))
+	for _, ct := range res.CustomTypes {
+		customTypes = append(customTypes, &customTypeJSON{ID: ct.ID, Name: ct.Name})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		CustomTypes []*customTypeJSON `json:"customTypes"`
+	}{
+		CustomTypes: customTypes,
+	})
+}
+
+// Call CreateCustomType method on `document-service`.
+func (app *Config) customTypeCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := customTypePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.CreateCustomType(ctx, &document.CustomTypeRequest{
+		UserID: userID.(string),
+		Name:   payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::CreateCustomType method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		CustomType *customTypeJSON `json:"customType"`
+	}{
+		CustomType: &customTypeJSON{ID: res.CustomType.ID, Name: res.CustomType.Name},
+	})
+}
+
+// Call EditCustomType method on `document-service`.
+func (app *Config) customTypeEdit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		CustomTypeID string `uri:"customTypeId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	payload := customTypePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.EditCustomType(ctx, &document.CustomTypeRequest{
+		UserID:       userID.(string),
+		CustomTypeID: uri.CustomTypeID,
+		Name:         payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::EditCustomType method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		CustomType *customTypeJSON `json:"customType"`
+	}{
+		CustomType: &customTypeJSON{ID: res.CustomType.ID, Name: res.CustomType.Name},
+	})
+}
+
+// Call DeleteCustomType method on `document-service`.
+func (app *Config) customTypeDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		CustomTypeID string `uri:"customTypeId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.documentsClient.documentService.DeleteCustomType(ctx, &document.CustomTypeRequest{
+		UserID:       userID.(string),
+		CustomTypeID: uri.CustomTypeID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::DeleteCustomType method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/redis/go-redis/v9"
+	"github.com/samgozman/validity.red/broker/internal/digest"
+	"github.com/samgozman/validity.red/broker/proto/document"
+	"github.com/samgozman/validity.red/broker/proto/user"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Time limit of a single gRPC call made by the digest job.
+const digestRequestTimeout = 5 * time.Second
+
+// Digest recipients from `user-service`.
+type digestUsers struct {
+	userService user.UserServiceClient
+}
+
+func (u *digestUsers) FindRecipients(ctx context.Context, afterUserID string, limit int) ([]digest.Recipient, error) {
+	ctx, cancel := context.WithTimeout(ctx, digestRequestTimeout)
+	defer cancel()
+
+	res, err := u.userService.GetDigestRecipients(ctx, &user.GetDigestRecipientsRequest{
+		AfterUserId: afterUserID,
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on calling user-service::GetDigestRecipients method: %w", err)
+	}
+
+	recipients := make([]digest.Recipient, 0, len(res.Recipients))
+
+	for _, r := range res.Recipients {
+		loc, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("invalid timezone of user '%s': %w", r.UserId, err))
+
+			loc = time.UTC
+		}
+
+		recipient := digest.Recipient{
+			UserID:   r.UserId,
+			Email:    r.Email,
+			Location: loc,
+			Monthly:  r.DigestFrequency == user.DigestFrequency_MONTHLY,
+		}
+
+		if r.DigestSentAt != nil {
+			recipient.SentAt = r.DigestSentAt.AsTime()
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+func (u *digestUsers) SetSent(ctx context.Context, userID string, sentAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, digestRequestTimeout)
+	defer cancel()
+
+	_, err := u.userService.SetDigestSent(ctx, &user.SetDigestSentRequest{
+		UserId: userID,
+		SentAt: timestamppb.New(sentAt),
+	})
+	if err != nil {
+		return fmt.Errorf("error on calling user-service::SetDigestSent method: %w", err)
+	}
+
+	return nil
+}
+
+// Expiring documents from `document-service`.
+type digestDocuments struct {
+	documentService document.DocumentServiceClient
+}
+
+func (d *digestDocuments) FindExpiring(ctx context.Context, userID string, now, end time.Time) ([]digest.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, digestRequestTimeout)
+	defer cancel()
+
+	// Documents are filtered by whole days, the rest is cut off by the period end
+	days := int32(math.Ceil(end.Sub(now).Hours() / 24))
+
+	res, err := d.documentService.GetAll(ctx, &document.DocumentsRequest{
+		UserID:            userID,
+		SortBy:            document.DocumentSort_EXPIRES_AT,
+		ExpiresWithinDays: days,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on calling document-service::GetAll method: %w", err)
+	}
+
+	var documents []digest.Document
+
+	for _, doc := range res.Documents {
+		expiresAt := doc.ExpiresAt.AsTime()
+		if !expiresAt.Before(end) {
+			continue
+		}
+
+		documents = append(documents, digest.Document{
+			ID:         doc.ID,
+			Title:      doc.Title,
+			ExpiresAt:  expiresAt,
+			PersonName: doc.PersonName,
+		})
+	}
+
+	return documents, nil
+}
+
+// Lock of the digests shared by the gateway replicas in Redis.
+type digestLocker struct {
+	redisClient *redis.Client
+}
+
+func (l *digestLocker) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.redisClient.SetNX(ctx, key, 1, ttl).Result()
+}
+
+func (l *digestLocker) Unlock(ctx context.Context, key string) error {
+	return l.redisClient.Del(ctx, key).Err()
+}
+
+// Create the job sending weekly and monthly digests of the upcoming expirations.
+// Emails are sent only in production, otherwise digests are written to the log.
+func (app *Config) setupDigest() *digest.Job {
+	var sender digest.Sender = digest.LogSender{}
+
+	if app.options.Environment == "production" {
+		sender = app.mailer
+	}
+
+	return &digest.Job{
+		Users:     &digestUsers{userService: app.usersClient.userService},
+		Documents: &digestDocuments{documentService: app.documentsClient.documentService},
+		Sender:    sender,
+		Locker:    &digestLocker{redisClient: app.redisClient},
+		Clock:     digest.SystemClock{},
+		Interval:  15 * time.Minute,
+		BatchSize: 100,
+	}
+}
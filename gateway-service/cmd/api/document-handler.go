@@ -15,10 +15,14 @@ import (
 // ? Maybe use alpha-num-unicode rule for string fields?
 
 type documentCreate struct {
-	Type        int32     `json:"type" binding:"number,min=0,max=255"`
-	Title       string    `json:"title" binding:"required,max=100"`
-	Description string    `json:"description" binding:"max=500"`
-	ExpiresAt   time.Time `json:"expiresAt" binding:"required"`
+	Type          int32     `json:"type" binding:"number,min=0,max=255"`
+	Title         string    `json:"title" binding:"required,max=100"`
+	Description   string    `json:"description" binding:"max=500"`
+	ExpiresAt     time.Time `json:"expiresAt" binding:"required"`
+	RenewalMonths int32     `json:"renewalMonths" binding:"min=0,max=120"`
+	PersonID      string    `json:"personId" binding:"omitempty,uuid"`
+	CustomTypeID  string    `json:"customTypeId" binding:"omitempty,uuid"`
+	TagIDs        []string  `json:"tagIds" binding:"max=10,dive,uuid"`
 }
 
 type documentEdit struct {
@@ -27,6 +31,49 @@ type documentEdit struct {
 	Title       string    `json:"title" binding:"required,max=100"`
 	Description string    `json:"description" binding:"max=500"`
 	ExpiresAt   time.Time `json:"expiresAt" binding:"required"`
+	// Renewal period, person, custom type and tags are changed only if they are present in the payload
+	RenewalMonths *int32    `json:"renewalMonths" binding:"omitempty,min=0,max=120"`
+	PersonID      *string   `json:"personId" binding:"omitempty,len=0|uuid"`
+	CustomTypeID  *string   `json:"customTypeId" binding:"omitempty,len=0|uuid"`
+	TagIDs        *[]string `json:"tagIds" binding:"omitempty,max=10,dive,uuid"`
+}
+
+// Document is renewed by its renewal period if the new expiration date is not set.
+type documentRenew struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Query parameters of the documents list.
+type documentsQuery struct {
+	Limit         int32    `form:"limit" binding:"min=0,max=100"`
+	Cursor        string   `form:"cursor" binding:"max=256"`
+	Sort          string   `form:"sort" binding:"omitempty,oneof=expiresAt createdAt type"`
+	Order         string   `form:"order" binding:"omitempty,oneof=asc desc"`
+	Types         []int32  `form:"type" binding:"dive,min=0,max=255"`
+	Expired       bool     `form:"expired"`
+	ExpiresWithin int32    `form:"expiresWithin" binding:"min=0,max=3650"` // Days
+	Person        string   `form:"person" binding:"omitempty,uuid"`
+	CustomTypes   []string `form:"customType" binding:"dive,uuid"`
+	Tags          []string `form:"tag" binding:"dive,uuid"` // Documents with any of the tags
+}
+
+// Query parameters of the documents statistics.
+type documentsStatisticsQuery struct {
+	Person string `form:"person" binding:"omitempty,uuid"`
+}
+
+// Query parameters of the documents search.
+type documentsSearchQuery struct {
+	Query string `form:"q" binding:"required,max=100"`
+	Limit int32  `form:"limit" binding:"min=0,max=100"`
+}
+
+// Maps sort query parameter to the documents list sort field.
+var documentSortFields = map[string]document.DocumentSort{
+	"":          document.DocumentSort_EXPIRES_AT,
+	"expiresAt": document.DocumentSort_EXPIRES_AT,
+	"createdAt": document.DocumentSort_CREATED_AT,
+	"type":      document.DocumentSort_TYPE,
 }
 
 // Call Create method on `document-service`.
@@ -46,11 +93,15 @@ func (app *Config) documentCreate(c *gin.Context) {
 	// call service
 	res, err := app.documentsClient.documentService.Create(ctx, &document.DocumentCreateRequest{
 		DocumentEntry: &document.Document{
-			UserID:      userID.(string),
-			Title:       documentPayload.Title,
-			Type:        document.Type(documentPayload.Type),
-			Description: documentPayload.Description,
-			ExpiresAt:   timestamppb.New(documentPayload.ExpiresAt),
+			UserID:        userID.(string),
+			Title:         documentPayload.Title,
+			Type:          document.Type(documentPayload.Type),
+			Description:   documentPayload.Description,
+			ExpiresAt:     timestamppb.New(documentPayload.ExpiresAt),
+			RenewalMonths: documentPayload.RenewalMonths,
+			PersonID:      documentPayload.PersonID,
+			CustomTypeID:  documentPayload.CustomTypeID,
+			TagIDs:        documentPayload.TagIDs,
 		},
 	})
 	if err != nil {
@@ -81,8 +132,13 @@ func (app *Config) documentEdit(c *gin.Context) {
 		return
 	}
 
-	// call service
-	_, err := app.documentsClient.documentService.Edit(ctx, &document.DocumentCreateRequest{
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	req := &document.DocumentCreateRequest{
 		DocumentEntry: &document.Document{
 			ID:          documentPayload.ID,
 			UserID:      userID.(string),
@@ -91,7 +147,27 @@ func (app *Config) documentEdit(c *gin.Context) {
 			Description: documentPayload.Description,
 			ExpiresAt:   timestamppb.New(documentPayload.ExpiresAt),
 		},
-	})
+		GroupIDs:         groupIDs,
+		SetPersonID:      documentPayload.PersonID != nil,
+		SetCustomTypeID:  documentPayload.CustomTypeID != nil,
+		SetTagIDs:        documentPayload.TagIDs != nil,
+		SetRenewalMonths: documentPayload.RenewalMonths != nil,
+	}
+	if documentPayload.RenewalMonths != nil {
+		req.DocumentEntry.RenewalMonths = *documentPayload.RenewalMonths
+	}
+	if documentPayload.PersonID != nil {
+		req.DocumentEntry.PersonID = *documentPayload.PersonID
+	}
+	if documentPayload.CustomTypeID != nil {
+		req.DocumentEntry.CustomTypeID = *documentPayload.CustomTypeID
+	}
+	if documentPayload.TagIDs != nil {
+		req.DocumentEntry.TagIDs = *documentPayload.TagIDs
+	}
+
+	// call service
+	_, err = app.documentsClient.documentService.Edit(ctx, req)
 	if err != nil {
 		log.Println("Error on calling document-service::Edit method:", err)
 		_ = c.Error(err)
@@ -99,9 +175,7 @@ func (app *Config) documentEdit(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		_, _ = app.updateIcsCalendar(userID.(string))
-	}()
+	go app.updateGroupCalendars(userID.(string))
 	c.Status(http.StatusCreated)
 }
 
@@ -133,9 +207,7 @@ func (app *Config) documentDelete(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		_, _ = app.updateIcsCalendar(userID.(string))
-	}()
+	go app.updateGroupCalendars(userID.(string))
 	c.Status(http.StatusOK)
 }
 
@@ -155,10 +227,17 @@ func (app *Config) documentGetOne(c *gin.Context) {
 		return
 	}
 
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	// call service
 	res, err := app.documentsClient.documentService.GetOne(ctx, &document.DocumentRequest{
 		DocumentID: uri.DocumentID,
 		UserID:     userID.(string),
+		GroupIDs:   groupIDs,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::GetOne method:", err)
@@ -171,27 +250,55 @@ func (app *Config) documentGetOne(c *gin.Context) {
 		Document *document.DocumentJSON `json:"document"`
 	}{
 		Document: &document.DocumentJSON{
-			ID:          res.Document.ID,
-			UserID:      res.Document.UserID,
-			Title:       res.Document.Title,
-			Type:        res.Document.Type,
-			Description: res.Document.Description,
-			ExpiresAt:   utils.ParseProtobufDateToString(res.Document.ExpiresAt),
+			ID:             res.Document.ID,
+			UserID:         res.Document.UserID,
+			Title:          res.Document.Title,
+			Type:           res.Document.Type,
+			Description:    res.Document.Description,
+			ExpiresAt:      utils.ParseProtobufDateToString(res.Document.ExpiresAt),
+			RenewalMonths:  res.Document.RenewalMonths,
+			PersonID:       res.Document.PersonID,
+			PersonName:     res.Document.PersonName,
+			CustomTypeID:   res.Document.CustomTypeID,
+			CustomTypeName: res.Document.CustomTypeName,
+			TagIDs:         res.Document.TagIDs,
 		},
 	})
 }
 
 // Call GetAll method on `document-service`.
+// All documents are returned if the limit is not set.
 func (app *Config) documentGetAll(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
+	query := documentsQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	types := make([]document.Type, len(query.Types))
+	for i, t := range query.Types {
+		types[i] = document.Type(t)
+	}
+
 	// get userID from context
 	userID, _ := c.Get("UserId")
 
 	// call service
 	res, err := app.documentsClient.documentService.GetAll(ctx, &document.DocumentsRequest{
-		UserID: userID.(string),
+		UserID:            userID.(string),
+		Limit:             query.Limit,
+		Cursor:            query.Cursor,
+		SortBy:            documentSortFields[query.Sort],
+		Descending:        query.Order == "desc",
+		Types:             types,
+		Expired:           query.Expired,
+		ExpiresWithinDays: query.ExpiresWithin,
+		PersonID:          query.Person,
+		CustomTypeIDs:     query.CustomTypes,
+		TagIDs:            query.Tags,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::GetAll method:", err)
@@ -200,6 +307,42 @@ func (app *Config) documentGetAll(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, struct {
+		Documents  []*document.DocumentJSON `json:"documents"`
+		NextCursor string                   `json:"nextCursor,omitempty"`
+	}{
+		Documents:  utils.ConvertDocumentsToJSON(res.Documents),
+		NextCursor: res.NextCursor,
+	})
+}
+
+// Call Search method on `document-service`.
+func (app *Config) documentSearch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	query := documentsSearchQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// call service
+	res, err := app.documentsClient.documentService.Search(ctx, &document.DocumentSearchRequest{
+		UserID: userID.(string),
+		Query:  query.Query,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Search method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
 	c.JSON(http.StatusOK, struct {
 		Documents []*document.DocumentJSON `json:"documents"`
 	}{
@@ -207,24 +350,241 @@ func (app *Config) documentGetAll(c *gin.Context) {
 	})
 }
 
+// Call ListDeleted method on `document-service`.
+func (app *Config) documentTrashGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// call service
+	res, err := app.documentsClient.documentService.ListDeleted(ctx, &document.DocumentsRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::ListDeleted method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Documents []*document.DocumentJSON `json:"documents"`
+	}{
+		Documents: utils.ConvertDocumentsToJSON(res.Documents),
+	})
+}
+
+// Call Restore method on `document-service`.
+func (app *Config) documentRestore(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	_, err := app.documentsClient.documentService.Restore(ctx, &document.DocumentRequest{
+		DocumentID: uri.DocumentID,
+		UserID:     userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Restore method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.Status(http.StatusOK)
+}
+
+// Call Purge method on `document-service`.
+func (app *Config) documentPurge(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	_, err := app.documentsClient.documentService.Purge(ctx, &document.DocumentRequest{
+		DocumentID: uri.DocumentID,
+		UserID:     userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Purge method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Call Renew method on `document-service`.
+func (app *Config) documentRenew(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Body is optional
+	payload := documentRenew{}
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&payload); err != nil {
+			_ = c.Error(ErrInvalidInputs)
+			return
+		}
+	}
+
+	req := &document.DocumentRenewRequest{
+		DocumentID: uri.DocumentID,
+		UserID:     userID.(string),
+	}
+	if payload.ExpiresAt != nil {
+		req.ExpiresAt = timestamppb.New(*payload.ExpiresAt)
+	}
+
+	// call service
+	res, err := app.documentsClient.documentService.Renew(ctx, req)
+	if err != nil {
+		log.Println("Error on calling document-service::Renew method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Notifications are shifted with the document
+	go app.updateGroupCalendars(userID.(string))
+
+	c.JSON(http.StatusOK, struct {
+		Renewal *document.RenewalJSON `json:"renewal"`
+	}{
+		Renewal: utils.ConvertRenewalToJSON(res.Renewal),
+	})
+}
+
+// Call GetRenewals method on `document-service`.
+func (app *Config) documentGetRenewals(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.documentService.GetRenewals(ctx, &document.DocumentRequest{
+		DocumentID: uri.DocumentID,
+		UserID:     userID.(string),
+		GroupIDs:   groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetRenewals method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Renewals []*document.RenewalJSON `json:"renewals"`
+	}{
+		Renewals: utils.ConvertRenewalsToJSON(res.Renewals),
+	})
+}
+
+// Counts of the documents by expiration date in JSON format, zero counts are not omitted.
+type documentsExpiryJSON struct {
+	Expired              int64 `json:"expired"`
+	Within30Days         int64 `json:"within30Days"`
+	Within90Days         int64 `json:"within90Days"`
+	Within365Days        int64 `json:"within365Days"`
+	WithoutNotifications int64 `json:"withoutNotifications"`
+}
+
+// Count of the documents expiring in the month in JSON format.
+type expiryMonthJSON struct {
+	Month string `json:"month"`
+	Count int64  `json:"count"`
+}
+
+// Counts of the notifications in JSON format.
+type notificationsStatisticsJSON struct {
+	Total     int64 `json:"total"`
+	Upcoming  int64 `json:"upcoming"`
+	Delivered int64 `json:"delivered"`
+}
+
 // TODO: Cache this route.
 func (app *Config) documentGetStatistics(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
+	query := documentsStatisticsQuery{}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
 	// get userID from context
 	userID, _ := c.Get("UserId")
 
 	var statistics struct {
-		TotalDocuments     int64                          `json:"totalDocuments"`
-		TotalNotifications int64                          `json:"totalNotifications"`
-		UsedTypes          []*document.DocumentTypesCount `json:"usedTypes"`
-		LatestDocuments    []*document.DocumentJSON       `json:"latestDocuments"`
+		TotalDocuments     int64                                `json:"totalDocuments"`
+		TotalNotifications int64                                `json:"totalNotifications"`
+		UsedTypes          []*document.DocumentTypesCount       `json:"usedTypes"`
+		UsedCustomTypes    []*document.DocumentCustomTypesCount `json:"usedCustomTypes"`
+		UsedTags           []*document.DocumentTagsCount        `json:"usedTags"`
+		LatestDocuments    []*document.DocumentJSON             `json:"latestDocuments"`
+		Expiry             documentsExpiryJSON                  `json:"expiry"`
+		ExpiryTimeline     []expiryMonthJSON                    `json:"expiryTimeline"`
+		Notifications      notificationsStatisticsJSON          `json:"notifications"`
 	}
 
 	// call services
 	getStats, err := app.documentsClient.documentService.GetUserStatistics(ctx, &document.DocumentsRequest{
-		UserID: userID.(string),
+		UserID:   userID.(string),
+		PersonID: query.Person,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::GetUserStatistics method:", err)
@@ -238,6 +598,28 @@ func (app *Config) documentGetStatistics(c *gin.Context) {
 	// Nasty fix to return empty array instead of null.
 	// For some reason, even after initializing this array of pointers, it returns null.
 	statistics.UsedTypes = append([]*document.DocumentTypesCount{}, getStats.Types...)
+	statistics.UsedCustomTypes = append([]*document.DocumentCustomTypesCount{}, getStats.CustomTypes...)
+	statistics.UsedTags = append([]*document.DocumentTagsCount{}, getStats.Tags...)
+	statistics.Expiry = documentsExpiryJSON{
+		Expired:              getStats.GetExpiry().GetExpired(),
+		Within30Days:         getStats.GetExpiry().GetWithin30Days(),
+		Within90Days:         getStats.GetExpiry().GetWithin90Days(),
+		Within365Days:        getStats.GetExpiry().GetWithin365Days(),
+		WithoutNotifications: getStats.GetExpiry().GetWithoutNotifications(),
+	}
+	statistics.Notifications = notificationsStatisticsJSON{
+		Total:     getStats.GetNotifications().GetTotal(),
+		Upcoming:  getStats.GetNotifications().GetUpcoming(),
+		Delivered: getStats.GetNotifications().GetDelivered(),
+	}
+
+	statistics.ExpiryTimeline = make([]expiryMonthJSON, 0, len(getStats.ExpiryTimeline))
+	for _, m := range getStats.ExpiryTimeline {
+		statistics.ExpiryTimeline = append(statistics.ExpiryTimeline, expiryMonthJSON{
+			Month: utils.ParseProtobufDateToString(m.Month),
+			Count: m.Count,
+		})
+	}
 
 	totalNotificationsCount, err := app.documentsClient.notificationService.CountAll(
 		ctx,
@@ -12,6 +12,9 @@ var (
 	ErrInvalidInputs    = errors.New("invalid inputs")
 	ErrInvalidCaptcha   = errors.New("invalid captcha")
 	ErrEmailNotVerified = errors.New("email not verified")
+	ErrTooManyRequests  = errors.New("too many requests, try again later")
+	ErrCaptchaRequired  = errors.New("captcha is required")
+	ErrFileTooLarge     = errors.New("file is too large")
 )
 
 var ErrorsArr = []error{
@@ -19,6 +22,9 @@ var ErrorsArr = []error{
 	ErrInvalidInputs,
 	ErrInvalidCaptcha,
 	ErrEmailNotVerified,
+	ErrTooManyRequests,
+	ErrCaptchaRequired,
+	ErrFileTooLarge,
 }
 
 // ErrorStatus map error types to HTTP status codes.
@@ -27,6 +33,9 @@ var ErrorStatus = map[error]int{
 	ErrInvalidInputs:    http.StatusBadRequest,
 	ErrInvalidCaptcha:   http.StatusBadRequest,
 	ErrEmailNotVerified: http.StatusUnauthorized,
+	ErrTooManyRequests:  http.StatusTooManyRequests,
+	ErrCaptchaRequired:  http.StatusPreconditionRequired,
+	ErrFileTooLarge:     http.StatusRequestEntityTooLarge,
 }
 
 // RPCStatus maps gRPC codes to HTTP status codes.
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/document"
+	"github.com/samgozman/validity.red/broker/proto/user"
+)
+
+// Export decrypts all user's documents, so it is slower than the other requests.
+const exportTimeout = 10 * time.Second
+
+// Export file format with its extension and content type.
+type exportFile struct {
+	format      document.ExportFormat
+	extension   string
+	contentType string
+}
+
+// Maps format query parameter to the export file.
+var exportFiles = map[string]exportFile{
+	"csv":  {format: document.ExportFormat_EXPORT_CSV, extension: "csv", contentType: "text/csv; charset=utf-8"},
+	"json": {format: document.ExportFormat_EXPORT_JSON, extension: "json", contentType: "application/json; charset=utf-8"},
+	"pdf":  {format: document.ExportFormat_EXPORT_PDF, extension: "pdf", contentType: "application/pdf"},
+}
+
+// Call Export method on Document in `document-service` and send the file.
+func (app *Config) documentExport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	query := struct {
+		Format string `form:"format" binding:"required,oneof=csv json pdf"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindQuery(&query); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	file := exportFiles[query.Format]
+
+	// Dates of the PDF summary are written in the user's timezone
+	profile, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.documentService.Export(ctx, &document.DocumentExportRequest{
+		UserID:   userID.(string),
+		Format:   file.format,
+		Timezone: profile.Timezone,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Export method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	fileName := "validity-documents-" + time.Now().Format("2006-01-02") + "." + file.extension
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, file.contentType, res.Content)
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/utils"
+	"github.com/samgozman/validity.red/broker/proto/document"
+	"github.com/samgozman/validity.red/broker/proto/user"
+)
+
+type groupCreatePayload struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type groupMemberPayload struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type groupJSON struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	OwnerID string             `json:"ownerId"`
+	Members []*groupMemberJSON `json:"members"`
+}
+
+type groupInvitationJSON struct {
+	GroupID    string `json:"groupId"`
+	GroupName  string `json:"groupName"`
+	OwnerEmail string `json:"ownerEmail"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type groupMemberJSON struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// Call GetAll method on GroupService in `user-service`.
+func (app *Config) groupGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	groups, err := app.userGroups(ctx, userID.(string))
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	res := make([]*groupJSON, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, convertGroupToJSON(g))
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Groups []*groupJSON `json:"groups"`
+	}{
+		Groups: res,
+	})
+}
+
+// Call Create method on GroupService in `user-service`.
+func (app *Config) groupCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := groupCreatePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.usersClient.groupService.Create(ctx, &user.CreateGroupRequest{
+		UserId: userID.(string),
+		Name:   payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::Create method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		Group *groupJSON `json:"group"`
+	}{
+		Group: convertGroupToJSON(res.Group),
+	})
+}
+
+// Call Delete method on GroupService in `user-service` and delete all shares of the group.
+func (app *Config) groupDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		GroupID string `uri:"groupId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Members lose access to the shared documents, so their calendars should be updated
+	members, err := app.groupMemberIDs(ctx, userID.(string), uri.GroupID)
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	_, err = app.usersClient.groupService.Delete(ctx, &user.GroupRequest{
+		UserId:  userID.(string),
+		GroupId: uri.GroupID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::Delete method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	_, err = app.documentsClient.documentService.DeleteGroupShares(ctx, &document.GroupSharesRequest{
+		GroupID: uri.GroupID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::DeleteGroupShares method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateCalendars(members)
+	c.Status(http.StatusOK)
+}
+
+// Call InviteMember method on GroupService in `user-service`.
+// Response is the same whether the email is registered or not.
+func (app *Config) groupMemberInvite(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		GroupID string `uri:"groupId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	payload := groupMemberPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.usersClient.groupService.InviteMember(ctx, &user.GroupMemberRequest{
+		UserId:  userID.(string),
+		GroupId: uri.GroupID,
+		Email:   payload.Email,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::InviteMember method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Call GetInvitations method on GroupService in `user-service`.
+func (app *Config) groupInvitationGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.usersClient.groupService.GetInvitations(ctx, &user.GetGroupsRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetInvitations method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	invitations := make([]*groupInvitationJSON, 0, len(res.Invitations))
+	for _, inv := range res.Invitations {
+		invitations = append(invitations, convertInvitationToJSON(inv))
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Invitations []*groupInvitationJSON `json:"invitations"`
+	}{
+		Invitations: invitations,
+	})
+}
+
+// Call AcceptInvitation method on GroupService in `user-service`.
+func (app *Config) groupInvitationAccept(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		GroupID string `uri:"groupId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.usersClient.groupService.AcceptInvitation(ctx, &user.GroupRequest{
+		UserId:  userID.(string),
+		GroupId: uri.GroupID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::AcceptInvitation method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.JSON(http.StatusOK, struct {
+		Group *groupJSON `json:"group"`
+	}{
+		Group: convertGroupToJSON(res.Group),
+	})
+}
+
+// Call DeclineInvitation method on GroupService in `user-service`.
+func (app *Config) groupInvitationDecline(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		GroupID string `uri:"groupId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.usersClient.groupService.DeclineInvitation(ctx, &user.GroupRequest{
+		UserId:  userID.(string),
+		GroupId: uri.GroupID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::DeclineInvitation method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Call RemoveMember method on GroupService in `user-service`
+// and delete the documents shares of the removed member in the group.
+func (app *Config) groupMemberRemove(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		GroupID string `uri:"groupId" binding:"required,uuid"`
+		UserID  string `uri:"userId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	members, err := app.groupMemberIDs(ctx, userID.(string), uri.GroupID)
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	_, err = app.usersClient.groupService.RemoveMember(ctx, &user.GroupMemberRequest{
+		UserId:   userID.(string),
+		GroupId:  uri.GroupID,
+		MemberId: uri.UserID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::group::RemoveMember method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	_, err = app.documentsClient.documentService.DeleteGroupShares(ctx, &document.GroupSharesRequest{
+		GroupID: uri.GroupID,
+		UserID:  uri.UserID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::DeleteGroupShares method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateCalendars(members)
+	c.Status(http.StatusOK)
+}
+
+// Get groups which the user is a member of.
+func (app *Config) userGroups(ctx context.Context, userID string) ([]*user.Group, error) {
+	res, err := app.usersClient.groupService.GetAll(ctx, &user.GetGroupsRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Groups, nil
+}
+
+// Get IDs of the user's groups, used to access the documents shared with them.
+func (app *Config) userGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	groups, err := app.userGroups(ctx, userID)
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.Id)
+	}
+
+	return ids, nil
+}
+
+// Get IDs of all members of the user's group.
+func (app *Config) groupMemberIDs(ctx context.Context, userID, groupID string) ([]string, error) {
+	groups, err := app.userGroups(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, g := range groups {
+		if g.Id != groupID {
+			continue
+		}
+
+		for _, m := range g.Members {
+			ids = append(ids, m.UserId)
+		}
+	}
+
+	return ids, nil
+}
+
+// Re-create calendars of the user and of all members of the user's groups,
+// since their calendars include the documents shared with the groups.
+func (app *Config) updateGroupCalendars(userID string) {
+	const requestTimeout = 3 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	ids := []string{userID}
+
+	groups, err := app.userGroups(ctx, userID)
+	if err != nil {
+		log.Println("Error on calling user-service::group::GetAll method:", err)
+	}
+
+	for _, g := range groups {
+		for _, m := range g.Members {
+			ids = append(ids, m.UserId)
+		}
+	}
+
+	app.updateCalendars(ids)
+}
+
+// Re-create calendars of the users, each calendar is updated once.
+func (app *Config) updateCalendars(userIDs []string) {
+	updated := make(map[string]bool, len(userIDs))
+
+	for _, id := range userIDs {
+		if updated[id] {
+			continue
+		}
+
+		updated[id] = true
+		_, _ = app.updateIcsCalendar(id)
+	}
+}
+
+func convertGroupToJSON(g *user.Group) *groupJSON {
+	members := make([]*groupMemberJSON, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, &groupMemberJSON{
+			UserID: m.UserId,
+			Email:  m.Email,
+			Role:   m.Role,
+		})
+	}
+
+	return &groupJSON{
+		ID:      g.Id,
+		Name:    g.Name,
+		OwnerID: g.OwnerId,
+		Members: members,
+	}
+}
+
+func convertInvitationToJSON(inv *user.GroupInvitation) *groupInvitationJSON {
+	return &groupInvitationJSON{
+		GroupID:    inv.GroupId,
+		GroupName:  inv.GroupName,
+		OwnerEmail: inv.OwnerEmail,
+		CreatedAt:  utils.ParseProtobufDateToString(inv.CreatedAt),
+	}
+}
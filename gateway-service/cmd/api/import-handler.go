@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/document"
+)
+
+// Import of many documents is slower than the other requests because of encryption.
+const importTimeout = 10 * time.Second
+
+// Max size of the imported documents file in bytes.
+const maxImportFileSize = 1024 * 1024
+
+// Error of the row which was not imported, row is not omitted for the consistency.
+type importRowErrorJSON struct {
+	Row     int32  `json:"row"`
+	Message string `json:"message"`
+}
+
+// Call Import method on Document in `document-service`.
+// CSV or JSON file is sent as the "file" field of the multipart form.
+func (app *Config) documentImport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			_ = c.Error(ErrFileTooLarge)
+			return
+		}
+
+		_ = c.Error(ErrInvalidInputs)
+
+		return
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		_ = c.Error(ErrFileTooLarge)
+		return
+	}
+
+	format, ok := importFormat(fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+	if !ok {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.documentService.Import(ctx, &document.DocumentImportRequest{
+		UserID:  userID.(string),
+		Format:  format,
+		Content: content,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Import method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Documents can get reminders from the user's presets
+	if len(res.DocumentIDs) > 0 {
+		go app.updateGroupCalendars(userID.(string))
+	}
+
+	rowErrors := make([]importRowErrorJSON, 0, len(res.Errors))
+	for _, e := range res.Errors {
+		rowErrors = append(rowErrors, importRowErrorJSON{
+			Row:     e.Row,
+			Message: e.Message,
+		})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Imported    int                  `json:"imported"`
+		DocumentIDs []string             `json:"documentIDs"`
+		Errors      []importRowErrorJSON `json:"errors"`
+	}{
+		Imported:    len(res.DocumentIDs),
+		DocumentIDs: append([]string{}, res.DocumentIDs...),
+		Errors:      rowErrors,
+	})
+}
+
+// Get format of the imported file by its extension or content type.
+func importFormat(fileName, contentType string) (document.ImportFormat, bool) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return document.ImportFormat_CSV, true
+	case ".json":
+		return document.ImportFormat_JSON, true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return document.ImportFormat_CSV, true
+	case "application/json":
+		return document.ImportFormat_JSON, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/samgozman/validity.red/broker/proto/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Redis counter of failed logins for the account (by email) or for the client IP.
+func loginFailuresKey(kind, id string) string {
+	return "auth:login:failures:" + kind + ":" + id
+}
+
+// Redis key which exists while the account is temporary locked.
+func loginLockKey(email string) string {
+	return "auth:login:lock:" + email
+}
+
+// Normalize email, so the same account can not be brute-forced with different letter cases.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check if login is allowed for the account and IP.
+//
+// Returns time left until the lockout ends (zero if login is allowed)
+// and whether hCaptcha should be solved because of previous failures.
+func (app *Config) loginStatus(ctx context.Context, email, ip string) (lockedFor time.Duration, captchaRequired bool, err error) {
+	email = normalizeLoginEmail(email)
+
+	pipe := app.redisClient.Pipeline()
+	accountLock := pipe.PTTL(ctx, loginLockKey(email))
+	accountFailures := pipe.Get(ctx, loginFailuresKey("account", email))
+	ipFailures := pipe.Get(ctx, loginFailuresKey("ip", ip))
+	ipFailuresTTL := pipe.PTTL(ctx, loginFailuresKey("ip", ip))
+
+	_, err = pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, false, err
+	}
+
+	if ttl := accountLock.Val(); ttl > 0 {
+		return ttl, false, nil
+	}
+
+	// Errors are redis.Nil here, which means no failures yet
+	accountCount, _ := accountFailures.Int64()
+	ipCount, _ := ipFailures.Int64()
+
+	if ipCount >= int64(app.options.LoginIPLockoutAfter) {
+		return ipFailuresTTL.Val(), false, nil
+	}
+
+	captchaRequired = accountCount >= int64(app.options.LoginCaptchaAfter) ||
+		ipCount >= int64(app.options.LoginIPCaptchaAfter)
+
+	return 0, captchaRequired, nil
+}
+
+// Count failed login for the account and IP.
+//
+// Locks the account with exponential backoff after LoginLockoutAfter failures in a row.
+// Returns the lockout duration and whether this failure started the lockout.
+func (app *Config) registerLoginFailure(ctx context.Context, email, ip string) (lockedFor time.Duration, firstLock bool, err error) {
+	email = normalizeLoginEmail(email)
+	window := time.Second * time.Duration(app.options.LoginFailureWindow)
+
+	pipe := app.redisClient.TxPipeline()
+	accountFailures := pipe.Incr(ctx, loginFailuresKey("account", email))
+	pipe.Expire(ctx, loginFailuresKey("account", email), window)
+	pipe.Incr(ctx, loginFailuresKey("ip", ip))
+	pipe.Expire(ctx, loginFailuresKey("ip", ip), window)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	over := accountFailures.Val() - int64(app.options.LoginLockoutAfter)
+	if over < 0 {
+		return 0, false, nil
+	}
+
+	// Double the lockout for every next failure
+	lockedFor = time.Second * time.Duration(app.options.LoginLockoutBase)
+	maxLockout := time.Second * time.Duration(app.options.LoginLockoutMax)
+
+	for i := int64(0); i < over && lockedFor < maxLockout; i++ {
+		lockedFor *= 2
+	}
+
+	if lockedFor > maxLockout {
+		lockedFor = maxLockout
+	}
+
+	err = app.redisClient.Set(ctx, loginLockKey(email), 1, lockedFor).Err()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return lockedFor, over == 0, nil
+}
+
+// Reset failed logins counter of the account after successful login.
+func (app *Config) resetLoginFailures(ctx context.Context, email string) error {
+	return app.redisClient.Del(ctx, loginFailuresKey("account", normalizeLoginEmail(email))).Err()
+}
+
+// Count failed login and notify the account owner if the account was locked.
+//
+// accountExists - whether the email belongs to the registered user.
+func (app *Config) handleLoginFailure(ctx context.Context, email, ip string, accountExists bool) {
+	lockedFor, firstLock, err := app.registerLoginFailure(ctx, email, ip)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("registerLoginFailure error: %w", err))
+		return
+	}
+
+	if !firstLock || !accountExists {
+		return
+	}
+
+	log.Printf("Login for '%s' is locked for %s after failed attempts", email, lockedFor)
+
+	if app.options.Environment == "production" {
+		err := app.mailer.SendLoginLockout(email, lockedFor)
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("SendLoginLockout error: %w", err))
+		}
+	}
+}
+
+// Confirm the password of the logged in user for the sensitive action.
+//
+// Failures are counted together with the failed logins, so the password can't be
+// brute-forced with a stolen session. Returns ErrTooManyRequests while the account is locked.
+func (app *Config) confirmPassword(ctx context.Context, c *gin.Context, email, password string) error {
+	lockedFor, _, err := app.loginStatus(ctx, email, c.ClientIP())
+	if err != nil {
+		return err
+	}
+
+	if lockedFor > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedFor.Seconds()))))
+		return ErrTooManyRequests
+	}
+
+	_, err = app.usersClient.authService.Login(ctx, &user.AuthRequest{
+		AuthEntry: &user.Auth{
+			Email:    email,
+			Password: password,
+		},
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			app.handleLoginFailure(ctx, email, c.ClientIP(), true)
+		}
+
+		return err
+	}
+
+	err = app.resetLoginFailures(ctx, email)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("resetLoginFailures error: %w", err))
+	}
+
+	return nil
+}
+
+// Confirm the password of the user found by ID, see confirmPassword.
+func (app *Config) confirmUserPassword(ctx context.Context, c *gin.Context, userID, password string) error {
+	profile, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetUser method:", err)
+		return err
+	}
+
+	return app.confirmPassword(ctx, c, profile.Email, password)
+}
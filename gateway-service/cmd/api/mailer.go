@@ -1,8 +1,16 @@
 package main
 
+import (
+	"time"
+
+	"github.com/samgozman/validity.red/broker/internal/digest"
+)
+
 // Mailer is an interface for sending emails.
 type Mailer interface {
 	SendEmailVerification(email, tokenURL string) error
-	// SendPasswordReset(email, token string) error
+	SendPasswordReset(email, tokenURL string) error
+	SendLoginLockout(email string, lockedFor time.Duration) error
+	SendDigest(email string, d *digest.Digest) error
 	// TODO: Send email with "how to use" instructions
 }
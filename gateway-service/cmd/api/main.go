@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -20,10 +21,22 @@ import (
 )
 
 type options struct {
-	JWTAuthTTL         int    // JWT auth token TTL in seconds
-	JWTVerificationTTL int    // JWT email verification token TTL in seconds
-	AppURL             string // Application API URL
-	Environment        string // Application environment (development or production)
+	JWTAuthTTL                 int    // JWT auth token TTL in seconds
+	JWTVerificationTTL         int    // JWT email verification token TTL in seconds
+	JWTPasswordResetTTL        int    // JWT password reset token TTL in seconds
+	RefreshTokenTTL            int    // Refresh token (and session) TTL in seconds
+	TwoFactorChallengeTTL      int    // Time to enter the second factor code after password login in seconds
+	LoginFailureWindow         int    // Failed logins are counted until no new failures happen during this time in seconds
+	LoginCaptchaAfter          int    // Failed logins for the account after which hCaptcha is required
+	LoginLockoutAfter          int    // Failed logins for the account after which it is temporary locked
+	LoginLockoutBase           int    // First lockout duration in seconds, doubled with every next failure
+	LoginLockoutMax            int    // Max lockout duration in seconds
+	LoginIPCaptchaAfter        int    // Failed logins from the IP after which hCaptcha is required
+	LoginIPLockoutAfter        int    // Failed logins from the IP after which all logins from it are blocked
+	VerificationResendCooldown int    // Min time between verification emails to the same address in seconds
+	MaxAttachmentSize          int64  // Max size of the uploaded attachment file in bytes
+	AppURL                     string // Application API URL
+	Environment                string // Application environment (development or production)
 }
 
 type Config struct {
@@ -38,13 +51,15 @@ type Config struct {
 }
 
 type UsersClient struct {
-	authService user.AuthServiceClient
-	userService user.UserServiceClient
+	authService  user.AuthServiceClient
+	userService  user.UserServiceClient
+	groupService user.GroupServiceClient
 }
 
 type DocumentsClient struct {
 	documentService     document.DocumentServiceClient
 	notificationService document.NotificationServiceClient
+	attachmentService   document.AttachmentServiceClient
 }
 
 type CalendarsClient struct {
@@ -72,8 +87,9 @@ func main() {
 	defer userServiceConn.Close()
 
 	usersClient := UsersClient{
-		authService: user.NewAuthServiceClient(userServiceConn),
-		userService: user.NewUserServiceClient(userServiceConn),
+		authService:  user.NewAuthServiceClient(userServiceConn),
+		userService:  user.NewUserServiceClient(userServiceConn),
+		groupService: user.NewGroupServiceClient(userServiceConn),
 	}
 	// USERS CLIENT SECTION - END //
 
@@ -88,6 +104,7 @@ func main() {
 	documentsClient := DocumentsClient{
 		documentService:     document.NewDocumentServiceClient(documentServiceConn),
 		notificationService: document.NewNotificationServiceClient(documentServiceConn),
+		attachmentService:   document.NewAttachmentServiceClient(documentServiceConn),
 	}
 	// DOCUMENTS CLIENT SECTION - END //
 
@@ -123,10 +140,22 @@ func main() {
 
 	app := Config{
 		options: options{
-			JWTAuthTTL:         10 * 60,      // 10 minutes
-			JWTVerificationTTL: 24 * 60 * 60, // 24 hours
-			AppURL:             os.Getenv("HOST_URL"),
-			Environment:        os.Getenv("ENVIRONMENT"),
+			JWTAuthTTL:                 10 * 60,           // 10 minutes
+			JWTVerificationTTL:         24 * 60 * 60,      // 24 hours
+			JWTPasswordResetTTL:        60 * 60,           // 1 hour
+			RefreshTokenTTL:            30 * 24 * 60 * 60, // 30 days
+			TwoFactorChallengeTTL:      5 * 60,            // 5 minutes
+			LoginFailureWindow:         60 * 60,           // 1 hour
+			LoginCaptchaAfter:          3,                 // 3 failed logins
+			LoginLockoutAfter:          5,                 // 5 failed logins
+			LoginLockoutBase:           60,                // 1 minute
+			LoginLockoutMax:            60 * 60,           // 1 hour
+			LoginIPCaptchaAfter:        10,                // 10 failed logins
+			LoginIPLockoutAfter:        50,                // 50 failed logins
+			VerificationResendCooldown: 5 * 60,            // 5 minutes
+			MaxAttachmentSize:          10 * 1024 * 1024,  // 10 MB
+			AppURL:                     os.Getenv("HOST_URL"),
+			Environment:                os.Getenv("ENVIRONMENT"),
 		},
 		token:           &token,
 		usersClient:     &usersClient,
@@ -137,6 +166,9 @@ func main() {
 		hcaptcha:        hcaptcha.New(os.Getenv("HCAPTCHA_SECRET")),
 	}
 
+	// Send weekly and monthly digests of the upcoming expirations
+	go app.setupDigest().Run(context.Background())
+
 	router := app.routes()
 	err = router.Run(fmt.Sprintf(":%s", os.Getenv("GATEWAY_PORT")))
 
@@ -16,6 +16,29 @@ type notificationPayload struct {
 	Date time.Time `json:"date" binding:"required"`
 }
 
+type reminderRulePayload struct {
+	DaysBefore int32 `json:"daysBefore" binding:"min=0,max=3650"`
+}
+
+// Reminder rule in JSON format, days before are not omitted if 0 (expiry day).
+type reminderRuleJSON struct {
+	ID         string `json:"ID"`
+	DocumentID string `json:"documentID"`
+	DaysBefore int32  `json:"daysBefore"`
+}
+
+type reminderPresetPayload struct {
+	Type       int32 `json:"type" binding:"number,min=0,max=255"`
+	DaysBefore int32 `json:"daysBefore" binding:"min=0,max=3650"`
+}
+
+// Reminder preset in JSON format, type and days before are not omitted if 0.
+type reminderPresetJSON struct {
+	ID         string `json:"ID"`
+	Type       int32  `json:"type"`
+	DaysBefore int32  `json:"daysBefore"`
+}
+
 type notificationModifyPayload struct {
 	ID         string `uri:"id" binding:"required,uuid"`
 	DocumentID string `uri:"documentId" binding:"required,uuid"`
@@ -44,13 +67,20 @@ func (app *Config) documentNotificationCreate(c *gin.Context) {
 		return
 	}
 
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	// call service
-	_, err := app.documentsClient.notificationService.Create(ctx, &document.NotificationCreateRequest{
+	_, err = app.documentsClient.notificationService.Create(ctx, &document.NotificationCreateRequest{
 		NotificationEntry: &document.Notification{
 			DocumentID: uri.DocumentID,
 			Date:       timestamppb.New(payload.Date),
 		},
-		UserID: userID.(string),
+		UserID:   userID.(string),
+		GroupIDs: groupIDs,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::notification::Create method:", err)
@@ -59,9 +89,7 @@ func (app *Config) documentNotificationCreate(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		_, _ = app.updateIcsCalendar(userID.(string))
-	}()
+	go app.updateGroupCalendars(userID.(string))
 	c.Status(http.StatusCreated)
 }
 
@@ -81,13 +109,20 @@ func (app *Config) documentNotificationDelete(c *gin.Context) {
 		return
 	}
 
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	// call service
-	_, err := app.documentsClient.notificationService.Delete(ctx, &document.NotificationCreateRequest{
+	_, err = app.documentsClient.notificationService.Delete(ctx, &document.NotificationCreateRequest{
 		NotificationEntry: &document.Notification{
 			ID:         uri.ID,
 			DocumentID: uri.DocumentID,
 		},
-		UserID: userID.(string),
+		UserID:   userID.(string),
+		GroupIDs: groupIDs,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::notification::Delete method:", err)
@@ -96,9 +131,7 @@ func (app *Config) documentNotificationDelete(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		_, _ = app.updateIcsCalendar(userID.(string))
-	}()
+	go app.updateGroupCalendars(userID.(string))
 	c.Status(http.StatusOK)
 }
 
@@ -120,10 +153,17 @@ func (app *Config) documentNotificationGetAll(c *gin.Context) {
 		return
 	}
 
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
 	// call service
 	res, err := app.documentsClient.notificationService.GetAll(ctx, &document.NotificationsRequest{
 		DocumentID: uri.DocumentID,
 		UserID:     userID.(string),
+		GroupIDs:   groupIDs,
 	})
 	if err != nil {
 		log.Println("Error on calling document-service::notification::GetAll method:", err)
@@ -138,3 +178,257 @@ func (app *Config) documentNotificationGetAll(c *gin.Context) {
 		Notifications: utils.ConvertNotificationsToJSON(res.Notifications),
 	})
 }
+
+// Call CreateRule method on Notification in `document-service`.
+func (app *Config) documentReminderRuleCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	payload := reminderRulePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.notificationService.CreateRule(ctx, &document.ReminderRuleCreateRequest{
+		UserID:     userID.(string),
+		DocumentID: uri.DocumentID,
+		DaysBefore: payload.DaysBefore,
+		GroupIDs:   groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::CreateRule method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.JSON(http.StatusCreated, struct {
+		Rule reminderRuleJSON `json:"rule"`
+	}{
+		Rule: reminderRuleJSON{
+			ID:         res.Rule.ID,
+			DocumentID: res.Rule.DocumentID,
+			DaysBefore: res.Rule.DaysBefore,
+		},
+	})
+}
+
+// Call DeleteRule method on Notification in `document-service`.
+func (app *Config) documentReminderRuleDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := notificationModifyPayload{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// call service
+	_, err = app.documentsClient.notificationService.DeleteRule(ctx, &document.ReminderRuleRequest{
+		UserID:     userID.(string),
+		DocumentID: uri.DocumentID,
+		RuleID:     uri.ID,
+		GroupIDs:   groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::DeleteRule method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.Status(http.StatusOK)
+}
+
+// Call GetRules method on Notification in `document-service`.
+func (app *Config) documentReminderRuleGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := struct {
+		DocumentID string `uri:"documentId" binding:"required,uuid"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.notificationService.GetRules(ctx, &document.NotificationsRequest{
+		UserID:     userID.(string),
+		DocumentID: uri.DocumentID,
+		GroupIDs:   groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::GetRules method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	rules := make([]reminderRuleJSON, 0, len(res.Rules))
+	for _, r := range res.Rules {
+		rules = append(rules, reminderRuleJSON{
+			ID:         r.ID,
+			DocumentID: r.DocumentID,
+			DaysBefore: r.DaysBefore,
+		})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Rules []reminderRuleJSON `json:"rules"`
+	}{
+		Rules: rules,
+	})
+}
+
+// Call CreatePreset method on Notification in `document-service`.
+func (app *Config) userReminderPresetCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := reminderPresetPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	res, err := app.documentsClient.notificationService.CreatePreset(ctx, &document.ReminderPresetCreateRequest{
+		UserID:     userID.(string),
+		Type:       document.Type(payload.Type),
+		DaysBefore: payload.DaysBefore,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::CreatePreset method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		Preset reminderPresetJSON `json:"preset"`
+	}{
+		Preset: reminderPresetJSON{
+			ID:         res.Preset.ID,
+			Type:       int32(res.Preset.Type),
+			DaysBefore: res.Preset.DaysBefore,
+		},
+	})
+}
+
+// Call DeletePreset method on Notification in `document-service`.
+func (app *Config) userReminderPresetDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	uri := struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}{}
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// Validate inputs
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// call service
+	_, err := app.documentsClient.notificationService.DeletePreset(ctx, &document.ReminderPresetRequest{
+		UserID:   userID.(string),
+		PresetID: uri.ID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::DeletePreset method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Call GetPresets method on Notification in `document-service`.
+func (app *Config) userReminderPresetGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	// call service
+	res, err := app.documentsClient.notificationService.GetPresets(ctx, &document.NotificationsAllRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::notification::GetPresets method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	presets := make([]reminderPresetJSON, 0, len(res.Presets))
+	for _, p := range res.Presets {
+		presets = append(presets, reminderPresetJSON{
+			ID:         p.ID,
+			Type:       int32(p.Type),
+			DaysBefore: p.DaysBefore,
+		})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Presets []reminderPresetJSON `json:"presets"`
+	}{
+		Presets: presets,
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/document"
+)
+
+type personPayload struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// Person in JSON format.
+type personJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Call GetPersons method on `document-service`.
+func (app *Config) personGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.documentsClient.documentService.GetPersons(ctx, &document.DocumentsRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetPersons method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	persons := make([]*personJSON, 0, len(res.Persons))
+	for _, p := range res.Persons {
+		persons = append(persons, &personJSON{ID: p.ID, Name: p.Name})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Persons []*personJSON `json:"persons"`
+	}{
+		Persons: persons,
+	})
+}
+
+// Call CreatePerson method on `document-service`.
+func (app *Config) personCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := personPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.CreatePerson(ctx, &document.PersonRequest{
+		UserID: userID.(string),
+		Name:   payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::CreatePerson method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		Person *personJSON `json:"person"`
+	}{
+		Person: &personJSON{ID: res.Person.ID, Name: res.Person.Name},
+	})
+}
+
+// Call EditPerson method on `document-service`.
+// Person's name is a part of the calendar events titles, so the calendars are updated.
+func (app *Config) personEdit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		PersonID string `uri:"personId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	payload := personPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.EditPerson(ctx, &document.PersonRequest{
+		UserID:   userID.(string),
+		PersonID: uri.PersonID,
+		Name:     payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::EditPerson method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.JSON(http.StatusOK, struct {
+		Person *personJSON `json:"person"`
+	}{
+		Person: &personJSON{ID: res.Person.ID, Name: res.Person.Name},
+	})
+}
+
+// Call DeletePerson method on `document-service`.
+func (app *Config) personDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		PersonID string `uri:"personId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.documentsClient.documentService.DeletePerson(ctx, &document.PersonRequest{
+		UserID:   userID.(string),
+		PersonID: uri.PersonID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::DeletePerson method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.Status(http.StatusOK)
+}
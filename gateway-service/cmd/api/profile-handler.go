@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/token"
+	"github.com/samgozman/validity.red/broker/proto/user"
+)
+
+type profileUpdatePayload struct {
+	Timezone string `json:"timezone" binding:"required,timezone"`
+}
+
+type passwordChangePayload struct {
+	CurrentPassword string `json:"currentPassword" binding:"required,max=64"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=64"`
+}
+
+type emailChangePayload struct {
+	CurrentPassword string `json:"currentPassword" binding:"required,max=64"`
+	Email           string `json:"email" binding:"required,email"`
+}
+
+// Get current user profile from `user-service`.
+func (app *Config) userGetProfile(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetUser method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Email      string `json:"email"`
+		Timezone   string `json:"timezone"`
+		IsVerified bool   `json:"isVerified"`
+	}{
+		Email:      res.Email,
+		Timezone:   res.Timezone,
+		IsVerified: res.IsVerified,
+	})
+}
+
+// Call UpdateProfile method on `user-service` and regenerate user's calendar.
+func (app *Config) userUpdateProfile(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := profileUpdatePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.usersClient.userService.UpdateProfile(ctx, &user.UpdateProfileRequest{
+		UserId:   userID.(string),
+		Timezone: payload.Timezone,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::UpdateProfile method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Calendar events are created in the user's timezone
+	go func() {
+		_, _ = app.updateIcsCalendar(userID.(string))
+	}()
+	c.Status(http.StatusAccepted)
+}
+
+// Call ChangePassword method on `user-service`.
+// Revokes all user sessions except the current one.
+func (app *Config) userChangePassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := passwordChangePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Failed confirmations are throttled like failed logins
+	err := app.confirmUserPassword(ctx, c, userID.(string), payload.CurrentPassword)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_, err = app.usersClient.userService.ChangePassword(ctx, &user.ChangePasswordRequest{
+		UserId:          userID.(string),
+		CurrentPassword: payload.CurrentPassword,
+		NewPassword:     payload.NewPassword,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::ChangePassword method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	err = app.revokeUserSessions(ctx, userID.(string))
+	if err != nil {
+		log.Println("Error on revoking user sessions:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Start new session for the current device
+	err = app.startSession(ctx, c, userID.(string))
+	if err != nil {
+		log.Println("Error on starting user session:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Call ChangeEmail method on `user-service` and send confirmation link to the new email.
+func (app *Config) userChangeEmail(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := emailChangePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Failed confirmations are throttled like failed logins
+	err := app.confirmUserPassword(ctx, c, userID.(string), payload.CurrentPassword)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_, err = app.usersClient.userService.ChangeEmail(ctx, &user.ChangeEmailRequest{
+		UserId:          userID.(string),
+		CurrentPassword: payload.CurrentPassword,
+		NewEmail:        payload.Email,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::ChangeEmail method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Create confirmation token, the previous one is replaced in Redis
+	confirmationToken, err := app.token.Generate(userID.(string), token.PurposeEmailChange, app.options.JWTVerificationTTL)
+	if err != nil {
+		log.Println("Error on calling gateway-service::token::Generate method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	app.redisClient.Set(
+		ctx,
+		"user:email-change:"+userID.(string), confirmationToken,
+		time.Second*time.Duration(app.options.JWTVerificationTTL),
+	)
+
+	if app.options.Environment == "production" {
+		confirmationLink := app.options.AppURL + "/email/confirm?token=" + confirmationToken
+		err := app.mailer.SendEmailVerification(payload.Email, confirmationLink)
+
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("SendEmailVerification error: %w", err))
+		}
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Confirm new user email by sended token.
+func (app *Config) userConfirmEmailChange(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	json := emailVerificationPayload{}
+
+	// Validate inputs
+	if err := c.BindJSON(&json); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Validate token
+	userID, err := app.token.Verify(json.Token, token.PurposeEmailChange)
+	if err != nil {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	// Get and delete token from Redis, so it can be used only once
+	token, err := app.redisClient.GetDel(ctx, "user:email-change:"+userID).Result()
+	if err != nil || token != json.Token {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	_, err = app.usersClient.userService.ConfirmEmail(ctx, &user.ConfirmEmailRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::ConfirmEmail method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
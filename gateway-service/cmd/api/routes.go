@@ -50,14 +50,73 @@ func (app *Config) routes() *gin.Engine {
 	documents.Use(app.AuthGuard(), app.ErrorHandler())
 	{
 		documents.GET("", app.documentGetAll)
+		documents.GET("/search", app.documentSearch)
+		documents.GET("/export", app.documentExport)
+		documents.GET("/shared", app.documentGetShared)
+		documents.GET("/shares", app.documentShareGetAll)
+		documents.POST("/share", app.documentShare)
+		documents.DELETE("/shares/:id", app.documentUnshare)
 		documents.GET("/:documentId", app.documentGetOne)
 		documents.GET("/:documentId/notifications", app.documentNotificationGetAll)
 		documents.POST("/:documentId/notifications/create", app.documentNotificationCreate)
 		documents.DELETE("/:documentId/notifications/delete/:id", app.documentNotificationDelete)
+		documents.GET("/:documentId/reminders", app.documentReminderRuleGetAll)
+		documents.POST("/:documentId/reminders/create", app.documentReminderRuleCreate)
+		documents.DELETE("/:documentId/reminders/delete/:id", app.documentReminderRuleDelete)
+		documents.GET("/:documentId/attachments", app.documentAttachmentGetAll)
+		documents.POST("/:documentId/attachments/upload", app.documentAttachmentUpload)
+		documents.GET("/:documentId/attachments/:id", app.documentAttachmentDownload)
+		documents.DELETE("/:documentId/attachments/delete/:id", app.documentAttachmentDelete)
 		documents.POST("/create", app.documentCreate)
+		documents.POST("/import", app.documentImport)
 		documents.PATCH("/edit", app.documentEdit)
 		documents.DELETE("/:documentId/delete", app.documentDelete)
+		documents.POST("/:documentId/renew", app.documentRenew)
+		documents.GET("/:documentId/renewals", app.documentGetRenewals)
 		documents.GET("/statistics", app.documentGetStatistics)
+		documents.GET("/trash", app.documentTrashGetAll)
+		documents.POST("/trash/:documentId/restore", app.documentRestore)
+		documents.DELETE("/trash/:documentId", app.documentPurge)
+	}
+
+	persons := g.Group("/persons")
+	persons.Use(app.AuthGuard(), app.ErrorHandler())
+	{
+		persons.GET("", app.personGetAll)
+		persons.POST("/create", app.personCreate)
+		persons.PATCH("/:personId", app.personEdit)
+		persons.DELETE("/:personId", app.personDelete)
+	}
+
+	customTypes := g.Group("/custom-types")
+	customTypes.Use(app.AuthGuard(), app.ErrorHandler())
+	{
+		customTypes.GET("", app.customTypeGetAll)
+		customTypes.POST("/create", app.customTypeCreate)
+		customTypes.PATCH("/:customTypeId", app.customTypeEdit)
+		customTypes.DELETE("/:customTypeId", app.customTypeDelete)
+	}
+
+	tags := g.Group("/tags")
+	tags.Use(app.AuthGuard(), app.ErrorHandler())
+	{
+		tags.GET("", app.tagGetAll)
+		tags.POST("/create", app.tagCreate)
+		tags.PATCH("/:tagId", app.tagEdit)
+		tags.DELETE("/:tagId", app.tagDelete)
+	}
+
+	groups := g.Group("/groups")
+	groups.Use(app.AuthGuard(), app.ErrorHandler())
+	{
+		groups.GET("", app.groupGetAll)
+		groups.POST("/create", app.groupCreate)
+		groups.DELETE("/:groupId", app.groupDelete)
+		groups.GET("/invitations", app.groupInvitationGetAll)
+		groups.POST("/:groupId/members", app.groupMemberInvite)
+		groups.POST("/:groupId/invitation", app.groupInvitationAccept)
+		groups.DELETE("/:groupId/invitation", app.groupInvitationDecline)
+		groups.DELETE("/:groupId/members/:userId", app.groupMemberRemove)
 	}
 
 	calendar := g.Group("/calendar")
@@ -75,7 +134,20 @@ func (app *Config) routes() *gin.Engine {
 	user := g.Group("/user")
 	user.Use(app.AuthGuard(), app.ErrorHandler())
 	{
-		user.GET("/token/refresh", app.userRefreshToken)
+		user.GET("/profile", app.userGetProfile)
+		user.PATCH("/profile", app.userUpdateProfile)
+		user.GET("/settings", app.userGetSettings)
+		user.PATCH("/settings", app.userUpdateSettings)
+		user.PATCH("/password", app.userChangePassword)
+		user.PATCH("/email", app.userChangeEmail)
+		user.GET("/export", app.userExportData)
+		user.DELETE("", app.userDeleteAccount)
+		user.GET("/reminder-presets", app.userReminderPresetGetAll)
+		user.POST("/reminder-presets", app.userReminderPresetCreate)
+		user.DELETE("/reminder-presets/:id", app.userReminderPresetDelete)
+		user.POST("/2fa/enroll", app.userEnrollTOTP)
+		user.POST("/2fa/confirm", app.userConfirmTOTP)
+		user.POST("/2fa/disable", app.userDisableTOTP)
 	}
 
 	// Auth routes (without auth guard)
@@ -83,10 +155,16 @@ func (app *Config) routes() *gin.Engine {
 	auth.Use(app.ErrorHandler())
 	{
 		auth.POST("/login", app.userLogin)
+		auth.POST("/login/2fa", app.userLoginTwoFactor)
 		auth.POST("/register", app.userRegister)
 		auth.POST("/verify", app.userVerifyEmail)
-		// TODO: In separate task
-		// auth.POST("/verify/resend", app.userVerifyResend)
+		auth.POST("/password/forgot", app.userForgotPassword)
+		auth.POST("/password/reset", app.userResetPassword)
+		auth.POST("/verify/resend", app.userVerifyResend)
+		auth.POST("/email/confirm", app.userConfirmEmailChange)
+		auth.POST("/token/refresh", app.userRefreshToken)
+		auth.POST("/logout", app.userLogout)
+		auth.POST("/logout/all", app.AuthGuard(), app.userLogoutAll)
 	}
 
 	return engine
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/samgozman/validity.red/broker/internal/token"
+)
+
+// Name of the http-only cookie with the opaque refresh token.
+const refreshTokenCookie = "refresh_token"
+
+// Path of the refresh token cookie, so it is sent only to the auth routes.
+const refreshTokenCookiePath = "/api/auth"
+
+// Redis key with the unix time before which all user's auth tokens are revoked.
+func sessionsValidAfterKey(userID string) string {
+	return "user:sessions:valid-after:" + userID
+}
+
+// Redis set with IDs of all active user's sessions.
+func userSessionsKey(userID string) string {
+	return "user:sessions:" + userID
+}
+
+// Redis key of the session (refresh token family) with the user ID as a value.
+func sessionKey(sessionID string) string {
+	return "auth:session:" + sessionID
+}
+
+// Redis hash of the refresh token. Only the token hash is stored.
+func refreshTokenKey(refreshToken string) string {
+	return "auth:refresh:" + token.Hash(refreshToken)
+}
+
+// Redis key of the revoked auth token ID (jti).
+func revokedTokenKey(tokenID string) string {
+	return "auth:revoked:" + tokenID
+}
+
+// Start new session for the user and write auth and refresh tokens to cookies.
+func (app *Config) startSession(ctx context.Context, c *gin.Context, userID string) error {
+	const sessionIDSize = 16
+
+	sessionID, err := token.NewOpaque(sessionIDSize)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Second * time.Duration(app.options.RefreshTokenTTL)
+
+	pipe := app.redisClient.TxPipeline()
+	pipe.Set(ctx, sessionKey(sessionID), userID, ttl)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return app.issueSessionTokens(ctx, c, userID, sessionID)
+}
+
+// Issue new refresh token and auth token for the existing session and write them to cookies.
+// Session TTL is prolonged with every issued refresh token.
+func (app *Config) issueSessionTokens(ctx context.Context, c *gin.Context, userID, sessionID string) error {
+	const refreshTokenSize = 32
+
+	refreshToken, err := token.NewOpaque(refreshTokenSize)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Second * time.Duration(app.options.RefreshTokenTTL)
+	key := refreshTokenKey(refreshToken)
+
+	pipe := app.redisClient.TxPipeline()
+	pipe.HSet(ctx, key, "userId", userID, "sessionId", sessionID)
+	pipe.Expire(ctx, key, ttl)
+	pipe.Expire(ctx, sessionKey(sessionID), ttl)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	authToken, err := app.token.GenerateForSession(userID, sessionID, app.options.JWTAuthTTL)
+	if err != nil {
+		return err
+	}
+
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie("token", authToken, app.options.JWTAuthTTL, "/", "", true, false)
+	c.SetCookie(refreshTokenCookie, refreshToken, app.options.RefreshTokenTTL, refreshTokenCookiePath, "", true, true)
+
+	return nil
+}
+
+// Remove auth and refresh token cookies.
+func (app *Config) clearSessionCookies(c *gin.Context) {
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie("token", "", -1, "/", "", true, false)
+	c.SetCookie(refreshTokenCookie, "", -1, refreshTokenCookiePath, "", true, true)
+}
+
+// Revoke the session, so none of its refresh tokens can be used anymore.
+func (app *Config) revokeSession(ctx context.Context, userID, sessionID string) error {
+	pipe := app.redisClient.TxPipeline()
+	pipe.Del(ctx, sessionKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// Revoke all sessions and auth tokens issued for the user until now.
+func (app *Config) revokeUserSessions(ctx context.Context, userID string) error {
+	sessions, err := app.redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := app.redisClient.TxPipeline()
+	for _, sessionID := range sessions {
+		pipe.Del(ctx, sessionKey(sessionID))
+	}
+
+	pipe.Del(ctx, userSessionsKey(userID))
+	// Revoked tokens will expire by themselves after JWTAuthTTL
+	pipe.Set(
+		ctx,
+		sessionsValidAfterKey(userID),
+		time.Now().Unix(),
+		time.Second*time.Duration(app.options.JWTAuthTTL),
+	)
+
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// Check if the session still exists, it is deleted on logout and on refresh token reuse.
+func (app *Config) isSessionActive(ctx context.Context, userID, sessionID string) (bool, error) {
+	owner, err := app.redisClient.Get(ctx, sessionKey(sessionID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return owner == userID, nil
+}
+
+// Check if the auth token issued at the given unix time was revoked.
+func (app *Config) isSessionRevoked(ctx context.Context, userID string, issuedAt int64) (bool, error) {
+	validAfter, err := app.redisClient.Get(ctx, sessionsValidAfterKey(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt < validAfter, nil
+}
+
+// Add auth token ID to the revocation list until the token expires.
+func (app *Config) revokeAuthToken(ctx context.Context, claims *token.JWTClaims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	return app.redisClient.Set(ctx, revokedTokenKey(claims.Id), 1, ttl).Err()
+}
+
+// Check if the auth token ID is in the revocation list.
+func (app *Config) isAuthTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := app.redisClient.Exists(ctx, revokedTokenKey(tokenID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/user"
+)
+
+// Digest frequencies accepted by the API.
+var digestFrequencies = map[string]user.DigestFrequency{
+	"weekly":  user.DigestFrequency_WEEKLY,
+	"monthly": user.DigestFrequency_MONTHLY,
+}
+
+type settingsPayload struct {
+	DigestEnabled   bool   `json:"digestEnabled"`
+	DigestFrequency string `json:"digestFrequency" binding:"required,oneof=weekly monthly"`
+}
+
+// Call GetSettings method on `user-service`.
+func (app *Config) userGetSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.usersClient.userService.GetSettings(ctx, &user.GetSettingsRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::GetSettings method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, convertSettingsToJSON(res))
+}
+
+func convertSettingsToJSON(s *user.Settings) settingsPayload {
+	frequency := "weekly"
+	if s.DigestFrequency == user.DigestFrequency_MONTHLY {
+		frequency = "monthly"
+	}
+
+	return settingsPayload{
+		DigestEnabled:   s.DigestEnabled,
+		DigestFrequency: frequency,
+	}
+}
+
+// Call UpdateSettings method on `user-service`.
+func (app *Config) userUpdateSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := settingsPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.usersClient.userService.UpdateSettings(ctx, &user.UpdateSettingsRequest{
+		UserId: userID.(string),
+		Settings: &user.Settings{
+			DigestEnabled:   payload.DigestEnabled,
+			DigestFrequency: digestFrequencies[payload.DigestFrequency],
+		},
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::UpdateSettings method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
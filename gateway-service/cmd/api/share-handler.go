@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/utils"
+	"github.com/samgozman/validity.red/broker/proto/document"
+)
+
+// All user's documents are shared if the document ID is not set.
+type documentSharePayload struct {
+	GroupID    string `json:"groupId" binding:"required,uuid"`
+	DocumentID string `json:"documentId" binding:"omitempty,uuid"`
+	Permission string `json:"permission" binding:"omitempty,oneof=read edit"`
+}
+
+// Share in JSON format, the permission is "read" or "edit".
+type shareJSON struct {
+	ID         string `json:"id"`
+	GroupID    string `json:"groupId"`
+	DocumentID string `json:"documentId,omitempty"`
+	Permission string `json:"permission"`
+}
+
+// Maps permission payload to the share permission.
+var sharePermissions = map[string]document.SharePermission{
+	"":     document.SharePermission_READ,
+	"read": document.SharePermission_READ,
+	"edit": document.SharePermission_EDIT,
+}
+
+// Call Share method on `document-service`.
+func (app *Config) documentShare(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := documentSharePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.Share(ctx, &document.DocumentShareRequest{
+		UserID:     userID.(string),
+		GroupID:    payload.GroupID,
+		DocumentID: payload.DocumentID,
+		Permission: sharePermissions[payload.Permission],
+		GroupIDs:   groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Share method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.JSON(http.StatusCreated, struct {
+		Share *shareJSON `json:"share"`
+	}{
+		Share: convertShareToJSON(res.Share),
+	})
+}
+
+// Call Unshare method on `document-service`.
+func (app *Config) documentUnshare(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.documentsClient.documentService.Unshare(ctx, &document.ShareRequest{
+		UserID:  userID.(string),
+		ShareID: uri.ID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::Unshare method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	go app.updateGroupCalendars(userID.(string))
+	c.Status(http.StatusOK)
+}
+
+// Call GetShares method on `document-service`.
+func (app *Config) documentShareGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.documentsClient.documentService.GetShares(ctx, &document.DocumentsRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetShares method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	shares := make([]*shareJSON, 0, len(res.Shares))
+	for _, s := range res.Shares {
+		shares = append(shares, convertShareToJSON(s))
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Shares []*shareJSON `json:"shares"`
+	}{
+		Shares: shares,
+	})
+}
+
+// Call GetShared method on `document-service`.
+func (app *Config) documentGetShared(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	groupIDs, err := app.userGroupIDs(ctx, userID.(string))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.GetShared(ctx, &document.SharedDocumentsRequest{
+		UserID:   userID.(string),
+		GroupIDs: groupIDs,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetShared method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Documents []*document.DocumentJSON `json:"documents"`
+	}{
+		Documents: utils.ConvertDocumentsToJSON(res.Documents),
+	})
+}
+
+func convertShareToJSON(s *document.Share) *shareJSON {
+	permission := "read"
+	if s.Permission == document.SharePermission_EDIT {
+		permission = "edit"
+	}
+
+	return &shareJSON{
+		ID:         s.ID,
+		GroupID:    s.GroupID,
+		DocumentID: s.DocumentID,
+		Permission: permission,
+	}
+}
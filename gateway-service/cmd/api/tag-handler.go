@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/proto/document"
+)
+
+type tagPayload struct {
+	Name string `json:"name" binding:"required,max=30"`
+}
+
+// Tag of the documents in JSON format.
+type tagJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Call GetTags method on `document-service`.
+func (app *Config) tagGetAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.documentsClient.documentService.GetTags(ctx, &document.DocumentsRequest{
+		UserID: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::GetTags method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	tags := make([]*tagJSON, 0, len(res.Tags))
+	for _, t := range res.Tags {
+		tags = append(tags, &tagJSON{ID: t.ID, Name: t.Name})
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Tags []*tagJSON `json:"tags"`
+	}{
+		Tags: tags,
+	})
+}
+
+// Call CreateTag method on `document-service`.
+func (app *Config) tagCreate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := tagPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.CreateTag(ctx, &document.TagRequest{
+		UserID: userID.(string),
+		Name:   payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::CreateTag method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		Tag *tagJSON `json:"tag"`
+	}{
+		Tag: &tagJSON{ID: res.Tag.ID, Name: res.Tag.Name},
+	})
+}
+
+// Call EditTag method on `document-service`.
+func (app *Config) tagEdit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		TagID string `uri:"tagId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	payload := tagPayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.documentsClient.documentService.EditTag(ctx, &document.TagRequest{
+		UserID: userID.(string),
+		TagID:  uri.TagID,
+		Name:   payload.Name,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::EditTag method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Tag *tagJSON `json:"tag"`
+	}{
+		Tag: &tagJSON{ID: res.Tag.ID, Name: res.Tag.Name},
+	})
+}
+
+// Call DeleteTag method on `document-service`.
+func (app *Config) tagDelete(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+	uri := struct {
+		TagID string `uri:"tagId" binding:"required,uuid"`
+	}{}
+
+	if err := c.BindUri(&uri); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	_, err := app.documentsClient.documentService.DeleteTag(ctx, &document.TagRequest{
+		UserID: userID.(string),
+		TagID:  uri.TagID,
+	})
+	if err != nil {
+		log.Println("Error on calling document-service::DeleteTag method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/token"
+	"github.com/samgozman/validity.red/broker/proto/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Max number of codes which can be checked for one login challenge.
+const maxTwoFactorAttempts = 5
+
+type twoFactorLoginPayload struct {
+	ChallengeToken string `json:"challengeToken" uri:"challengeToken" binding:"required,max=64"`
+	Code           string `json:"code" uri:"code" binding:"required,max=16"`
+}
+
+type twoFactorCodePayload struct {
+	Code string `json:"code" uri:"code" binding:"required,max=16"`
+}
+
+type twoFactorDisablePayload struct {
+	Password string `json:"password" uri:"password" binding:"required,max=64"`
+	Code     string `json:"code" uri:"code" binding:"required,max=16"`
+}
+
+// Redis hash of the login challenge waiting for the second factor. Only the token hash is stored.
+func twoFactorChallengeKey(challengeToken string) string {
+	return "auth:2fa-challenge:" + token.Hash(challengeToken)
+}
+
+// Redis set with the keys of the user's login challenges, so they can be deleted with the account.
+func userTwoFactorChallengesKey(userID string) string {
+	return "user:2fa-challenges:" + userID
+}
+
+// Save login result as a challenge until the second factor is verified
+// and return the challenge token for the client.
+// Email is saved to count failed codes together with the failed logins of the account.
+func (app *Config) createTwoFactorChallenge(ctx context.Context, res *user.AuthResponse, email string) (string, error) {
+	const challengeTokenSize = 32
+
+	challengeToken, err := token.NewOpaque(challengeTokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	key := twoFactorChallengeKey(challengeToken)
+
+	pipe := app.redisClient.TxPipeline()
+	pipe.HSet(
+		ctx, key,
+		"userId", res.UserId,
+		"email", normalizeLoginEmail(email),
+		"calendarId", res.CalendarId,
+		"timezone", res.Timezone,
+	)
+	pipe.Expire(ctx, key, time.Second*time.Duration(app.options.TwoFactorChallengeTTL))
+	pipe.SAdd(ctx, userTwoFactorChallengesKey(res.UserId), key)
+	pipe.Expire(ctx, userTwoFactorChallengesKey(res.UserId), time.Second*time.Duration(app.options.TwoFactorChallengeTTL))
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return challengeToken, nil
+}
+
+// Delete all pending login challenges of the user.
+func (app *Config) deleteTwoFactorChallenges(ctx context.Context, userID string) error {
+	keys, err := app.redisClient.SMembers(ctx, userTwoFactorChallengesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	return app.redisClient.Del(ctx, append(keys, userTwoFactorChallengesKey(userID))...).Err()
+}
+
+// Finish login by the challenge token and TOTP (or recovery) code.
+//
+// Every login creates a new challenge, so failed codes are also counted for the account
+// with the same lockout as failed passwords.
+func (app *Config) userLoginTwoFactor(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	requestPayload := twoFactorLoginPayload{}
+	if err := c.BindJSON(&requestPayload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	key := twoFactorChallengeKey(requestPayload.ChallengeToken)
+
+	challenge, err := app.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Println("Error on getting two-factor challenge:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if challenge["userId"] == "" || challenge["email"] == "" {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	lockedFor, _, err := app.loginStatus(ctx, challenge["email"], c.ClientIP())
+	if err != nil {
+		log.Println("Error on getting login status:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if lockedFor > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedFor.Seconds()))))
+		_ = c.Error(ErrTooManyRequests)
+
+		return
+	}
+
+	// Limit the number of guesses for one challenge
+	attempts, err := app.redisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		log.Println("Error on counting two-factor attempts:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if attempts > maxTwoFactorAttempts {
+		app.redisClient.Del(ctx, key)
+		_ = c.Error(ErrUnauthorized)
+
+		return
+	}
+
+	_, err = app.usersClient.userService.VerifyTOTP(ctx, &user.VerifyTOTPRequest{
+		UserId: challenge["userId"],
+		Code:   requestPayload.Code,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::VerifyTOTP method:", err)
+
+		if status.Code(err) == codes.Unauthenticated {
+			app.handleLoginFailure(ctx, challenge["email"], c.ClientIP(), true)
+		}
+
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Challenge can be used only once
+	deleted, err := app.redisClient.Del(ctx, key).Result()
+	if err != nil || deleted == 0 {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	// Failures are reset only when both factors are verified
+	err = app.resetLoginFailures(ctx, challenge["email"])
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("resetLoginFailures error: %w", err))
+	}
+
+	err = app.startSession(ctx, c, challenge["userId"])
+	if err != nil {
+		log.Println("Error on starting user session:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, struct {
+		CalendarID string `json:"calendarId"`
+		Timezone   string `json:"timezone"`
+	}{
+		CalendarID: challenge["calendarId"],
+		Timezone:   challenge["timezone"],
+	})
+}
+
+// Call EnrollTOTP method on `user-service` to generate new TOTP secret.
+func (app *Config) userEnrollTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	res, err := app.usersClient.userService.EnrollTOTP(ctx, &user.EnrollTOTPRequest{
+		UserId: userID.(string),
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::EnrollTOTP method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}{
+		Secret: res.Secret,
+		URI:    res.Uri,
+	})
+}
+
+// Call ConfirmTOTP method on `user-service` to enable two-factor authentication.
+func (app *Config) userConfirmTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := twoFactorCodePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	res, err := app.usersClient.userService.ConfirmTOTP(ctx, &user.ConfirmTOTPRequest{
+		UserId: userID.(string),
+		Code:   payload.Code,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::ConfirmTOTP method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}{
+		RecoveryCodes: res.RecoveryCodes,
+	})
+}
+
+// Call DisableTOTP method on `user-service`.
+func (app *Config) userDisableTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	payload := twoFactorDisablePayload{}
+	if err := c.BindJSON(&payload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Failed confirmations are throttled like failed logins
+	err := app.confirmUserPassword(ctx, c, userID.(string), payload.Password)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_, err = app.usersClient.userService.DisableTOTP(ctx, &user.DisableTOTPRequest{
+		UserId:   userID.(string),
+		Password: payload.Password,
+		Code:     payload.Code,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::DisableTOTP method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
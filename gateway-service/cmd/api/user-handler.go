@@ -4,17 +4,25 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/gin-gonic/gin"
+	"github.com/samgozman/validity.red/broker/internal/token"
+	"github.com/samgozman/validity.red/broker/proto/document"
 	"github.com/samgozman/validity.red/broker/proto/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type authPayload struct {
-	Email    string `json:"email" uri:"email" binding:"required,email"`
-	Password string `json:"password" uri:"password" binding:"required,min=8,max=64"`
+	Email            string `json:"email" uri:"email" binding:"required,email"`
+	Password         string `json:"password" uri:"password" binding:"required,min=8,max=64"`
+	HCaptchaResponse string `json:"hcaptcha" uri:"hcaptcha"` // Required only after several failed logins
 }
 
 type registerPayload struct {
@@ -28,6 +36,20 @@ type emailVerificationPayload struct {
 	Token string `json:"token" uri:"token" binding:"required,jwt"`
 }
 
+type emailVerificationResendPayload struct {
+	Email string `json:"email" uri:"email" binding:"required,email"`
+}
+
+type passwordForgotPayload struct {
+	Email            string `json:"email" uri:"email" binding:"required,email"`
+	HCaptchaResponse string `json:"hcaptcha" uri:"hcaptcha" binding:"required"`
+}
+
+type passwordResetPayload struct {
+	Token    string `json:"token" uri:"token" binding:"required,jwt"`
+	Password string `json:"password" uri:"password" binding:"required,min=8,max=64"`
+}
+
 // Call Register method on `user-service`.
 func (app *Config) userRegister(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "application/json")
@@ -65,8 +87,16 @@ func (app *Config) userRegister(c *gin.Context) {
 		return
 	}
 
+	// Generate user's data key. If it fails, the key is created on the first use
+	_, err = app.documentsClient.documentService.CreateDataKey(ctx, &document.DocumentsRequest{
+		UserID: res.UserId,
+	})
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("CreateDataKey error: %w", err))
+	}
+
 	// Create verification token for the user to verify email
-	verificationToken, err := app.token.Generate(res.UserId, app.options.JWTVerificationTTL)
+	verificationToken, err := app.token.Generate(res.UserId, token.PurposeVerification, app.options.JWTVerificationTTL)
 	if err != nil {
 		log.Println("Error on calling user-service::Register::Generate token method:", err)
 		_ = c.Error(err)
@@ -105,6 +135,36 @@ func (app *Config) userLogin(c *gin.Context) {
 		return
 	}
 
+	// Check brute-force protection before checking the password
+	lockedFor, captchaRequired, err := app.loginStatus(ctx, requestPayload.Email, c.ClientIP())
+	if err != nil {
+		log.Println("Error on getting login status:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if lockedFor > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedFor.Seconds()))))
+		_ = c.Error(ErrTooManyRequests)
+
+		return
+	}
+
+	// If environment is not production, skip captcha verification
+	if captchaRequired && app.options.Environment == "production" {
+		if requestPayload.HCaptchaResponse == "" {
+			_ = c.Error(ErrCaptchaRequired)
+			return
+		}
+
+		if hr := app.hcaptcha.VerifyToken(requestPayload.HCaptchaResponse); !hr.Success {
+			sentry.CaptureException(fmt.Errorf("hCaptcha errors: %s", hr.ErrorCodes))
+			_ = c.Error(ErrInvalidCaptcha)
+			return
+		}
+	}
+
 	// call service
 	res, err := app.usersClient.authService.Login(ctx, &user.AuthRequest{
 		AuthEntry: &user.Auth{
@@ -114,6 +174,11 @@ func (app *Config) userLogin(c *gin.Context) {
 	})
 	if err != nil {
 		log.Println("Error on calling user-service::Login method:", err)
+
+		if code := status.Code(err); code == codes.Unauthenticated || code == codes.NotFound {
+			app.handleLoginFailure(ctx, requestPayload.Email, c.ClientIP(), code == codes.Unauthenticated)
+		}
+
 		_ = c.Error(err)
 
 		return
@@ -125,18 +190,41 @@ func (app *Config) userLogin(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := app.token.Generate(res.UserId, app.options.JWTAuthTTL)
+	// Session is started only after the second factor is verified,
+	// failures are reset there so the code can't be brute-forced with new challenges
+	if res.TotpEnabled {
+		challengeToken, err := app.createTwoFactorChallenge(ctx, res, requestPayload.Email)
+		if err != nil {
+			log.Println("Error on creating two-factor challenge:", err)
+			_ = c.Error(err)
+
+			return
+		}
+
+		c.JSON(http.StatusAccepted, struct {
+			TwoFactorRequired bool   `json:"twoFactorRequired"`
+			ChallengeToken    string `json:"challengeToken"`
+		}{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+
+		return
+	}
+
+	err = app.resetLoginFailures(ctx, requestPayload.Email)
 	if err != nil {
-		log.Println("Error on calling gateway-service::token::Generate method:", err)
+		sentry.CaptureException(fmt.Errorf("resetLoginFailures error: %w", err))
+	}
+
+	err = app.startSession(ctx, c, res.UserId)
+	if err != nil {
+		log.Println("Error on starting user session:", err)
 		_ = c.Error(err)
 
 		return
 	}
 
-	// write jwt token
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("token", token, app.options.JWTAuthTTL, "/", "", true, false)
 	c.JSON(http.StatusAccepted, struct {
 		CalendarID string `json:"calendarId"`
 		Timezone   string `json:"timezone"`
@@ -146,26 +234,145 @@ func (app *Config) userLogin(c *gin.Context) {
 	})
 }
 
-// Refresh current user JWT token.
+// Rotate refresh token from the cookie and issue new auth token.
+//
+// Every refresh token can be used only once. Reuse of the token means that it was stolen,
+// so the whole session is revoked.
 func (app *Config) userRefreshToken(c *gin.Context) {
-	// get token from context
-	tk, _ := c.Get("Token")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	refreshToken, err := c.Cookie(refreshTokenCookie)
+	if err != nil {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	key := refreshTokenKey(refreshToken)
+
+	stored, err := app.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Println("Error on getting refresh token:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	userID, sessionID := stored["userId"], stored["sessionId"]
+	if userID == "" || sessionID == "" {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
 
-	// Refresh JWT token
-	token, err := app.token.Refresh(tk.(string), app.options.JWTAuthTTL)
+	// Mark token as used, only the first request will succeed
+	firstUse, err := app.redisClient.HSetNX(ctx, key, "usedAt", time.Now().Unix()).Result()
 	if err != nil {
-		log.Println("Error on calling gateway-service::token::Refresh method:", err)
+		log.Println("Error on rotating refresh token:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if !firstUse {
+		sentry.CaptureException(fmt.Errorf("refresh token reuse detected for session '%s'", sessionID))
+
+		err = app.revokeSession(ctx, userID, sessionID)
+		if err != nil {
+			log.Println("Error on revoking user session:", err)
+		}
 
+		app.clearSessionCookies(c)
 		_ = c.Error(ErrUnauthorized)
 
 		return
 	}
 
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("token", token, app.options.JWTAuthTTL, "/", "", false, false)
+	// Session could be revoked on logout
+	exists, err := app.redisClient.Exists(ctx, sessionKey(sessionID)).Result()
+	if err != nil {
+		log.Println("Error on getting user session:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if exists == 0 {
+		app.clearSessionCookies(c)
+		_ = c.Error(ErrUnauthorized)
+
+		return
+	}
+
+	err = app.issueSessionTokens(ctx, c, userID, sessionID)
+	if err != nil {
+		log.Println("Error on issuing session tokens:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
 	c.Status(http.StatusAccepted)
 }
 
+// Logout from the current session: revoke auth token and its refresh tokens.
+// Works even if the auth token is already expired.
+func (app *Config) userLogout(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if authToken, err := c.Cookie("token"); err == nil {
+		if claims, err := app.token.VerifyClaims(authToken); err == nil {
+			err = app.revokeAuthToken(ctx, claims)
+			if err != nil {
+				sentry.CaptureException(fmt.Errorf("userLogout revoke auth token error: %w", err))
+			}
+		}
+	}
+
+	if refreshToken, err := c.Cookie(refreshTokenCookie); err == nil {
+		stored, err := app.redisClient.HGetAll(ctx, refreshTokenKey(refreshToken)).Result()
+		if err == nil && stored["sessionId"] != "" {
+			err = app.revokeSession(ctx, stored["userId"], stored["sessionId"])
+		}
+
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("userLogout revoke session error: %w", err))
+		}
+	}
+
+	app.clearSessionCookies(c)
+	c.Status(http.StatusOK)
+}
+
+// Logout from all devices: revoke all user sessions and auth tokens.
+func (app *Config) userLogoutAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// get userID from context
+	userID, _ := c.Get("UserId")
+
+	err := app.revokeUserSessions(ctx, userID.(string))
+	if err != nil {
+		log.Println("Error on revoking user sessions:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Current token could be issued in the same second as revocation time
+	tk, _ := c.Get("Token")
+	if claims, err := app.token.VerifyClaims(tk.(string)); err == nil {
+		err = app.revokeAuthToken(ctx, claims)
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("userLogoutAll revoke auth token error: %w", err))
+		}
+	}
+
+	app.clearSessionCookies(c)
+	c.Status(http.StatusOK)
+}
+
 // Verify user email by sended token.
 func (app *Config) userVerifyEmail(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "application/json")
@@ -182,7 +389,7 @@ func (app *Config) userVerifyEmail(c *gin.Context) {
 	}
 
 	// Validate token
-	userID, err := app.token.Verify(json.Token)
+	userID, err := app.token.Verify(json.Token, token.PurposeVerification)
 	if err != nil {
 		_ = c.Error(ErrUnauthorized)
 		return
@@ -218,3 +425,218 @@ func (app *Config) userVerifyEmail(c *gin.Context) {
 
 	c.Status(http.StatusAccepted)
 }
+
+// Resend email verification link to the user.
+// Responds the same way whether the user exists (or is already verified) or not.
+func (app *Config) userVerifyResend(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	requestPayload := emailVerificationResendPayload{}
+	if err := c.BindJSON(&requestPayload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Allow only one resend per email during the cooldown period
+	throttleKey := "user:verification:resend:" + strings.ToLower(strings.TrimSpace(requestPayload.Email))
+
+	allowed, err := app.redisClient.SetNX(
+		ctx,
+		throttleKey, 1,
+		time.Second*time.Duration(app.options.VerificationResendCooldown),
+	).Result()
+	if err != nil {
+		log.Println("Error on setting verification resend throttle:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	if !allowed {
+		_ = c.Error(ErrTooManyRequests)
+		return
+	}
+
+	res, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		Email: requestPayload.Email,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+			log.Println("Error on calling user-service::GetUser method:", err)
+			sentry.CaptureException(fmt.Errorf("userVerifyResend GetUser error: %w", err))
+		}
+
+		c.Status(http.StatusAccepted)
+
+		return
+	}
+
+	if res.IsVerified {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	// Create new verification token, the previous one is replaced in Redis
+	verificationToken, err := app.token.Generate(res.UserId, token.PurposeVerification, app.options.JWTVerificationTTL)
+	if err != nil {
+		log.Println("Error on calling gateway-service::token::Generate method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	app.redisClient.Set(
+		ctx,
+		"user:verification:"+res.UserId, verificationToken,
+		time.Second*time.Duration(app.options.JWTVerificationTTL),
+	)
+
+	if app.options.Environment == "production" {
+		verificationLink := app.options.AppURL + "/verify?token=" + verificationToken
+		err := app.mailer.SendEmailVerification(res.Email, verificationLink)
+
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("SendEmailVerification error: %w", err))
+		}
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Send password reset link to the user's email.
+// Responds the same way whether the user exists or not.
+func (app *Config) userForgotPassword(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	requestPayload := passwordForgotPayload{}
+	if err := c.BindJSON(&requestPayload); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// If environment is not production, skip captcha verification
+	if app.options.Environment == "production" {
+		if hr := app.hcaptcha.VerifyToken(requestPayload.HCaptchaResponse); !hr.Success {
+			sentry.CaptureException(fmt.Errorf("hCaptcha errors: %s", hr.ErrorCodes))
+			_ = c.Error(ErrInvalidCaptcha)
+			return
+		}
+	}
+
+	res, err := app.usersClient.userService.GetUser(ctx, &user.GetUserRequest{
+		Email: requestPayload.Email,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+			log.Println("Error on calling user-service::GetUser method:", err)
+			sentry.CaptureException(fmt.Errorf("userForgotPassword GetUser error: %w", err))
+		}
+
+		c.Status(http.StatusAccepted)
+
+		return
+	}
+
+	// Create single-use reset token
+	resetToken, err := app.token.Generate(res.UserId, token.PurposePasswordReset, app.options.JWTPasswordResetTTL)
+	if err != nil {
+		log.Println("Error on calling gateway-service::token::Generate method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+	// Save reset token to Redis, replacing the previous one
+	app.redisClient.Set(
+		ctx,
+		"user:password-reset:"+res.UserId, resetToken,
+		time.Second*time.Duration(app.options.JWTPasswordResetTTL),
+	)
+
+	if app.options.Environment == "production" {
+		resetLink := app.options.AppURL + "/password/reset?token=" + resetToken
+		err := app.mailer.SendPasswordReset(res.Email, resetLink)
+
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("SendPasswordReset error: %w", err))
+		}
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Set new user password by sended reset token.
+// Revokes all existing user sessions.
+func (app *Config) userResetPassword(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	json := passwordResetPayload{}
+
+	// Validate inputs
+	if err := c.BindJSON(&json); err != nil {
+		_ = c.Error(ErrInvalidInputs)
+		return
+	}
+
+	// Validate token
+	userID, err := app.token.Verify(json.Token, token.PurposePasswordReset)
+	if err != nil {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	// Token is deleted from Redis only after the password is changed,
+	// so a wrong token doesn't invalidate the one sent by email
+	resetKey := "user:password-reset:" + userID
+
+	token, err := app.redisClient.Get(ctx, resetKey).Result()
+	if err != nil {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	// Check if token is valid
+	if token != json.Token {
+		_ = c.Error(ErrUnauthorized)
+		return
+	}
+
+	// Call user-service to set new password
+	_, err = app.usersClient.userService.SetPassword(ctx, &user.SetPasswordRequest{
+		UserId:   userID,
+		Password: json.Password,
+	})
+	if err != nil {
+		log.Println("Error on calling user-service::SetPassword method:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	// Token can be used only once
+	err = app.redisClient.Del(ctx, resetKey).Err()
+	if err != nil {
+		log.Println("Error on deleting password reset token:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	err = app.revokeUserSessions(ctx, userID)
+	if err != nil {
+		log.Println("Error on revoking user sessions:", err)
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
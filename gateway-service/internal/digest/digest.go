@@ -0,0 +1,252 @@
+// Package digest is used to send weekly and monthly email digests of the upcoming expirations.
+//
+// Job periodically scans the users which opted in for the digest. When a new period
+// (week or month) starts in the user's timezone, it collects documents expiring
+// until the end of the period, hands them to the Sender and saves the time of sending,
+// so the digest is not sent twice for the same period after the service restarts.
+// Each digest is locked for its period before sending, so several instances of the
+// service running the job do not send it twice.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Local hour of the period's first day after which the digest is sent.
+const SendHour = 8
+
+// Clock is used to get the current time. Can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock returns the current system time.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Recipient is the user which opted in for the digest.
+type Recipient struct {
+	UserID   string
+	Email    string
+	Location *time.Location // User's timezone
+	Monthly  bool           // Weekly digest if false
+	SentAt   time.Time      // Time when the last digest was sent, zero if never
+}
+
+// Document expiring in the digest period.
+type Document struct {
+	ID         string
+	Title      string
+	ExpiresAt  time.Time
+	PersonName string // Name of the dependent person, empty for the user's own documents
+}
+
+// Digest is the content of the email sent to the user.
+type Digest struct {
+	Monthly   bool
+	From      time.Time // Start of the period in the user's timezone
+	To        time.Time // End of the period (exclusive) in the user's timezone
+	Documents []Document
+}
+
+// Users is a source of the digest recipients.
+type Users interface {
+	// FindRecipients returns up to limit recipients with the user ID greater than afterUserID.
+	FindRecipients(ctx context.Context, afterUserID string, limit int) ([]Recipient, error)
+	// SetSent saves the time when the digest was sent to the user.
+	SetSent(ctx context.Context, userID string, sentAt time.Time) error
+}
+
+// Documents is a source of the documents included in the digest.
+type Documents interface {
+	// FindExpiring returns the user's documents expiring from now until the end time.
+	FindExpiring(ctx context.Context, userID string, now, end time.Time) ([]Document, error)
+}
+
+// Sender is a delivery channel for the digests.
+type Sender interface {
+	SendDigest(email string, d *Digest) error
+}
+
+// LogSender only writes digests to the log. Used outside of production.
+type LogSender struct{}
+
+func (LogSender) SendDigest(email string, d *Digest) error {
+	log.Printf("Digest for '%s' with %d documents until %s", email, len(d.Documents), d.To.Format(time.DateOnly))
+	return nil
+}
+
+// Locker is a lock shared by all instances of the service.
+type Locker interface {
+	// Lock acquires the key for ttl, returns false if it is already held.
+	Lock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Unlock releases the key.
+	Unlock(ctx context.Context, key string) error
+}
+
+type Job struct {
+	Users     Users
+	Documents Documents
+	Sender    Sender
+	Locker    Locker
+	Clock     Clock
+	Interval  time.Duration // Time between two scans
+	BatchSize int           // Number of recipients requested at once
+}
+
+// Period returns the start and the end (exclusive) of the week (from Monday) or the month
+// containing now in the loc timezone.
+func Period(now time.Time, loc *time.Location, monthly bool) (time.Time, time.Time) {
+	local := now.In(loc)
+	year, month, day := local.Date()
+
+	if monthly {
+		start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+
+	// Weekday is 0 for Sunday, the week starts on Monday
+	offset := (int(local.Weekday()) + 6) % 7
+	start := time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+
+	return start, start.AddDate(0, 0, 7)
+}
+
+// IsDue reports whether the digest of the current period should be sent to the recipient.
+func IsDue(r *Recipient, now time.Time) bool {
+	start, _ := Period(now, r.Location, r.Monthly)
+
+	return !now.Before(start.Add(SendHour*time.Hour)) && r.SentAt.Before(start)
+}
+
+// Run sends due digests every Interval until ctx is canceled.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := j.Send(ctx)
+		if err != nil {
+			log.Println("Error on sending digests:", err)
+		} else if sent > 0 {
+			log.Printf("Sent %d digests", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send sends digests to all recipients whose period has started and returns the number of sent emails.
+//
+// Recipients without expiring documents are marked as sent without an email.
+// Digests that failed to be sent are retried on the next call, errors of one recipient
+// do not stop sending to the others.
+func (j *Job) Send(ctx context.Context) (int, error) {
+	var (
+		sent    int
+		afterID string
+	)
+
+	for {
+		recipients, err := j.Users.FindRecipients(ctx, afterID, j.BatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range recipients {
+			r := &recipients[i]
+
+			if j.sendOne(ctx, r) {
+				sent++
+			}
+		}
+
+		if len(recipients) < j.BatchSize {
+			return sent, nil
+		}
+
+		afterID = recipients[len(recipients)-1].UserID
+	}
+}
+
+// Send the digest to the recipient if it is due. Returns true if the email was sent.
+func (j *Job) sendOne(ctx context.Context, r *Recipient) bool {
+	now := j.Clock.Now()
+	if !IsDue(r, now) {
+		return false
+	}
+
+	start, end := Period(now, r.Location, r.Monthly)
+
+	// Lock is held until the end of the period, so it also covers the digests
+	// which were sent but failed to be marked as sent
+	key := lockKey(r.UserID, start)
+
+	locked, err := j.Locker.Lock(ctx, key, end.Sub(now))
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error locking digest for user '%s': %w", r.UserID, err))
+		return false
+	}
+
+	if !locked {
+		return false
+	}
+
+	documents, err := j.Documents.FindExpiring(ctx, r.UserID, now, end)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error collecting digest for user '%s': %w", r.UserID, err))
+		j.unlock(ctx, key)
+
+		return false
+	}
+
+	var emailed bool
+
+	if len(documents) > 0 {
+		err = j.Sender.SendDigest(r.Email, &Digest{
+			Monthly:   r.Monthly,
+			From:      start,
+			To:        end,
+			Documents: documents,
+		})
+		if err != nil {
+			sentry.CaptureException(fmt.Errorf("error sending digest to user '%s': %w", r.UserID, err))
+			j.unlock(ctx, key)
+
+			return false
+		}
+
+		emailed = true
+	}
+
+	err = j.Users.SetSent(ctx, r.UserID, now)
+	if err != nil {
+		sentry.CaptureException(fmt.Errorf("error saving digest of user '%s' as sent: %w", r.UserID, err))
+	}
+
+	return emailed
+}
+
+// Release the lock so the digest is retried on the next scan.
+func (j *Job) unlock(ctx context.Context, key string) {
+	if err := j.Locker.Unlock(ctx, key); err != nil {
+		sentry.CaptureException(fmt.Errorf("error unlocking digest '%s': %w", key, err))
+	}
+}
+
+// Key of the lock of the user's digest for the period starting at start.
+func lockKey(userID string, start time.Time) string {
+	return "digest:lock:" + userID + ":" + start.Format("2006-01-02")
+}
@@ -0,0 +1,271 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// In-memory recipients, SetSent fails for the users in setSentErr.
+type fakeUsers struct {
+	recipients []Recipient
+	sent       map[string]time.Time
+	setSentErr map[string]bool
+}
+
+func (u *fakeUsers) FindRecipients(ctx context.Context, afterUserID string, limit int) ([]Recipient, error) {
+	var page []Recipient
+
+	for _, r := range u.recipients {
+		if r.UserID > afterUserID && len(page) < limit {
+			page = append(page, r)
+		}
+	}
+
+	return page, nil
+}
+
+func (u *fakeUsers) SetSent(ctx context.Context, userID string, sentAt time.Time) error {
+	if u.setSentErr[userID] {
+		return errors.New("user-service is unavailable")
+	}
+
+	u.sent[userID] = sentAt
+
+	return nil
+}
+
+// Every user has one expiring document.
+type fakeDocuments struct{}
+
+func (fakeDocuments) FindExpiring(ctx context.Context, userID string, now, end time.Time) ([]Document, error) {
+	return []Document{{ID: "doc-" + userID, Title: "Passport", ExpiresAt: end.Add(-time.Hour)}}, nil
+}
+
+// Sender failing for the emails in failFor.
+type fakeSender struct {
+	sent    []string
+	failFor map[string]bool
+}
+
+func (s *fakeSender) SendDigest(email string, d *Digest) error {
+	if s.failFor[email] {
+		return errors.New("mailer is unavailable")
+	}
+
+	s.sent = append(s.sent, email)
+
+	return nil
+}
+
+type fakeLocker struct {
+	locked map[string]bool
+}
+
+func (l *fakeLocker) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if l.locked[key] {
+		return false, nil
+	}
+
+	l.locked[key] = true
+
+	return true, nil
+}
+
+func (l *fakeLocker) Unlock(ctx context.Context, key string) error {
+	delete(l.locked, key)
+	return nil
+}
+
+// Monday, 9:00 UTC.
+var testNow = time.Date(2023, time.May, 15, 9, 0, 0, 0, time.UTC)
+
+func newTestJob(users *fakeUsers, sender *fakeSender, locker *fakeLocker) *Job {
+	return &Job{
+		Users:     users,
+		Documents: fakeDocuments{},
+		Sender:    sender,
+		Locker:    locker,
+		Clock:     &fakeClock{now: testNow},
+		BatchSize: 2,
+	}
+}
+
+func newTestUsers(ids ...string) *fakeUsers {
+	users := &fakeUsers{sent: map[string]time.Time{}, setSentErr: map[string]bool{}}
+
+	for _, id := range ids {
+		users.recipients = append(users.recipients, Recipient{UserID: id, Email: id + "@example.com", Location: time.UTC})
+	}
+
+	return users
+}
+
+func TestPeriod(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone database is not available")
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		loc       *time.Location
+		monthly   bool
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "week starts on Monday",
+			now:       time.Date(2023, time.May, 18, 12, 0, 0, 0, time.UTC),
+			loc:       time.UTC,
+			wantStart: time.Date(2023, time.May, 15, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Sunday belongs to the previous week",
+			now:       time.Date(2023, time.May, 21, 12, 0, 0, 0, time.UTC),
+			loc:       time.UTC,
+			wantStart: time.Date(2023, time.May, 15, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "week is taken in the user's timezone",
+			now:       time.Date(2023, time.May, 21, 23, 30, 0, 0, time.UTC), // Monday 01:30 in Berlin
+			loc:       berlin,
+			wantStart: time.Date(2023, time.May, 22, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2023, time.May, 29, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "month",
+			now:       time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC),
+			loc:       time.UTC,
+			monthly:   true,
+			wantStart: time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Period(tt.now, tt.loc, tt.monthly)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("Period() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	monday := time.Date(2023, time.May, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		r    Recipient
+		want bool
+	}{
+		{
+			name: "never sent after the send hour",
+			now:  monday.Add(SendHour * time.Hour),
+			r:    Recipient{Location: time.UTC},
+			want: true,
+		},
+		{
+			name: "before the send hour",
+			now:  monday.Add(SendHour*time.Hour - time.Minute),
+			r:    Recipient{Location: time.UTC},
+			want: false,
+		},
+		{
+			name: "sent in the previous period",
+			now:  monday.Add(10 * time.Hour),
+			r:    Recipient{Location: time.UTC, SentAt: monday.Add(-time.Hour)},
+			want: true,
+		},
+		{
+			name: "already sent in the period",
+			now:  monday.AddDate(0, 0, 2),
+			r:    Recipient{Location: time.UTC, SentAt: monday.Add(9 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "monthly digest is not due in the middle of the month",
+			now:  monday,
+			r:    Recipient{Location: time.UTC, Monthly: true, SentAt: time.Date(2023, time.May, 1, 9, 0, 0, 0, time.UTC)},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDue(&tt.r, tt.now); got != tt.want {
+				t.Errorf("IsDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_SendSkipsLockedDigests(t *testing.T) {
+	users := newTestUsers("a", "b")
+	sender := &fakeSender{}
+	locker := &fakeLocker{locked: map[string]bool{}}
+
+	// Digest of the user "a" is being sent by another instance
+	start, _ := Period(testNow, time.UTC, false)
+	locker.locked[lockKey("a", start)] = true
+
+	sent, err := newTestJob(users, sender, locker).Send(context.Background())
+	if err != nil {
+		t.Fatalf("Job.Send() error = %v", err)
+	}
+
+	if sent != 1 || len(sender.sent) != 1 || sender.sent[0] != "b@example.com" {
+		t.Errorf("Job.Send() sent = %v, want only the unlocked digest", sender.sent)
+	}
+
+	if _, ok := users.sent["a"]; ok {
+		t.Errorf("Job.Send() want locked digest not to be marked as sent")
+	}
+}
+
+func TestJob_SendContinuesAfterErrors(t *testing.T) {
+	users := newTestUsers("a", "b", "c")
+	users.setSentErr["b"] = true
+	sender := &fakeSender{failFor: map[string]bool{"a@example.com": true}}
+	locker := &fakeLocker{locked: map[string]bool{}}
+	job := newTestJob(users, sender, locker)
+
+	sent, err := job.Send(context.Background())
+	if err != nil {
+		t.Fatalf("Job.Send() error = %v", err)
+	}
+
+	// Sending to "a" failed and saving "b" failed, "c" should still get the digest
+	if sent != 2 || len(sender.sent) != 2 || sender.sent[1] != "c@example.com" {
+		t.Fatalf("Job.Send() sent = %v, want digests of the users after the failed ones", sender.sent)
+	}
+
+	if _, ok := users.sent["c"]; !ok {
+		t.Errorf("Job.Send() want digest of the last user to be marked as sent")
+	}
+
+	// Failed digest is unlocked and retried, the sent one stays locked even though it was not saved
+	sender.failFor = nil
+
+	sent, err = job.Send(context.Background())
+	if err != nil {
+		t.Fatalf("Job.Send() second call error = %v", err)
+	}
+
+	if sent != 1 || sender.sent[2] != "a@example.com" {
+		t.Errorf("Job.Send() second call sent = %v, want only the failed digest to be retried", sender.sent)
+	}
+}
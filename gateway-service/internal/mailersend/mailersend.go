@@ -10,6 +10,7 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	ms "github.com/mailersend/mailersend-go"
+	"github.com/samgozman/validity.red/broker/internal/digest"
 )
 
 // MailerSend integration.
@@ -22,6 +23,96 @@ type MailerSend struct {
 //
 // TokenURL should be a full URL, e.g. https://validity.red/verify?token=123
 func (m *MailerSend) SendEmailVerification(email, tokenURL string) error {
+	subject := "Confirm your email | Validity.Red"
+
+	return m.send(email, subject, func(message *ms.Message, recipientName string) {
+		message.SetTemplateID("3zxk54vm7ox4jy6v")
+		message.SetPersonalization([]ms.Personalization{
+			{
+				Email: email,
+				Data: map[string]interface{}{
+					"name":             recipientName,
+					"confirmation_url": tokenURL,
+				},
+			},
+		})
+	})
+}
+
+// SendPasswordReset sends password reset email to the user with the tokenURL
+// via MailerSend. The tokenURL is a link to the frontend with the token as a query param.
+//
+// TokenURL should be a full URL, e.g. https://validity.red/password/reset?token=123
+func (m *MailerSend) SendPasswordReset(email, tokenURL string) error {
+	subject := "Reset your password | Validity.Red"
+
+	return m.send(email, subject, func(message *ms.Message, recipientName string) {
+		message.SetText(fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Somebody requested a password reset for your Validity.Red account.\n"+
+				"Follow the link to set a new password: %s\n\n"+
+				"If it wasn't you, just ignore this email.",
+			recipientName,
+			tokenURL,
+		))
+	})
+}
+
+// SendLoginLockout notifies the user that login to the account was temporary locked
+// because of too many failed attempts.
+func (m *MailerSend) SendLoginLockout(email string, lockedFor time.Duration) error {
+	subject := "Too many failed logins | Validity.Red"
+
+	return m.send(email, subject, func(message *ms.Message, recipientName string) {
+		message.SetText(fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"There were too many failed attempts to log in to your Validity.Red account, "+
+				"so the login is locked for %s.\n\n"+
+				"If it wasn't you, somebody may be trying to guess your password. "+
+				"Consider changing it and enabling two-factor authentication.",
+			recipientName,
+			lockedFor,
+		))
+	})
+}
+
+// SendDigest sends the digest of the documents expiring in the coming week or month.
+func (m *MailerSend) SendDigest(email string, d *digest.Digest) error {
+	period := "week"
+	if d.Monthly {
+		period = "month"
+	}
+
+	subject := fmt.Sprintf("Documents expiring this %s | Validity.Red", period)
+
+	var list strings.Builder
+	for _, doc := range d.Documents {
+		list.WriteString(fmt.Sprintf("- %s", doc.Title))
+
+		if doc.PersonName != "" {
+			list.WriteString(fmt.Sprintf(" (%s)", doc.PersonName))
+		}
+
+		list.WriteString(fmt.Sprintf(", expires on %s\n", doc.ExpiresAt.In(d.From.Location()).Format("Mon, 02 Jan 2006")))
+	}
+
+	return m.send(email, subject, func(message *ms.Message, recipientName string) {
+		message.SetText(fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"These documents expire this %s (%s - %s):\n\n"+
+				"%s\n"+
+				"You can change the digest frequency or turn it off in the settings of your Validity.Red account.",
+			recipientName,
+			period,
+			d.From.Format("02 Jan"),
+			d.To.AddDate(0, 0, -1).Format("02 Jan 2006"),
+			list.String(),
+		))
+	})
+}
+
+// Send email to the single recipient. Message content is set by the setContent callback.
+func (m *MailerSend) send(email, subject string, setContent func(message *ms.Message, recipientName string)) error {
 	const requestTimeout = 5 * time.Second
 
 	client := ms.NewMailersend(m.APIKey)
@@ -30,7 +121,6 @@ func (m *MailerSend) SendEmailVerification(email, tokenURL string) error {
 	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
-	subject := "Confirm your email | Validity.Red"
 	recipientName := strings.Split(email, "@")[0]
 
 	from := ms.From{
@@ -45,23 +135,12 @@ func (m *MailerSend) SendEmailVerification(email, tokenURL string) error {
 		},
 	}
 
-	personalization := []ms.Personalization{
-		{
-			Email: email,
-			Data: map[string]interface{}{
-				"name":             recipientName,
-				"confirmation_url": tokenURL,
-			},
-		},
-	}
-
 	message := client.Email.NewMessage()
 
 	message.SetFrom(from)
 	message.SetRecipients(recipients)
 	message.SetSubject(subject)
-	message.SetTemplateID("3zxk54vm7ox4jy6v")
-	message.SetPersonalization(personalization)
+	setContent(message, recipientName)
 
 	res, err := client.Email.Send(ctx, message)
 	if err != nil {
@@ -1,6 +1,10 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -12,25 +16,64 @@ var (
 	ErrExpiredToken = errors.New("expired token")
 )
 
+// Purposes of the tokens. Token is accepted only by the flow it was issued for.
+const (
+	PurposeAuth          = "auth"           // Auth token of the refresh token session
+	PurposeVerification  = "verification"   // Email verification link
+	PurposePasswordReset = "password-reset" // Password reset link
+	PurposeEmailChange   = "email-change"   // New email confirmation link
+)
+
 type TokenMaker struct {
 	Key []byte // JWT secret key
 }
 
 type JWTClaims struct {
-	UserID string `json:"userId"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sid,omitempty"` // Refresh token session the token was issued for
+	Purpose   string `json:"pur"`           // Flow the token was issued for, see Purpose* constants
 	jwt.StandardClaims
 }
 
-// Generate - generates a JWT token for the user.
+// Generate - generates a JWT token for the user which is accepted only for the given purpose.
+// Auth tokens must be generated with GenerateForSession.
 //
 // maxAge - JWT token max age (in seconds).
-func (j *TokenMaker) Generate(userID string, maxAge int) (t string, err error) {
-	expirationTime := time.Now().Add(time.Duration(maxAge) * time.Second).Unix()
+func (j *TokenMaker) Generate(userID, purpose string, maxAge int) (t string, err error) {
+	if purpose == PurposeAuth {
+		return "", ErrInvalidToken
+	}
+
+	return j.generate(userID, "", purpose, maxAge)
+}
+
+// GenerateForSession - generates a JWT auth token for the user bound to the refresh token session.
+//
+// maxAge - JWT token max age (in seconds).
+func (j *TokenMaker) GenerateForSession(userID, sessionID string, maxAge int) (t string, err error) {
+	return j.generate(userID, sessionID, PurposeAuth, maxAge)
+}
+
+// Sign the token with the given claims.
+func (j *TokenMaker) generate(userID, sessionID, purpose string, maxAge int) (t string, err error) {
+	const idSize = 16
+
+	tokenID, err := NewOpaque(idSize)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(time.Duration(maxAge) * time.Second).Unix()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
+		Purpose:   purpose,
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
 			ExpiresAt: expirationTime,
+			IssuedAt:  now.Unix(),
 		},
 	})
 
@@ -43,30 +86,23 @@ func (j *TokenMaker) Generate(userID string, maxAge int) (t string, err error) {
 	return tokenString, nil
 }
 
-// Verifies a JWT token and returns decoded UserID.
-func (j *TokenMaker) Verify(tokenString string) (userID string, e error) {
+// Verifies a JWT token issued for the purpose and returns decoded UserID.
+func (j *TokenMaker) Verify(tokenString, purpose string) (userID string, e error) {
 	claims, err := j.parse(tokenString)
 	if err != nil {
 		return "", err
 	}
 
-	return claims.UserID, nil
-}
-
-// Refresh - generates new JWT token for the user.
-//
-// maxAge - JWT token max age (in seconds).
-func (j *TokenMaker) Refresh(tokenString string, maxAge int) (t string, err error) {
-	claims, err := j.parse(tokenString)
-	if err != nil {
-		return "", err
+	if claims.Purpose != purpose {
+		return "", ErrInvalidToken
 	}
 
-	// TODO: Ensure that a new token is not issued until enough time has passed
-	// TODO: Return previous token if it's far from expired
+	return claims.UserID, nil
+}
 
-	// Create new token with current payload
-	return j.Generate(claims.UserID, maxAge)
+// Verifies a JWT token and returns all decoded claims.
+func (j *TokenMaker) VerifyClaims(tokenString string) (*JWTClaims, error) {
+	return j.parse(tokenString)
 }
 
 // Parse token string and return decoded JWTClaims.
@@ -89,3 +125,22 @@ func (j *TokenMaker) parse(tokenString string) (*JWTClaims, error) {
 
 	return claims, nil
 }
+
+// NewOpaque generates random URL-safe string from the given number of random bytes.
+// Used for token IDs and refresh tokens.
+func NewOpaque(size int) (string, error) {
+	b := make([]byte, size)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns SHA-256 hex digest of the opaque token, so it can be stored without the token itself.
+func Hash(opaque string) string {
+	sum := sha256.Sum256([]byte(opaque))
+	return hex.EncodeToString(sum[:])
+}
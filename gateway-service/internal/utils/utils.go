@@ -19,6 +19,7 @@ func ConvertNotificationsToJSON(ns []*document.Notification) []*document.Notific
 			ID:         n.ID,
 			DocumentID: n.DocumentID,
 			Date:       ParseProtobufDateToString(n.Date),
+			RuleID:     n.RuleID,
 		})
 	}
 
@@ -28,14 +29,26 @@ func ConvertNotificationsToJSON(ns []*document.Notification) []*document.Notific
 func ConvertDocumentsToJSON(ds []*document.Document) []*document.DocumentJSON {
 	var djs = []*document.DocumentJSON{}
 	for _, d := range ds {
-		djs = append(djs, &document.DocumentJSON{
-			ID:          d.ID,
-			UserID:      d.UserID,
-			Title:       d.Title,
-			Type:        d.Type,
-			Description: d.Description,
-			ExpiresAt:   ParseProtobufDateToString(d.ExpiresAt),
-		})
+		dj := &document.DocumentJSON{
+			ID:             d.ID,
+			UserID:         d.UserID,
+			Title:          d.Title,
+			Type:           d.Type,
+			Description:    d.Description,
+			ExpiresAt:      ParseProtobufDateToString(d.ExpiresAt),
+			RenewalMonths:  d.RenewalMonths,
+			PersonID:       d.PersonID,
+			PersonName:     d.PersonName,
+			CustomTypeID:   d.CustomTypeID,
+			CustomTypeName: d.CustomTypeName,
+			TagIDs:         d.TagIDs,
+		}
+
+		if d.DeletedAt != nil {
+			dj.DeletedAt = ParseProtobufDateToString(d.DeletedAt)
+		}
+
+		djs = append(djs, dj)
 	}
 
 	return djs
@@ -54,3 +67,42 @@ func ConvertCalendarToJSON(cl []*calendar.CalendarEntity) []*calendar.CalendarEn
 
 	return calendarJSON
 }
+
+func ConvertAttachmentsToJSON(as []*document.Attachment) []*document.AttachmentJSON {
+	var ajs = []*document.AttachmentJSON{}
+	for _, a := range as {
+		ajs = append(ajs, ConvertAttachmentToJSON(a))
+	}
+
+	return ajs
+}
+
+func ConvertAttachmentToJSON(a *document.Attachment) *document.AttachmentJSON {
+	return &document.AttachmentJSON{
+		ID:         a.ID,
+		DocumentID: a.DocumentID,
+		FileName:   a.FileName,
+		MimeType:   a.MimeType,
+		Size:       a.Size,
+		CreatedAt:  ParseProtobufDateToString(a.CreatedAt),
+	}
+}
+
+func ConvertRenewalsToJSON(rs []*document.Renewal) []*document.RenewalJSON {
+	var rjs = []*document.RenewalJSON{}
+	for _, r := range rs {
+		rjs = append(rjs, ConvertRenewalToJSON(r))
+	}
+
+	return rjs
+}
+
+func ConvertRenewalToJSON(r *document.Renewal) *document.RenewalJSON {
+	return &document.RenewalJSON{
+		ID:                r.ID,
+		DocumentID:        r.DocumentID,
+		PreviousExpiresAt: ParseProtobufDateToString(r.PreviousExpiresAt),
+		ExpiresAt:         ParseProtobufDateToString(r.ExpiresAt),
+		CreatedAt:         ParseProtobufDateToString(r.CreatedAt),
+	}
+}
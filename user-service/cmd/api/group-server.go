@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/user/internal/models/group"
+	"github.com/samgozman/validity.red/user/internal/models/user"
+	proto "github.com/samgozman/validity.red/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Max number of members in one group including the owner and pending invitations.
+const MaxGroupMembers = 10
+
+var (
+	ErrMaxGroupMembersLimit = status.Error(codes.FailedPrecondition, "max group members limit reached")
+	ErrInvitationNotFound   = status.Error(codes.NotFound, "invitation not found")
+)
+
+type GroupServer struct {
+	App *Config
+	// Necessary parameter to insure backwards compatibility
+	proto.UnimplementedGroupServiceServer
+}
+
+// Create creates a new group with the user as its owner.
+func (gs *GroupServer) Create(ctx context.Context, req *proto.CreateGroupRequest) (*proto.GroupResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	g := group.Group{
+		Name:    req.Name,
+		OwnerID: userID,
+	}
+
+	err = gs.App.Groups.InsertOne(ctx, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GroupResponse{
+		Group: convertGroupToProto(&g),
+	}, nil
+}
+
+// Delete permanently deletes the group, only the owner can do it.
+func (gs *GroupServer) Delete(ctx context.Context, req *proto.GroupRequest) (*emptypb.Empty, error) {
+	userID, g, err := gs.findGroup(ctx, req.UserId, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.OwnerID != userID {
+		return nil, status.Error(codes.PermissionDenied, "only the owner can delete the group")
+	}
+
+	err = gs.App.Groups.Delete(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetAll returns all groups which the user is a member of.
+func (gs *GroupServer) GetAll(ctx context.Context, req *proto.GetGroupsRequest) (*proto.GetGroupsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	groups, err := gs.App.Groups.FindAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &proto.GetGroupsResponse{
+		Groups: make([]*proto.Group, 0, len(groups)),
+	}
+
+	for i := range groups {
+		res.Groups = append(res.Groups, convertGroupToProto(&groups[i]))
+	}
+
+	return res, nil
+}
+
+// InviteMember invites the registered user with the given email to the group, only the owner can do it.
+// The response is the same if the email is not registered or the user is already a member,
+// so the owner can't find out which emails are registered.
+func (gs *GroupServer) InviteMember(ctx context.Context, req *proto.GroupMemberRequest) (*emptypb.Empty, error) {
+	userID, g, err := gs.findGroup(ctx, req.UserId, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.OwnerID != userID {
+		return nil, status.Error(codes.PermissionDenied, "only the owner can invite members")
+	}
+
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	// Pending invitations are counted as well, so the group can't outgrow the limit when they are accepted
+	invitations, err := gs.App.Groups.CountInvitations(ctx, g.ID, time.Now().Add(-group.InvitationTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(g.Members))+invitations >= MaxGroupMembers {
+		return nil, ErrMaxGroupMembersLimit
+	}
+
+	u, err := gs.App.Repo.FindOne(ctx, &user.User{Email: user.NormalizeEmail(req.Email)}, "id")
+	if status.Code(err) == codes.NotFound {
+		return &emptypb.Empty{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if g.HasMember(u.ID) {
+		return &emptypb.Empty{}, nil
+	}
+
+	err = gs.App.Groups.Invite(ctx, &group.Invitation{
+		GroupID: g.ID,
+		UserID:  u.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetInvitations returns the user's pending invitations to the groups.
+func (gs *GroupServer) GetInvitations(ctx context.Context, req *proto.GetGroupsRequest) (*proto.GetInvitationsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	invitations, err := gs.App.Groups.FindInvitations(ctx, userID, time.Now().Add(-group.InvitationTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &proto.GetInvitationsResponse{
+		Invitations: make([]*proto.GroupInvitation, 0, len(invitations)),
+	}
+
+	for _, inv := range invitations {
+		res.Invitations = append(res.Invitations, &proto.GroupInvitation{
+			GroupId:    inv.GroupID.String(),
+			GroupName:  inv.GroupName,
+			OwnerEmail: inv.OwnerEmail,
+			CreatedAt:  timestamppb.New(inv.CreatedAt),
+		})
+	}
+
+	return res, nil
+}
+
+// AcceptInvitation adds the invited user to the group.
+func (gs *GroupServer) AcceptInvitation(ctx context.Context, req *proto.GroupRequest) (*proto.GroupResponse, error) {
+	userID, groupID, err := parseInvitationRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	after := time.Now().Add(-group.InvitationTTL)
+
+	// Group is not disclosed to the users without the invitation
+	invitations, err := gs.App.Groups.FindInvitations(ctx, userID, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasInvitation(invitations, groupID) {
+		return nil, ErrInvitationNotFound
+	}
+
+	g, err := gs.App.Groups.FindOne(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.Members) >= MaxGroupMembers {
+		return nil, ErrMaxGroupMembersLimit
+	}
+
+	err = gs.App.Groups.AcceptInvitation(ctx, groupID, userID, after)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err = gs.App.Groups.FindOne(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GroupResponse{
+		Group: convertGroupToProto(g),
+	}, nil
+}
+
+// DeclineInvitation deletes the user's invitation to the group.
+func (gs *GroupServer) DeclineInvitation(ctx context.Context, req *proto.GroupRequest) (*emptypb.Empty, error) {
+	userID, groupID, err := parseInvitationRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.App.Groups.DeleteInvitation(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RemoveMember removes the member from the group.
+// The owner can remove any other member, other members can only leave the group.
+func (gs *GroupServer) RemoveMember(ctx context.Context, req *proto.GroupMemberRequest) (*emptypb.Empty, error) {
+	userID, g, err := gs.findGroup(ctx, req.UserId, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	memberID, err := uuid.Parse(req.MemberId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid member id")
+	}
+
+	if memberID == g.OwnerID {
+		return nil, status.Error(codes.InvalidArgument, "owner can't leave the group, delete it instead")
+	}
+
+	if userID != g.OwnerID && userID != memberID {
+		return nil, status.Error(codes.PermissionDenied, "only the owner can remove other members")
+	}
+
+	err = gs.App.Groups.RemoveMember(ctx, g.ID, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Helper to parse ids and find the group which the user is a member of.
+// Groups of other users are not found, so their existence is not disclosed.
+func (gs *GroupServer) findGroup(ctx context.Context, uID, gID string) (uuid.UUID, *group.Group, error) {
+	userID, err := uuid.Parse(uID)
+	if err != nil {
+		return uuid.Nil, nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	groupID, err := uuid.Parse(gID)
+	if err != nil {
+		return uuid.Nil, nil, status.Error(codes.InvalidArgument, "invalid group id")
+	}
+
+	g, err := gs.App.Groups.FindOne(ctx, groupID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	if !g.HasMember(userID) {
+		return uuid.Nil, nil, status.Error(codes.NotFound, "group not found")
+	}
+
+	return userID, g, nil
+}
+
+// Helper to parse ids of the invitation request.
+func parseInvitationRequest(req *proto.GroupRequest) (uuid.UUID, uuid.UUID, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	groupID, err := uuid.Parse(req.GroupId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, "invalid group id")
+	}
+
+	return userID, groupID, nil
+}
+
+func hasInvitation(invitations []group.Invitation, groupID uuid.UUID) bool {
+	for _, inv := range invitations {
+		if inv.GroupID == groupID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func convertGroupToProto(g *group.Group) *proto.Group {
+	members := make([]*proto.GroupMember, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, &proto.GroupMember{
+			UserId: m.UserID.String(),
+			Email:  m.Email,
+			Role:   m.Role,
+		})
+	}
+
+	return &proto.Group{
+		Id:      g.ID.String(),
+		Name:    g.Name,
+		OwnerId: g.OwnerID.String(),
+		Members: members,
+	}
+}
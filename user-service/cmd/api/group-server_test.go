@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samgozman/validity.red/user/mocks"
+	proto "github.com/samgozman/validity.red/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGroupServer_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.CreateGroupRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "should create group",
+			req:  &proto.CreateGroupRequest{UserId: mocks.TestOwnerID.String(), Name: "Family"},
+		},
+		{
+			name:     "should fail if name is empty",
+			req:      &proto.CreateGroupRequest{UserId: mocks.TestOwnerID.String()},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "should fail if user id is invalid",
+			req:      &proto.CreateGroupRequest{UserId: "justWrongId", Name: "Family"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GroupServer{App: &testApp}
+			got, err := gs.Create(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("GroupServer.Create() error = %v, want code %v", err, tt.wantCode)
+				return
+			}
+			if err == nil && (got.Group.OwnerId != tt.req.UserId || len(got.Group.Members) != 1) {
+				t.Errorf("GroupServer.Create() = %v, want group with the owner", got.Group)
+			}
+		})
+	}
+}
+
+func TestGroupServer_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.GroupRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "should delete group by owner",
+			req:  &proto.GroupRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String()},
+		},
+		{
+			name:     "should fail if user is not the owner",
+			req:      &proto.GroupRequest{UserId: mocks.TestMemberID.String(), GroupId: mocks.TestGroupID.String()},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "should not find group of other users",
+			req:      &proto.GroupRequest{UserId: "458c9061-5262-48b7-9b87-e47fa64d654c", GroupId: mocks.TestGroupID.String()},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "should fail if group id is invalid",
+			req:      &proto.GroupRequest{UserId: mocks.TestOwnerID.String(), GroupId: "justWrongId"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GroupServer{App: &testApp}
+			_, err := gs.Delete(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("GroupServer.Delete() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGroupServer_GetAll(t *testing.T) {
+	gs := &GroupServer{App: &testApp}
+
+	got, err := gs.GetAll(context.Background(), &proto.GetGroupsRequest{UserId: mocks.TestMemberID.String()})
+	if err != nil {
+		t.Fatalf("GroupServer.GetAll() error = %v", err)
+	}
+
+	if len(got.Groups) != 1 || len(got.Groups[0].Members) != 2 || got.Groups[0].Members[1].Email != "member@example.com" {
+		t.Errorf("GroupServer.GetAll() = %v, want group with members", got.Groups)
+	}
+}
+
+func TestGroupServer_InviteMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.GroupMemberRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "should invite registered user",
+			req:  &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String(), Email: "new@example.com"},
+		},
+		{
+			name: "should not disclose that email is not registered",
+			req:  &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String(), Email: "nobody@example.com"},
+		},
+		{
+			// Mocked user with any email is the owner
+			name: "should not disclose that user is already a member",
+			req:  &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String(), Email: "me@example.com"},
+		},
+		{
+			name:     "should fail if user is not the owner",
+			req:      &proto.GroupMemberRequest{UserId: mocks.TestMemberID.String(), GroupId: mocks.TestGroupID.String(), Email: "new@example.com"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "should fail if email is empty",
+			req:      &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String()},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GroupServer{App: &testApp}
+			_, err := gs.InviteMember(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("GroupServer.InviteMember() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGroupServer_GetInvitations(t *testing.T) {
+	gs := &GroupServer{App: &testApp}
+
+	got, err := gs.GetInvitations(context.Background(), &proto.GetGroupsRequest{UserId: mocks.TestInviteeID.String()})
+	if err != nil {
+		t.Fatalf("GroupServer.GetInvitations() error = %v", err)
+	}
+
+	if len(got.Invitations) != 1 || got.Invitations[0].GroupId != mocks.TestGroupID.String() || got.Invitations[0].OwnerEmail != "me@example.com" {
+		t.Errorf("GroupServer.GetInvitations() = %v, want invitation to the group", got.Invitations)
+	}
+}
+
+func TestGroupServer_AcceptInvitation(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.GroupRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "should accept invitation",
+			req:  &proto.GroupRequest{UserId: mocks.TestInviteeID.String(), GroupId: mocks.TestGroupID.String()},
+		},
+		{
+			name:     "should not join group without invitation",
+			req:      &proto.GroupRequest{UserId: "458c9061-5262-48b7-9b87-e47fa64d654c", GroupId: mocks.TestGroupID.String()},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "should fail if group id is invalid",
+			req:      &proto.GroupRequest{UserId: mocks.TestInviteeID.String(), GroupId: "justWrongId"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GroupServer{App: &testApp}
+			_, err := gs.AcceptInvitation(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("GroupServer.AcceptInvitation() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGroupServer_DeclineInvitation(t *testing.T) {
+	gs := &GroupServer{App: &testApp}
+
+	_, err := gs.DeclineInvitation(context.Background(), &proto.GroupRequest{UserId: mocks.TestInviteeID.String(), GroupId: mocks.TestGroupID.String()})
+	if err != nil {
+		t.Errorf("GroupServer.DeclineInvitation() error = %v", err)
+	}
+
+	_, err = gs.DeclineInvitation(context.Background(), &proto.GroupRequest{UserId: mocks.TestMemberID.String(), GroupId: mocks.TestGroupID.String()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GroupServer.DeclineInvitation() error = %v, want code %v", err, codes.NotFound)
+	}
+}
+
+func TestGroupServer_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *proto.GroupMemberRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "should remove member by owner",
+			req:  &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String(), MemberId: mocks.TestMemberID.String()},
+		},
+		{
+			name: "should leave group",
+			req:  &proto.GroupMemberRequest{UserId: mocks.TestMemberID.String(), GroupId: mocks.TestGroupID.String(), MemberId: mocks.TestMemberID.String()},
+		},
+		{
+			name:     "should fail if member removes the owner",
+			req:      &proto.GroupMemberRequest{UserId: mocks.TestMemberID.String(), GroupId: mocks.TestGroupID.String(), MemberId: mocks.TestOwnerID.String()},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "should fail if member id is invalid",
+			req:      &proto.GroupMemberRequest{UserId: mocks.TestOwnerID.String(), GroupId: mocks.TestGroupID.String(), MemberId: "justWrongId"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := &GroupServer{App: &testApp}
+			_, err := gs.RemoveMember(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("GroupServer.RemoveMember() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
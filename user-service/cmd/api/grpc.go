@@ -2,15 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
 	"github.com/samgozman/validity.red/user/internal/models/user"
+	"github.com/samgozman/validity.red/user/internal/totp"
+	"github.com/samgozman/validity.red/user/pkg/encryption"
 	proto "github.com/samgozman/validity.red/user/proto"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
@@ -33,6 +38,11 @@ type UserServer struct {
 
 var gRPCPort = os.Getenv("GRPC_PORT")
 
+const (
+	totpIssuer             = "validity.red"
+	totpRecoveryCodesCount = 10
+)
+
 func (app *Config) gRPCListen() {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", gRPCPort))
 	if err != nil {
@@ -48,6 +58,9 @@ func (app *Config) gRPCListen() {
 	proto.RegisterUserServiceServer(s, &UserServer{
 		App: app,
 	})
+	proto.RegisterGroupServiceServer(s, &GroupServer{
+		App: app,
+	})
 
 	log.Printf("GRPC server listening on port %s", gRPCPort)
 
@@ -84,7 +97,7 @@ func (as *AuthServer) Login(ctx context.Context, req *proto.AuthRequest) (*proto
 	input := req.GetAuthEntry()
 
 	// find user
-	u, err := as.App.Repo.FindOne(ctx, &user.User{Email: input.Email}, "id, password, calendar_id, timezone, is_verified")
+	u, err := as.App.Repo.FindOne(ctx, &user.User{Email: input.Email}, "id, password, calendar_id, timezone, is_verified, totp_enabled")
 	if err != nil {
 		return nil, err
 	}
@@ -98,10 +111,11 @@ func (as *AuthServer) Login(ctx context.Context, req *proto.AuthRequest) (*proto
 	// return response
 	res := &proto.AuthResponse{
 		// TODO: Return user entity
-		UserId:     u.ID.String(),
-		CalendarId: u.CalendarID,
-		Timezone:   u.Timezone,
-		IsVerified: u.IsVerified,
+		UserId:      u.ID.String(),
+		CalendarId:  u.CalendarID,
+		Timezone:    u.Timezone,
+		IsVerified:  u.IsVerified,
+		TotpEnabled: u.TOTPEnabled,
 	}
 
 	return res, nil
@@ -164,5 +178,379 @@ func (us *UserServer) SetIsVerified(ctx context.Context, req *proto.SetIsVerifie
 	return &emptypb.Empty{}, nil
 }
 
+// GetUser finds the user with the given email or id.
+func (us *UserServer) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.GetUserResponse, error) {
+	query := &user.User{}
+
+	switch {
+	case req.UserId != "":
+		userID, err := uuid.Parse(req.UserId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid user id")
+		}
+
+		query.ID = userID
+	case req.Email != "":
+		query.Email = req.Email
+	default:
+		return nil, status.Error(codes.InvalidArgument, "email or user id is required")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, query, "id, email, is_verified, timezone")
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GetUserResponse{
+		UserId:     u.ID.String(),
+		Email:      u.Email,
+		IsVerified: u.IsVerified,
+		Timezone:   u.Timezone,
+	}, nil
+}
+
+// SetPassword hashes the new password and sets it for the user with the given id.
+func (us *UserServer) SetPassword(ctx context.Context, req *proto.SetPasswordRequest) (*emptypb.Empty, error) {
+	err := user.ValidatePassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := user.Hash(req.Password)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"password": string(hashedPassword),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// UpdateProfile updates user settings which do not require password confirmation.
+func (us *UserServer) UpdateProfile(ctx context.Context, req *proto.UpdateProfileRequest) (*emptypb.Empty, error) {
+	if req.Timezone == "" {
+		return nil, status.Error(codes.InvalidArgument, "timezone is required")
+	}
+
+	err := us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"timezone": req.Timezone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ChangePassword sets the new password after the current one is confirmed.
+func (us *UserServer) ChangePassword(ctx context.Context, req *proto.ChangePasswordRequest) (*emptypb.Empty, error) {
+	err := user.ValidatePassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.confirmPassword(ctx, req.UserId, req.CurrentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.SetPassword(ctx, &proto.SetPasswordRequest{
+		UserId:   req.UserId,
+		Password: req.NewPassword,
+	})
+}
+
+// ChangeEmail saves the new email as pending after the current password is confirmed.
+// Email is changed only after the new address is confirmed with ConfirmEmail.
+func (us *UserServer) ChangeEmail(ctx context.Context, req *proto.ChangeEmailRequest) (*emptypb.Empty, error) {
+	email := user.NormalizeEmail(req.NewEmail)
+
+	err := user.ValidateEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.confirmPassword(ctx, req.UserId, req.CurrentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that email is not taken by another user
+	_, err = us.App.Repo.FindOne(ctx, &user.User{Email: email}, "id")
+	if err == nil {
+		return nil, status.Error(codes.AlreadyExists, "email is already taken")
+	}
+
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+		return nil, err
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"pending_email": email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ConfirmEmail replaces user's email with the pending one.
+func (us *UserServer) ConfirmEmail(ctx context.Context, req *proto.ConfirmEmailRequest) (*proto.ConfirmEmailResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: userID}, "pending_email")
+	if err != nil {
+		return nil, err
+	}
+
+	if u.PendingEmail == "" {
+		return nil, status.Error(codes.FailedPrecondition, "no pending email change")
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"email":         u.PendingEmail,
+		"pending_email": "",
+		"is_verified":   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ConfirmEmailResponse{
+		Email: u.PendingEmail,
+	}, nil
+}
+
+// DeleteUser permanently deletes the user with the given id, the user's groups are deleted as well.
+func (us *UserServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*emptypb.Empty, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	err = us.App.Groups.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.App.Repo.Delete(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// EnrollTOTP generates new TOTP secret for the user.
+// Two-factor authentication is enabled only after the first code is confirmed with ConfirmTOTP.
+func (us *UserServer) EnrollTOTP(ctx context.Context, req *proto.EnrollTOTPRequest) (*proto.EnrollTOTPResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: userID}, "email, totp_enabled")
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	encryptedSecret, err := encryption.Encrypt(us.App.EncryptionKey, secret)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"totp_secret": encryptedSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.EnrollTOTPResponse{
+		Secret: secret,
+		Uri:    totp.URI(secret, totpIssuer, u.Email),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication if the code matches the enrolled secret
+// and returns new recovery codes. Recovery codes are shown only once.
+func (us *UserServer) ConfirmTOTP(ctx context.Context, req *proto.ConfirmTOTPRequest) (*proto.ConfirmTOTPResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: userID}, "totp_secret, totp_enabled")
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	if u.TOTPSecret == "" {
+		return nil, status.Error(codes.FailedPrecondition, "two-factor authentication enrollment is not started")
+	}
+
+	secret, err := encryption.Decrypt(us.App.EncryptionKey, u.TOTPSecret)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	step, ok := totp.Validate(secret, req.Code, time.Now(), 0)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid two-factor code")
+	}
+
+	recoveryCodes, err := totp.GenerateRecoveryCodes(totpRecoveryCodesCount)
+	if err != nil {
+		sentry.CaptureException(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	hashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashes[i] = totp.HashRecoveryCode(code)
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"totp_enabled":        true,
+		"totp_last_step":      step,
+		"totp_recovery_codes": strings.Join(hashes, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// VerifyTOTP checks the second factor code during login.
+// Accepts either TOTP code or one of the recovery codes, which is deleted after use.
+func (us *UserServer) VerifyTOTP(ctx context.Context, req *proto.VerifyTOTPRequest) (*emptypb.Empty, error) {
+	err := us.verifySecondFactor(ctx, req.UserId, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DisableTOTP disables two-factor authentication after both password and code are confirmed.
+func (us *UserServer) DisableTOTP(ctx context.Context, req *proto.DisableTOTPRequest) (*emptypb.Empty, error) {
+	err := us.confirmPassword(ctx, req.UserId, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.verifySecondFactor(ctx, req.UserId, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"totp_enabled":        false,
+		"totp_secret":         "",
+		"totp_last_step":      0,
+		"totp_recovery_codes": "",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Helper to check TOTP or recovery code of the user with enabled two-factor authentication.
+// Used code is saved, so it can not be used again.
+func (us *UserServer) verifySecondFactor(ctx context.Context, userID, code string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: id}, "totp_secret, totp_enabled, totp_last_step, totp_recovery_codes")
+	if err != nil {
+		return err
+	}
+
+	if !u.TOTPEnabled {
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	}
+
+	secret, err := encryption.Decrypt(us.App.EncryptionKey, u.TOTPSecret)
+	if err != nil {
+		sentry.CaptureException(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now(), u.TOTPLastStep); ok {
+		return us.App.Repo.Update(ctx, userID, map[string]interface{}{
+			"totp_last_step": step,
+		})
+	}
+
+	// Try recovery codes
+	codeHash := totp.HashRecoveryCode(code)
+	hashes := strings.Split(u.TOTPRecoveryCodes, ",")
+
+	for i, hash := range hashes {
+		if hash == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(codeHash)) != 1 {
+			continue
+		}
+
+		hashes = append(hashes[:i], hashes[i+1:]...)
+
+		return us.App.Repo.Update(ctx, userID, map[string]interface{}{
+			"totp_recovery_codes": strings.Join(hashes, ","),
+		})
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid two-factor code")
+}
+
+// Helper to check that the given password matches the user's one.
+func (us *UserServer) confirmPassword(ctx context.Context, userID, password string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: id}, "password")
+	if err != nil {
+		return err
+	}
+
+	if user.VerifyPassword(u.Password, password) != nil {
+		return status.Error(codes.PermissionDenied, "wrong password")
+	}
+
+	return nil
+}
+
 // TODO: Combine all set & get methods
-// TODO: Edit - edit user info
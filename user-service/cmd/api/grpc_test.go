@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 
 	proto "github.com/samgozman/validity.red/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestUserServer_Register(t *testing.T) {
@@ -125,3 +129,518 @@ func TestAuthServer_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestUserServer_GetUser(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.GetUserRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *proto.GetUserResponse
+		wantErr bool
+	}{
+		{
+			name:   "should find user by email",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.GetUserRequest{Email: "me@example.com"},
+			},
+			want: &proto.GetUserResponse{
+				UserId:     "434377cf-7509-4cc0-9895-0afa683f0e56",
+				Email:      "me@example.com",
+				IsVerified: true,
+				Timezone:   "Europe/London",
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should find user by id",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.GetUserRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56"},
+			},
+			want: &proto.GetUserResponse{
+				UserId:     "434377cf-7509-4cc0-9895-0afa683f0e56",
+				Email:      "me@example.com",
+				IsVerified: true,
+				Timezone:   "Europe/London",
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if user id is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.GetUserRequest{UserId: "justWrongId"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "should fail if email and user id are empty",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.GetUserRequest{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.GetUser(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.GetUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.GetUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_SetPassword(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.SetPasswordRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:   "should set password",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.SetPasswordRequest{
+					UserId:   "434377cf-7509-4cc0-9895-0afa683f0e56",
+					Password: "newPassword",
+				},
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if password is too short",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.SetPasswordRequest{
+					UserId:   "434377cf-7509-4cc0-9895-0afa683f0e56",
+					Password: "short",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.SetPassword(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.SetPassword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.SetPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_UpdateProfile(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.UpdateProfileRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:   "should update timezone",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.UpdateProfileRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56", Timezone: "Europe/Paris"},
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if timezone is empty",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.UpdateProfileRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.UpdateProfile(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.UpdateProfile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.UpdateProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_ChangePassword(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.ChangePasswordRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:   "should fail if new password is too short",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ChangePasswordRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56", CurrentPassword: "fooPassword", NewPassword: "short"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "should fail if current password is wrong",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ChangePasswordRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56", CurrentPassword: "wrongPassword", NewPassword: "newPassword"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.ChangePassword(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.ChangePassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_ChangeEmail(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.ChangeEmailRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:   "should fail if new email is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ChangeEmailRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56", CurrentPassword: "fooPassword", NewEmail: "bonk12345"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "should fail if current password is wrong",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ChangeEmailRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56", CurrentPassword: "wrongPassword", NewEmail: "new@example.com"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.ChangeEmail(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.ChangeEmail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.ChangeEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_ConfirmEmail(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.ConfirmEmailRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *proto.ConfirmEmailResponse
+		wantErr bool
+	}{
+		{
+			name:   "should fail if there is no pending email",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ConfirmEmailRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "should fail if user id is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.ConfirmEmailRequest{UserId: "justWrongId"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.ConfirmEmail(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.ConfirmEmail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.ConfirmEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_DeleteUser(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.DeleteUserRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:   "should delete user",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DeleteUserRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56"},
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if user id is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.DeleteUserRequest{UserId: "justWrongId"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.DeleteUser(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.DeleteUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.DeleteUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_EnrollTOTP(t *testing.T) {
+	type fields struct {
+		App                            *Config
+		UnimplementedUserServiceServer proto.UnimplementedUserServiceServer
+	}
+
+	type args struct {
+		ctx context.Context
+		req *proto.EnrollTOTPRequest
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:   "should generate secret",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.EnrollTOTPRequest{UserId: "434377cf-7509-4cc0-9895-0afa683f0e56"},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should fail if user id is invalid",
+			fields: fields{App: &testApp},
+			args: args{
+				ctx: context.Background(),
+				req: &proto.EnrollTOTPRequest{UserId: "justWrongId"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{
+				App:                            tt.fields.App,
+				UnimplementedUserServiceServer: tt.fields.UnimplementedUserServiceServer,
+			}
+			got, err := us.EnrollTOTP(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.EnrollTOTP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Secret == "" || !strings.HasPrefix(got.Uri, "otpauth://totp/validity.red:me@example.com?") {
+				t.Errorf("UserServer.EnrollTOTP() = %v, want secret and otpauth uri", got)
+			}
+		})
+	}
+}
+
+func TestUserServer_ConfirmTOTP(t *testing.T) {
+	us := &UserServer{App: &testApp}
+
+	// Mocked user has no enrolled secret
+	_, err := us.ConfirmTOTP(context.Background(), &proto.ConfirmTOTPRequest{
+		UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		Code:   "123456",
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("UserServer.ConfirmTOTP() error = %v, want FailedPrecondition", err)
+	}
+}
+
+func TestUserServer_VerifyTOTP(t *testing.T) {
+	us := &UserServer{App: &testApp}
+
+	// Mocked user has two-factor authentication disabled
+	_, err := us.VerifyTOTP(context.Background(), &proto.VerifyTOTPRequest{
+		UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		Code:   "123456",
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("UserServer.VerifyTOTP() error = %v, want FailedPrecondition", err)
+	}
+
+	_, err = us.VerifyTOTP(context.Background(), &proto.VerifyTOTPRequest{
+		UserId: "justWrongId",
+		Code:   "123456",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UserServer.VerifyTOTP() error = %v, want InvalidArgument", err)
+	}
+}
@@ -6,13 +6,16 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/validity.red/user/internal/models/group"
 	"github.com/samgozman/validity.red/user/internal/models/user"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type Config struct {
-	Repo user.UserRepository
+	Repo          user.UserRepository
+	Groups        group.GroupRepository
+	EncryptionKey []byte // Key for the sensitive user fields, like TOTP secret
 }
 
 func main() {
@@ -39,8 +42,15 @@ func main() {
 		panic(err)
 	}
 
+	err = db.AutoMigrate(&group.Group{}, &group.Member{}, &group.Invitation{})
+	if err != nil {
+		panic(err)
+	}
+
 	// Create app
-	app := Config{}
+	app := Config{
+		EncryptionKey: []byte(os.Getenv("ENCRYPTION_KEY")),
+	}
 	app.setupRepo(db)
 
 	// Start gRPC server
@@ -78,4 +88,5 @@ func connectToDB() *gorm.DB {
 func (app *Config) setupRepo(conn *gorm.DB) {
 	db := user.NewPostgresRepository(conn)
 	app.Repo = db
+	app.Groups = group.NewPostgresRepository(conn)
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/user/internal/models/user"
+	proto "github.com/samgozman/validity.red/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Max number of the digest recipients returned by one GetDigestRecipients call.
+const MaxDigestRecipientsPageSize = 1000
+
+// Digest frequencies stored in the users table.
+var digestFrequencies = map[proto.DigestFrequency]string{
+	proto.DigestFrequency_WEEKLY:  user.DigestWeekly,
+	proto.DigestFrequency_MONTHLY: user.DigestMonthly,
+}
+
+// GetSettings returns the user's email digest settings.
+func (us *UserServer) GetSettings(ctx context.Context, req *proto.GetSettingsRequest) (*proto.Settings, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	u, err := us.App.Repo.FindOne(ctx, &user.User{ID: userID}, "digest_enabled, digest_frequency")
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.Settings{
+		DigestEnabled:   u.DigestEnabled,
+		DigestFrequency: digestFrequencyToProto(u.DigestFrequency),
+	}, nil
+}
+
+// UpdateSettings opts the user in or out of the email digest and sets its frequency.
+func (us *UserServer) UpdateSettings(ctx context.Context, req *proto.UpdateSettingsRequest) (*emptypb.Empty, error) {
+	if _, err := uuid.Parse(req.UserId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	if req.Settings == nil {
+		return nil, status.Error(codes.InvalidArgument, "settings are required")
+	}
+
+	frequency, ok := digestFrequencies[req.Settings.DigestFrequency]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid digest frequency")
+	}
+
+	err := us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"digest_enabled":   req.Settings.DigestEnabled,
+		"digest_frequency": frequency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetDigestRecipients returns the page of the verified users which have the digest enabled.
+func (us *UserServer) GetDigestRecipients(
+	ctx context.Context,
+	req *proto.GetDigestRecipientsRequest,
+) (*proto.GetDigestRecipientsResponse, error) {
+	if req.Limit <= 0 || req.Limit > MaxDigestRecipientsPageSize {
+		return nil, status.Error(codes.InvalidArgument, "invalid page size")
+	}
+
+	afterID := uuid.Nil
+
+	if req.AfterUserId != "" {
+		id, err := uuid.Parse(req.AfterUserId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid user id")
+		}
+
+		afterID = id
+	}
+
+	users, err := us.App.Repo.FindDigestRecipients(ctx, afterID, int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]*proto.DigestRecipient, 0, len(users))
+
+	for _, u := range users {
+		r := &proto.DigestRecipient{
+			UserId:          u.ID.String(),
+			Email:           u.Email,
+			Timezone:        u.Timezone,
+			DigestFrequency: digestFrequencyToProto(u.DigestFrequency),
+		}
+
+		if u.DigestSentAt != nil {
+			r.DigestSentAt = timestamppb.New(*u.DigestSentAt)
+		}
+
+		recipients = append(recipients, r)
+	}
+
+	return &proto.GetDigestRecipientsResponse{
+		Recipients: recipients,
+	}, nil
+}
+
+// SetDigestSent saves the time when the digest was sent to the user, so it is not sent twice for the same period.
+func (us *UserServer) SetDigestSent(ctx context.Context, req *proto.SetDigestSentRequest) (*emptypb.Empty, error) {
+	if _, err := uuid.Parse(req.UserId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	if req.SentAt == nil {
+		return nil, status.Error(codes.InvalidArgument, "sent_at is required")
+	}
+
+	err := us.App.Repo.Update(ctx, req.UserId, map[string]interface{}{
+		"digest_sent_at": req.SentAt.AsTime(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func digestFrequencyToProto(frequency string) proto.DigestFrequency {
+	if frequency == user.DigestMonthly {
+		return proto.DigestFrequency_MONTHLY
+	}
+
+	return proto.DigestFrequency_WEEKLY
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	proto "github.com/samgozman/validity.red/user/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestUserServer_UpdateSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *proto.UpdateSettingsRequest
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name: "should enable monthly digest",
+			req: &proto.UpdateSettingsRequest{
+				UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+				Settings: &proto.Settings{
+					DigestEnabled:   true,
+					DigestFrequency: proto.DigestFrequency_MONTHLY,
+				},
+			},
+			want:    &emptypb.Empty{},
+			wantErr: false,
+		},
+		{
+			name: "should fail if settings are not set",
+			req: &proto.UpdateSettingsRequest{
+				UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail if frequency is unknown",
+			req: &proto.UpdateSettingsRequest{
+				UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+				Settings: &proto.Settings{
+					DigestEnabled:   true,
+					DigestFrequency: proto.DigestFrequency(42),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail if userId is incorrect",
+			req: &proto.UpdateSettingsRequest{
+				UserId:   "justWrongId",
+				Settings: &proto.Settings{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserServer{App: &testApp}
+			got, err := us.UpdateSettings(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserServer.UpdateSettings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserServer.UpdateSettings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_GetDigestRecipients(t *testing.T) {
+	us := &UserServer{App: &testApp}
+
+	res, err := us.GetDigestRecipients(context.Background(), &proto.GetDigestRecipientsRequest{Limit: 100})
+	if err != nil {
+		t.Fatalf("UserServer.GetDigestRecipients() error = %v", err)
+	}
+
+	want := []*proto.DigestRecipient{
+		{
+			UserId:          "434377cf-7509-4cc0-9895-0afa683f0e56",
+			Email:           "me@example.com",
+			Timezone:        "Europe/London",
+			DigestFrequency: proto.DigestFrequency_MONTHLY,
+		},
+	}
+	if !reflect.DeepEqual(res.Recipients, want) {
+		t.Errorf("UserServer.GetDigestRecipients() = %v, want %v", res.Recipients, want)
+	}
+
+	// Next page is empty
+	res, err = us.GetDigestRecipients(context.Background(), &proto.GetDigestRecipientsRequest{
+		AfterUserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		Limit:       100,
+	})
+	if err != nil || len(res.Recipients) != 0 {
+		t.Errorf("UserServer.GetDigestRecipients() next page = %v, %v, want empty", res, err)
+	}
+
+	_, err = us.GetDigestRecipients(context.Background(), &proto.GetDigestRecipientsRequest{})
+	if err == nil {
+		t.Errorf("UserServer.GetDigestRecipients() should fail without limit")
+	}
+}
+
+func TestUserServer_SetDigestSent(t *testing.T) {
+	us := &UserServer{App: &testApp}
+
+	_, err := us.SetDigestSent(context.Background(), &proto.SetDigestSentRequest{
+		UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+		SentAt: timestamppb.Now(),
+	})
+	if err != nil {
+		t.Errorf("UserServer.SetDigestSent() error = %v", err)
+	}
+
+	_, err = us.SetDigestSent(context.Background(), &proto.SetDigestSentRequest{
+		UserId: "434377cf-7509-4cc0-9895-0afa683f0e56",
+	})
+	if err == nil {
+		t.Errorf("UserServer.SetDigestSent() should fail without sentAt")
+	}
+}
@@ -12,6 +12,8 @@ var testApp Config
 func TestMain(m *testing.M) {
 	repo := mocks.NewPostgresTestRepository(nil)
 	testApp.Repo = repo
+	testApp.Groups = mocks.NewPostgresTestGroupRepository(nil)
+	testApp.EncryptionKey = []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj")
 
 	os.Exit(m.Run())
 }
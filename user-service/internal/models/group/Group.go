@@ -0,0 +1,411 @@
+// Package group contains households or family groups of users to share documents with.
+// Group is managed by its owner, who is also the first member of the group.
+// Other users become members only after accepting the owner's invitation.
+package group
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const NameMaxLength = 100
+
+// How long the invitation can be accepted.
+const InvitationTTL = 7 * 24 * time.Hour
+
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+)
+
+type PostgresRepository struct {
+	Conn *gorm.DB
+}
+
+func NewPostgresRepository(db *gorm.DB) *PostgresRepository {
+	return &PostgresRepository{
+		Conn: db,
+	}
+}
+
+type Group struct {
+	ID      uuid.UUID `gorm:"type:uuid;primarykey" json:"id,omitempty"`
+	Name    string    `gorm:"size:100;not null;" json:"name,omitempty"`
+	OwnerID uuid.UUID `gorm:"type:uuid;index;not null;" json:"owner_id,omitempty"`
+	Members []Member  `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"members,omitempty"`
+	// Pending invitations, only used to create the foreign key
+	Invitations []Invitation `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;references:ID" json:"-"`
+	CreatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+type Member struct {
+	GroupID   uuid.UUID `gorm:"type:uuid;primarykey" json:"group_id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;primarykey;index" json:"user_id,omitempty"`
+	Role      string    `gorm:"size:10;not null;" json:"role,omitempty"`
+	Email     string    `gorm:"->;-:migration" json:"email,omitempty"` // Selected from the users table
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (Member) TableName() string {
+	return "group_members"
+}
+
+// Invitation of the user to the group, replaced by the membership when accepted.
+type Invitation struct {
+	GroupID    uuid.UUID `gorm:"type:uuid;primarykey" json:"group_id,omitempty"`
+	UserID     uuid.UUID `gorm:"type:uuid;primarykey;index" json:"user_id,omitempty"`
+	GroupName  string    `gorm:"->;-:migration" json:"group_name,omitempty"`  // Selected from the groups table
+	OwnerEmail string    `gorm:"->;-:migration" json:"owner_email,omitempty"` // Selected from the users table
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (Invitation) TableName() string {
+	return "group_invitations"
+}
+
+// Validate Group object before inserting into database.
+func (g *Group) Validate() error {
+	if g.OwnerID == uuid.Nil {
+		return status.Error(codes.InvalidArgument, "owner id is required")
+	}
+
+	if strings.TrimSpace(g.Name) == "" {
+		return status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if len([]rune(g.Name)) > NameMaxLength {
+		return status.Error(codes.InvalidArgument, "name is too long, must be less than 100 characters")
+	}
+
+	return nil
+}
+
+func (g *Group) BeforeCreate(tx *gorm.DB) error {
+	// Create UUID ID
+	g.ID = uuid.New()
+	g.Name = strings.TrimSpace(g.Name)
+
+	return g.Validate()
+}
+
+// Check if the user is a member of the group.
+func (g *Group) HasMember(userID uuid.UUID) bool {
+	for _, m := range g.Members {
+		if m.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Insert the group with its owner as the first member.
+func (r *PostgresRepository) InsertOne(ctx context.Context, g *Group) error {
+	return r.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Table("groups").Omit("Members", "Invitations").Create(g)
+		if res.Error != nil {
+			if _, ok := status.FromError(res.Error); ok {
+				return res.Error
+			}
+
+			sentry.CaptureException(res.Error)
+
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		owner := Member{GroupID: g.ID, UserID: g.OwnerID, Role: RoleOwner}
+
+		res = tx.Table("group_members").Create(&owner)
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		g.Members = []Member{owner}
+
+		return nil
+	})
+}
+
+// Find the group by id with its members.
+func (r *PostgresRepository) FindOne(ctx context.Context, groupID uuid.UUID) (*Group, error) {
+	g := &Group{}
+
+	res := r.Conn.WithContext(ctx).
+		Table("groups").
+		Where("id = ?", groupID).
+		First(g)
+
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "group not found")
+		}
+
+		sentry.CaptureException(res.Error)
+
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	members, err := r.findMembers(ctx, []uuid.UUID{g.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	g.Members = members
+
+	return g, nil
+}
+
+// Find all groups which the user is a member of with their members.
+func (r *PostgresRepository) FindAll(ctx context.Context, userID uuid.UUID) ([]Group, error) {
+	var groups = []Group{}
+
+	res := r.Conn.WithContext(ctx).
+		Table("groups").
+		Where("id IN (?)", r.Conn.Table("group_members").Select("group_id").Where("user_id = ?", userID)).
+		Order("created_at ASC").
+		Find(&groups)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+
+	members, err := r.findMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		for _, m := range members {
+			if m.GroupID == groups[i].ID {
+				groups[i].Members = append(groups[i].Members, m)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// Find members of the groups with their emails, the owner first.
+func (r *PostgresRepository) findMembers(ctx context.Context, groupIDs []uuid.UUID) ([]Member, error) {
+	var members []Member
+
+	res := r.Conn.WithContext(ctx).
+		Table("group_members").
+		Select("group_members.*, users.email").
+		Joins("JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id IN ?", groupIDs).
+		Order("group_members.role = 'owner' DESC").
+		Order("group_members.created_at ASC").
+		Find(&members)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return members, nil
+}
+
+// Permanently delete the group with its members.
+func (r *PostgresRepository) Delete(ctx context.Context, groupID uuid.UUID) error {
+	res := r.Conn.WithContext(ctx).
+		Table("groups").
+		Where("id = ?", groupID).
+		Delete(&Group{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "group not found")
+	}
+
+	return nil
+}
+
+// Invite the user to the group. Invitation sent again is renewed.
+func (r *PostgresRepository) Invite(ctx context.Context, inv *Invitation) error {
+	inv.CreatedAt = time.Now()
+
+	res := r.Conn.WithContext(ctx).
+		Table("group_invitations").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "group_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"created_at"}),
+		}).
+		Create(inv)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return nil
+}
+
+// Count the group's invitations created after the given time.
+func (r *PostgresRepository) CountInvitations(ctx context.Context, groupID uuid.UUID, after time.Time) (int64, error) {
+	var count int64
+
+	res := r.Conn.WithContext(ctx).
+		Table("group_invitations").
+		Where("group_id = ? AND created_at > ?", groupID, after).
+		Count(&count)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return 0, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return count, nil
+}
+
+// Find the user's invitations created after the given time with the group names and owners emails.
+func (r *PostgresRepository) FindInvitations(ctx context.Context, userID uuid.UUID, after time.Time) ([]Invitation, error) {
+	var invitations = []Invitation{}
+
+	res := r.Conn.WithContext(ctx).
+		Table("group_invitations").
+		Select("group_invitations.*, groups.name AS group_name, users.email AS owner_email").
+		Joins("JOIN groups ON groups.id = group_invitations.group_id").
+		Joins("JOIN users ON users.id = groups.owner_id").
+		Where("group_invitations.user_id = ? AND group_invitations.created_at > ?", userID, after).
+		Order("group_invitations.created_at DESC").
+		Find(&invitations)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return invitations, nil
+}
+
+// Accept the user's invitation created after the given time and add the user to the group.
+func (r *PostgresRepository) AcceptInvitation(ctx context.Context, groupID, userID uuid.UUID, after time.Time) error {
+	return r.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("group_invitations").
+			Where("group_id = ? AND user_id = ? AND created_at > ?", groupID, userID, after).
+			Delete(&Invitation{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		if res.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "invitation not found")
+		}
+
+		res = tx.
+			Table("group_members").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Member{GroupID: groupID, UserID: userID, Role: RoleMember})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
+
+// Delete the user's invitation to the group.
+func (r *PostgresRepository) DeleteInvitation(ctx context.Context, groupID, userID uuid.UUID) error {
+	res := r.Conn.WithContext(ctx).
+		Table("group_invitations").
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&Invitation{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "invitation not found")
+	}
+
+	return nil
+}
+
+// Remove the user from the group.
+func (r *PostgresRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	res := r.Conn.WithContext(ctx).
+		Table("group_members").
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&Member{})
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "member not found")
+	}
+
+	return nil
+}
+
+// Delete the groups owned by the user, remove the user from the other groups and delete the user's invitations.
+func (r *PostgresRepository) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Table("group_members").
+			Where("user_id = ?", userID).
+			Delete(&Member{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("group_invitations").
+			Where("user_id = ?", userID).
+			Delete(&Invitation{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		res = tx.
+			Table("groups").
+			Where("owner_id = ?", userID).
+			Delete(&Group{})
+
+		if res.Error != nil {
+			sentry.CaptureException(res.Error)
+			return status.Error(codes.Internal, res.Error.Error())
+		}
+
+		return nil
+	})
+}
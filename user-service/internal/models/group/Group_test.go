@@ -0,0 +1,57 @@
+package group
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGroup_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   Group
+		wantErr bool
+	}{
+		{
+			name:    "owner null check",
+			group:   Group{Name: "Family"},
+			wantErr: true,
+		},
+		{
+			name:    "name empty check",
+			group:   Group{OwnerID: uuid.New(), Name: "  "},
+			wantErr: true,
+		},
+		{
+			name:    "name length check",
+			group:   Group{OwnerID: uuid.New(), Name: strings.Repeat("a", NameMaxLength+1)},
+			wantErr: true,
+		},
+		{
+			name:    "should pass",
+			group:   Group{OwnerID: uuid.New(), Name: "Family"},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.group.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Group.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroup_HasMember(t *testing.T) {
+	member := uuid.New()
+	g := Group{Members: []Member{{UserID: member, Role: RoleMember}}}
+
+	if !g.HasMember(member) {
+		t.Errorf("Group.HasMember() = false, want true")
+	}
+
+	if g.HasMember(uuid.New()) {
+		t.Errorf("Group.HasMember() = true, want false")
+	}
+}
@@ -0,0 +1,22 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GroupRepository interface {
+	InsertOne(ctx context.Context, g *Group) error
+	FindOne(ctx context.Context, groupID uuid.UUID) (*Group, error)
+	FindAll(ctx context.Context, userID uuid.UUID) ([]Group, error)
+	Delete(ctx context.Context, groupID uuid.UUID) error
+	Invite(ctx context.Context, inv *Invitation) error
+	CountInvitations(ctx context.Context, groupID uuid.UUID, after time.Time) (int64, error)
+	FindInvitations(ctx context.Context, userID uuid.UUID, after time.Time) ([]Invitation, error)
+	AcceptInvitation(ctx context.Context, groupID, userID uuid.UUID, after time.Time) error
+	DeleteInvitation(ctx context.Context, groupID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+	DeleteAllForUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -23,6 +23,12 @@ const PasswordMinLength = 8
 const PasswordMaxLength = 64
 const IVCalendarLength = 12
 
+// Frequencies of the email digest.
+const (
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+)
+
 type PostgresRepository struct {
 	Conn *gorm.DB
 }
@@ -35,20 +41,30 @@ func NewPostgresRepository(db *gorm.DB) *PostgresRepository {
 
 type User struct {
 	// Id will be set as primaryKey by default
-	ID         uuid.UUID `gorm:"type:uuid" json:"id,omitempty"`
-	Email      string    `gorm:"uniqueIndex;size:100;not null;" json:"email,omitempty"`
-	Password   string    `gorm:"not null;" json:"password"`
-	IsVerified bool      `gorm:"type:bool;default:false;not null;" json:"is_verified"`
-	CalendarID string    `gorm:"uniqueIndex;size:32;" json:"calendar_id,omitempty"`
-	IVCalendar []byte    `gorm:"size:12;" json:"iv_calendar,omitempty"`
-	Timezone   string    `gorm:"size:50;default:Etc/UTC" json:"timezone,omitempty"`
-	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
-	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	ID           uuid.UUID `gorm:"type:uuid" json:"id,omitempty"`
+	Email        string    `gorm:"uniqueIndex;size:100;not null;" json:"email,omitempty"`
+	Password     string    `gorm:"not null;" json:"password"`
+	IsVerified   bool      `gorm:"type:bool;default:false;not null;" json:"is_verified"`
+	CalendarID   string    `gorm:"uniqueIndex;size:32;" json:"calendar_id,omitempty"`
+	IVCalendar   []byte    `gorm:"size:12;" json:"iv_calendar,omitempty"`
+	Timezone     string    `gorm:"size:50;default:Etc/UTC" json:"timezone,omitempty"`
+	PendingEmail string    `gorm:"size:100;" json:"pending_email,omitempty"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	// Two-factor authentication
+	TOTPSecret        string `gorm:"size:128;" json:"-"` // Encrypted TOTP secret
+	TOTPEnabled       bool   `gorm:"type:bool;default:false;not null;" json:"totp_enabled"`
+	TOTPLastStep      int64  `gorm:"default:0;not null;" json:"-"` // Time step of the last accepted code
+	TOTPRecoveryCodes string `gorm:"type:text;" json:"-"`          // Comma separated hashes of unused recovery codes
+	// Email digest of the upcoming expirations
+	DigestEnabled   bool       `gorm:"type:bool;default:false;not null;" json:"digest_enabled"`
+	DigestFrequency string     `gorm:"size:10;default:weekly;not null;" json:"digest_frequency,omitempty"` // DigestWeekly or DigestMonthly
+	DigestSentAt    *time.Time `gorm:"" json:"-"`                                                          // Time of the last sent digest
 }
 
 // Prepare User object before inserting into database.
 func (user *User) Prepare() {
-	user.Email = html.EscapeString(strings.TrimSpace(user.Email))
+	user.Email = NormalizeEmail(user.Email)
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 	user.CalendarID = GenerateRandomString(CalendarIDLength)
@@ -60,22 +76,41 @@ func (user *User) Validate() error {
 		return status.Error(codes.InvalidArgument, "email is required")
 	}
 
-	if user.Password == "" {
+	if err := ValidatePassword(user.Password); err != nil {
+		return err
+	}
+
+	return ValidateEmail(user.Email)
+}
+
+// ValidateEmail checks email format.
+func ValidateEmail(email string) error {
+	if err := checkmail.ValidateFormat(email); err != nil {
+		return status.Error(codes.InvalidArgument, "invalid email")
+	}
+
+	return nil
+}
+
+// Escape and trim email before saving.
+func NormalizeEmail(email string) string {
+	return html.EscapeString(strings.TrimSpace(email))
+}
+
+// ValidatePassword checks plain password before hashing.
+func ValidatePassword(password string) error {
+	if password == "" {
 		return status.Error(codes.InvalidArgument, "password is required")
 	}
 
-	if len(user.Password) < PasswordMinLength {
+	if len(password) < PasswordMinLength {
 		return status.Error(codes.InvalidArgument, "password is too short, must be at least 8 characters")
 	}
 
-	if len(user.Password) > PasswordMaxLength {
+	if len(password) > PasswordMaxLength {
 		return status.Error(codes.InvalidArgument, "password is too long, must be between 8 - 64 characters")
 	}
 
-	if err := checkmail.ValidateFormat(user.Email); err != nil {
-		return status.Error(codes.InvalidArgument, "invalid email")
-	}
-
 	return nil
 }
 
@@ -193,6 +228,10 @@ func (u *PostgresRepository) Update(ctx context.Context, userID string, fields m
 			return status.Error(codes.InvalidArgument, "invalid user data")
 		}
 
+		if strings.Contains(res.Error.Error(), "SQLSTATE 23505") {
+			return status.Error(codes.AlreadyExists, "user is already exists")
+		}
+
 		sentry.CaptureException(res.Error)
 
 		return status.Error(codes.Internal, res.Error.Error())
@@ -204,3 +243,41 @@ func (u *PostgresRepository) Update(ctx context.Context, userID string, fields m
 
 	return nil
 }
+
+// Find page of the verified users with the enabled digest ordered by id, starting after afterID.
+func (u *PostgresRepository) FindDigestRecipients(ctx context.Context, afterID uuid.UUID, limit int) ([]User, error) {
+	var users = []User{}
+
+	res := u.Conn.WithContext(ctx).
+		Table("users").
+		Select("id, email, timezone, digest_frequency, digest_sent_at").
+		Where("is_verified AND digest_enabled AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&users)
+
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return nil, status.Error(codes.Internal, res.Error.Error())
+	}
+
+	return users, nil
+}
+
+// Permanently delete user by id.
+func (u *PostgresRepository) Delete(ctx context.Context, userID string) error {
+	res := u.Conn.WithContext(ctx).
+		Table("users").
+		Where("id = ?", userID).
+		Delete(&User{})
+	if res.Error != nil {
+		sentry.CaptureException(res.Error)
+		return status.Error(codes.Internal, res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "user not found")
+	}
+
+	return nil
+}
@@ -103,6 +103,42 @@ func TestUser_Validate(t *testing.T) {
 	}
 }
 
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{
+			name:     "fail if password is empty",
+			password: "",
+			wantErr:  true,
+		},
+		{
+			name:     "fail if password is too short",
+			password: "bonk",
+			wantErr:  true,
+		},
+		{
+			name:     "fail if password is too long",
+			password: "168601827c50b37054f4e565dbf4050a6bd854bc91650280539cca45bae1fb2f1",
+			wantErr:  true,
+		},
+		{
+			name:     "should pass",
+			password: "fooPassword",
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePassword(tt.password); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestHash(t *testing.T) {
 	type args struct {
 		password string
@@ -2,10 +2,14 @@ package user
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type UserRepository interface {
 	InsertOne(ctx context.Context, user *User) error
 	FindOne(ctx context.Context, query *User, fields string) (*User, error)
 	Update(ctx context.Context, userID string, fields map[string]interface{}) error
+	Delete(ctx context.Context, userID string) error
+	FindDigestRecipients(ctx context.Context, afterID uuid.UUID, limit int) ([]User, error)
 }
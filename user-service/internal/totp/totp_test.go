@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret from RFC 6238 test vectors (SHA1).
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// Last 6 digits of the RFC 6238 test vectors
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("Code() at %d = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	code, _ := Code(rfcSecret, current)
+	previous, _ := Code(rfcSecret, current-1)
+	tooOld, _ := Code(rfcSecret, current-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		want     bool
+	}{
+		{name: "should accept current code", code: code, want: true},
+		{name: "should accept previous code", code: previous, want: true},
+		{name: "should reject old code", code: tooOld, want: false},
+		{name: "should reject wrong code", code: "000000", want: false},
+		{name: "should reject malformed code", code: "12345", want: false},
+		{name: "should reject already used code", code: code, lastStep: current, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := Validate(rfcSecret, tt.code, now, tt.lastStep)
+			if got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	seen := map[string]bool{}
+
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("GenerateRecoveryCodes() code %v has wrong format", code)
+		}
+
+		if seen[code] {
+			t.Errorf("GenerateRecoveryCodes() code %v is not unique", code)
+		}
+
+		seen[code] = true
+	}
+
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToLower(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Errorf("HashRecoveryCode() should ignore case, spaces and dashes")
+	}
+}
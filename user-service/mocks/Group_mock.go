@@ -0,0 +1,114 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samgozman/validity.red/user/internal/models/group"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// Existing group of the mocked user with one more member and one invited user.
+var (
+	TestGroupID   = uuid.MustParse("6f1c4a3e-6a51-4b4e-8a59-0c3d7a6f2b10")
+	TestOwnerID   = uuid.MustParse("434377cf-7509-4cc0-9895-0afa683f0e56")
+	TestMemberID  = uuid.MustParse("2a0f1f0e-0e0f-4b43-9c66-5d5a1c0b0c6e")
+	TestInviteeID = uuid.MustParse("9d6c1b2e-3f4a-4c5d-8e7f-0a1b2c3d4e5f")
+)
+
+type PostgresTestGroupRepository struct {
+	Conn *gorm.DB
+}
+
+func NewPostgresTestGroupRepository(db *gorm.DB) *PostgresTestGroupRepository {
+	return &PostgresTestGroupRepository{
+		Conn: db,
+	}
+}
+
+func testGroup() group.Group {
+	return group.Group{
+		ID:      TestGroupID,
+		Name:    "Family",
+		OwnerID: TestOwnerID,
+		Members: []group.Member{
+			{GroupID: TestGroupID, UserID: TestOwnerID, Role: group.RoleOwner, Email: "me@example.com"},
+			{GroupID: TestGroupID, UserID: TestMemberID, Role: group.RoleMember, Email: "member@example.com"},
+		},
+	}
+}
+
+func (r *PostgresTestGroupRepository) InsertOne(ctx context.Context, g *group.Group) error {
+	g.ID = uuid.New()
+	g.Members = []group.Member{{GroupID: g.ID, UserID: g.OwnerID, Role: group.RoleOwner}}
+
+	return g.Validate()
+}
+
+func (r *PostgresTestGroupRepository) FindOne(ctx context.Context, groupID uuid.UUID) (*group.Group, error) {
+	if groupID != TestGroupID {
+		return nil, status.Error(codes.NotFound, "group not found")
+	}
+
+	g := testGroup()
+
+	return &g, nil
+}
+
+func (r *PostgresTestGroupRepository) FindAll(ctx context.Context, userID uuid.UUID) ([]group.Group, error) {
+	g := testGroup()
+	if !g.HasMember(userID) {
+		return []group.Group{}, nil
+	}
+
+	return []group.Group{g}, nil
+}
+
+func (r *PostgresTestGroupRepository) Delete(ctx context.Context, groupID uuid.UUID) error {
+	return nil
+}
+
+func (r *PostgresTestGroupRepository) Invite(ctx context.Context, inv *group.Invitation) error {
+	return nil
+}
+
+func (r *PostgresTestGroupRepository) CountInvitations(ctx context.Context, groupID uuid.UUID, after time.Time) (int64, error) {
+	if groupID == TestGroupID {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func (r *PostgresTestGroupRepository) FindInvitations(ctx context.Context, userID uuid.UUID, after time.Time) ([]group.Invitation, error) {
+	if userID != TestInviteeID {
+		return []group.Invitation{}, nil
+	}
+
+	return []group.Invitation{
+		{GroupID: TestGroupID, UserID: TestInviteeID, GroupName: "Family", OwnerEmail: "me@example.com"},
+	}, nil
+}
+
+func (r *PostgresTestGroupRepository) AcceptInvitation(ctx context.Context, groupID, userID uuid.UUID, after time.Time) error {
+	return r.DeleteInvitation(ctx, groupID, userID)
+}
+
+func (r *PostgresTestGroupRepository) DeleteInvitation(ctx context.Context, groupID, userID uuid.UUID) error {
+	if groupID != TestGroupID || userID != TestInviteeID {
+		return status.Error(codes.NotFound, "invitation not found")
+	}
+
+	return nil
+}
+
+func (r *PostgresTestGroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	return nil
+}
+
+func (r *PostgresTestGroupRepository) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
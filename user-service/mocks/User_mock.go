@@ -2,6 +2,8 @@ package mocks
 
 import (
 	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,6 +27,11 @@ func (u *PostgresTestRepository) InsertOne(ctx context.Context, user *user.User)
 }
 
 func (u *PostgresTestRepository) FindOne(ctx context.Context, query *user.User, fields string) (*user.User, error) {
+	// Email which is not registered
+	if query.Email == "nobody@example.com" {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
 	userID, _ := uuid.Parse("434377cf-7509-4cc0-9895-0afa683f0e56")
 
 	user := &user.User{
@@ -45,3 +52,26 @@ func (u *PostgresTestRepository) FindOne(ctx context.Context, query *user.User,
 func (u *PostgresTestRepository) Update(ctx context.Context, userID string, fields map[string]interface{}) error {
 	return nil
 }
+
+func (u *PostgresTestRepository) Delete(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (u *PostgresTestRepository) FindDigestRecipients(ctx context.Context, afterID uuid.UUID, limit int) ([]user.User, error) {
+	userID, _ := uuid.Parse("434377cf-7509-4cc0-9895-0afa683f0e56")
+
+	users := []user.User{
+		{
+			ID:              userID,
+			Email:           "me@example.com",
+			Timezone:        "Europe/London",
+			DigestFrequency: user.DigestMonthly,
+		},
+	}
+
+	if afterID == userID {
+		return []user.User{}, nil
+	}
+
+	return users, nil
+}
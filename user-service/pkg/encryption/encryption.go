@@ -0,0 +1,61 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrMalformedCipherText = errors.New("malformed cipher text")
+
+// Encrypt "text" with AES-GCM and return hex encoded nonce and cipher text.
+//
+// "key" - should be the AES key, either 16, 24, or 32 bytes to select AES-128, AES-192, or AES-256.
+func Encrypt(key []byte, text string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(text), nil)), nil
+}
+
+// Decrypt "cipherText" produced by Encrypt with the same key.
+func Decrypt(key []byte, cipherText string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := hex.DecodeString(cipherText)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", ErrMalformedCipherText
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	text, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(text), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,38 @@
+package encryption
+
+import (
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := []byte("f149VI7P9EsUkirKOnGNy9YKQtbZKEAj")
+	text := "JBSWY3DPEHPK3PXP"
+
+	cipherText, err := Encrypt(key, text)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if cipherText == text {
+		t.Fatalf("Encrypt() returned plain text")
+	}
+
+	got, err := Decrypt(key, cipherText)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+
+	if got != text {
+		t.Errorf("Decrypt() = %v, want %v", got, text)
+	}
+
+	_, err = Decrypt([]byte("8dHWTNSAsGaaD7JbqVubF1aWVWGJYF3q"), cipherText)
+	if err == nil {
+		t.Errorf("Decrypt() with wrong key should fail")
+	}
+
+	_, err = Decrypt(key, "abc")
+	if err == nil {
+		t.Errorf("Decrypt() of malformed text should fail")
+	}
+}